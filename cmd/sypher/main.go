@@ -4,6 +4,7 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -23,6 +24,10 @@ import (
 	"github.com/sypherexx/sypher-mini/pkg/extensions"
 	"github.com/sypherexx/sypher-mini/pkg/monitor"
 	"github.com/sypherexx/sypher-mini/pkg/observability"
+	"github.com/sypherexx/sypher-mini/pkg/secrets"
+	"github.com/sypherexx/sypher-mini/pkg/session"
+	"github.com/sypherexx/sypher-mini/pkg/tools"
+	"github.com/sypherexx/sypher-mini/pkg/usage"
 )
 
 var version = "dev"
@@ -65,6 +70,12 @@ func main() {
 		replayCmd(args)
 	case "cancel":
 		cancelCmd(args)
+	case "sessions":
+		sessionsCmd(args)
+	case "usage":
+		usageCmd(args)
+	case "secrets":
+		secretsCmd(args)
 	case "onboard":
 		onboardCmd()
 	case "whatsapp":
@@ -99,6 +110,9 @@ Commands:
   audit      Show audit log (audit show <task_id>)
   replay     Replay stored task (replay <task_id>)
   cancel     Cancel a running task (cancel <task_id>)
+  sessions   Conversation history (sessions list | show <key> | clear <key>)
+  usage      Token usage and estimated cost (usage [--by agent|session|task|provider|model] [--period today|month|all])
+  secrets    Manage stored secrets (secrets set <name> [value] | get <name> | list | rm <name>)
   onboard    Initialize config and workspace
   whatsapp   WhatsApp setup (whatsapp --connect)
   install-service  Install auto-start service (systemd/launchd/Task Scheduler)
@@ -131,6 +145,7 @@ func agentCmd(args []string, safeMode bool) {
 	msgBus := bus.NewMessageBus(100)
 	eventBus := bus.New()
 	loop := agent.NewLoop(cfg, msgBus, eventBus, &agent.LoopOptions{SafeMode: safeMode})
+	registerExtensionTools(loop, safeMode)
 
 	// Start async event dispatcher
 	ctx, cancel := context.WithCancel(context.Background())
@@ -148,12 +163,31 @@ func agentCmd(args []string, safeMode bool) {
 				Content:  msg,
 				SenderID: "cli",
 			})
-			// Process one message
-			out, ok := msgBus.SubscribeOutbound(ctx)
-			if ok {
+			// Print streamed chunks until the final message. Approval
+			// prompts are answered from stdin.
+			stdin := bufio.NewReader(os.Stdin)
+			answered := make(map[string]bool)
+			for {
+				out, ok := msgBus.SubscribeOutbound(ctx)
+				if !ok {
+					return
+				}
+				if out.Partial {
+					if !out.Replace {
+						fmt.Print(out.Content)
+					}
+					continue
+				}
 				fmt.Println(out.Content)
+				if out.ApprovalID != "" {
+					if !answered[out.ApprovalID] {
+						answered[out.ApprovalID] = true
+						answerApproval(msgBus, stdin, out.ApprovalID)
+					}
+					continue
+				}
+				return
 			}
-			return
 		}
 	}
 
@@ -173,12 +207,31 @@ func agentCmd(args []string, safeMode bool) {
 	_ = loop.Run(ctx)
 }
 
+// answerApproval asks on stdin and sends the answer back as a cli message.
+// Anything but y/yes denies, including EOF.
+func answerApproval(msgBus *bus.MessageBus, stdin *bufio.Reader, id string) {
+	fmt.Print("Approve? [y/N] ")
+	line, _ := stdin.ReadString('\n')
+	answer := "deny"
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		answer = "approve"
+	}
+	msgBus.PublishInbound(bus.InboundMessage{
+		Channel:  "cli",
+		ChatID:   "cli",
+		Content:  answer + " " + id,
+		SenderID: "cli",
+	})
+}
+
 func gatewayCmd(args []string, safeMode bool) {
 	cfg := loadConfig()
 
 	msgBus := bus.NewMessageBus(100)
 	eventBus := bus.New()
 	loop := agent.NewLoop(cfg, msgBus, eventBus, &agent.LoopOptions{SafeMode: safeMode})
+	registerExtensionTools(loop, safeMode)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -198,6 +251,7 @@ func gatewayCmd(args []string, safeMode bool) {
 
 	health := observability.NewHealthChecker()
 	health.Set("core", "ok")
+	loop.ReportProviderHealth(health)
 	mux := http.NewServeMux()
 	mux.Handle("/health", health.Handler())
 	if m := loop.Metrics(); m != nil {
@@ -238,10 +292,11 @@ func gatewayCmd(args []string, safeMode bool) {
 			return
 		}
 		var payload struct {
-			Type    string `json:"type"`
-			From    string `json:"from"`
-			Content string `json:"content"`
-			ChatID  string `json:"chat_id"`
+			Type    string   `json:"type"`
+			From    string   `json:"from"`
+			Content string   `json:"content"`
+			ChatID  string   `json:"chat_id"`
+			Media   []string `json:"media"` // attachment URLs served by the extension
 		}
 		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 			http.Error(w, "Bad request", http.StatusBadRequest)
@@ -256,6 +311,7 @@ func gatewayCmd(args []string, safeMode bool) {
 			ChatID:   chatID,
 			Content:  payload.Content,
 			SenderID: payload.From,
+			Media:    payload.Media,
 		})
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]bool{"ok": true})
@@ -425,6 +481,23 @@ func setConfigPath(cfg *config.Config, path []string, valStr string) error {
 		cfg.Task.TimeoutSec = v
 		return nil
 	}
+	// String fields; api_key usually takes a secret://name reference
+	fields := map[string]*string{
+		"secrets.backend":             &cfg.Secrets.Backend,
+		"secrets.file":                &cfg.Secrets.File,
+		"secrets.key_file":            &cfg.Secrets.KeyFile,
+		"providers.cerebras.api_key":  &cfg.Providers.Cerebras.APIKey,
+		"providers.openai.api_key":    &cfg.Providers.OpenAI.APIKey,
+		"providers.anthropic.api_key": &cfg.Providers.Anthropic.APIKey,
+		"providers.gemini.api_key":    &cfg.Providers.Gemini.APIKey,
+		"providers.ollama.api_key":    &cfg.Providers.Ollama.APIKey,
+		"providers.llamacpp.api_key":  &cfg.Providers.LlamaCpp.APIKey,
+		"media.transcription.api_key": &cfg.Media.Transcription.APIKey,
+	}
+	if f, ok := fields[strings.Join(path, ".")]; ok {
+		*f = valStr
+		return nil
+	}
 	return fmt.Errorf("set not supported for path: %s", strings.Join(path, "."))
 }
 
@@ -553,6 +626,212 @@ func cancelCmd(args []string) {
 	}
 }
 
+func secretsCmd(args []string) {
+	usage := "Usage: sypher secrets set <name> [value] | sypher secrets get <name> | sypher secrets list | sypher secrets rm <name>"
+	if len(args) < 1 || (args[0] != "list" && len(args) < 2) {
+		fmt.Println(usage)
+		return
+	}
+	cfg := loadConfig()
+	r := cfg.SecretResolver()
+
+	switch args[0] {
+	case "set":
+		name, value := args[1], ""
+		if len(args) > 2 {
+			value = args[2]
+		} else {
+			// Read from stdin so the value stays out of the shell history
+			fmt.Fprint(os.Stderr, "Value: ")
+			line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			value = strings.TrimRight(line, "\r\n")
+		}
+		if value == "" {
+			fmt.Fprintln(os.Stderr, "Secrets set error: empty value")
+			os.Exit(1)
+		}
+		if err := r.Set(name, value); err != nil {
+			fmt.Fprintf(os.Stderr, "Secrets set error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Stored %s (%s backend). Reference it as %s%s\n", name, r.Backend(), secrets.RefPrefix, name)
+	case "get":
+		v, err := r.Lookup(args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Secrets get error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(v)
+	case "list":
+		if r.Backend() == secrets.BackendEnv {
+			fmt.Println("The env backend reads environment variables and stores nothing; set secrets.backend to keychain, secret_service or file")
+			return
+		}
+		names, err := r.List()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Secrets list error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(names) == 0 {
+			fmt.Printf("No secrets (%s backend)\n", r.Backend())
+			return
+		}
+		fmt.Printf("Secrets (%s backend):\n", r.Backend())
+		for _, name := range names {
+			fmt.Printf("  %s\n", name)
+		}
+	case "rm":
+		if err := r.Delete(args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Secrets rm error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Removed %s\n", args[1])
+	default:
+		fmt.Println(usage)
+	}
+}
+
+func sessionsCmd(args []string) {
+	usage := "Usage: sypher sessions list | sypher sessions show <key> | sypher sessions clear <key>"
+	if len(args) < 1 {
+		fmt.Println(usage)
+		return
+	}
+	cfg := loadConfig()
+	workspace := config.ExpandPath(cfg.Agents.Defaults.Workspace)
+	if workspace == "" {
+		workspace = config.ExpandPath("~/.sypher-mini/workspace")
+	}
+	store := session.NewStore(filepath.Join(workspace, "sessions"), cfg.Session.MaxMessages)
+
+	switch args[0] {
+	case "list":
+		infos, err := store.List()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Sessions list error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(infos) == 0 {
+			fmt.Printf("No sessions (%s)\n", store.Dir())
+			return
+		}
+		fmt.Println("Sessions:")
+		for _, info := range infos {
+			fmt.Printf("  %s  (%d messages, updated %s)\n", info.Key, info.Messages, info.UpdatedAt.Format(time.RFC3339))
+		}
+	case "show":
+		if len(args) < 2 {
+			fmt.Println(usage)
+			return
+		}
+		sess, err := store.Get(args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Session not found: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Session %s (updated %s)\n", sess.Key, sess.UpdatedAt.Format(time.RFC3339))
+		for _, m := range sess.Messages {
+			switch {
+			case len(m.ToolCalls) > 0:
+				for _, tc := range m.ToolCalls {
+					args, _ := json.Marshal(tc.Arguments)
+					fmt.Printf("[%s] tool_call %s %s(%s)\n", m.Role, tc.ID, tc.Name, string(args))
+				}
+				if m.Content != "" {
+					fmt.Printf("[%s] %s\n", m.Role, m.Content)
+				}
+			case m.Role == "tool":
+				fmt.Printf("[tool %s] %s\n", m.ToolCallID, m.Content)
+			default:
+				fmt.Printf("[%s] %s\n", m.Role, m.Content)
+			}
+		}
+	case "clear":
+		if len(args) < 2 {
+			fmt.Println(usage)
+			return
+		}
+		if err := store.Clear(args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Clear failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Session %s cleared\n", args[1])
+	default:
+		fmt.Println(usage)
+	}
+}
+
+func usageCmd(args []string) {
+	by, period := "agent", "month"
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--by":
+			if i+1 < len(args) {
+				by = args[i+1]
+				i++
+			}
+		case "--period":
+			if i+1 < len(args) {
+				period = args[i+1]
+				i++
+			}
+		default:
+			fmt.Println("Usage: sypher usage [--by agent|session|task|provider|model] [--period today|month|all]")
+			return
+		}
+	}
+	cfg := loadConfig()
+	now := time.Now()
+	var since time.Time
+	switch period {
+	case "today":
+		since = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	case "month":
+		since = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	case "all":
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown period %q (today, month, all)\n", period)
+		os.Exit(1)
+	}
+	records, err := usage.Load(usage.Dir(cfg), since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Usage load error: %v\n", err)
+		os.Exit(1)
+	}
+	summaries, err := usage.Summarize(records, by)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if len(summaries) == 0 {
+		fmt.Printf("No usage recorded (%s, %s)\n", period, usage.Dir(cfg))
+		return
+	}
+	fmt.Printf("Usage by %s (%s):\n", by, period)
+	var total usage.Summary
+	for _, s := range summaries {
+		fmt.Printf("  %-40s %5d req  %9d in  %9d out  $%.4f\n", s.Key, s.Requests, s.PromptTokens, s.CompletionTokens, s.CostUSD)
+		total.Requests += s.Requests
+		total.PromptTokens += s.PromptTokens
+		total.CompletionTokens += s.CompletionTokens
+		total.CostUSD += s.CostUSD
+	}
+	fmt.Printf("  %-40s %5d req  %9d in  %9d out  $%.4f\n", "total", total.Requests, total.PromptTokens, total.CompletionTokens, total.CostUSD)
+
+	if len(cfg.Usage.Budgets) > 0 {
+		tracker := usage.NewTracker(cfg)
+		fmt.Println("Budgets:")
+		for _, b := range cfg.Usage.Budgets {
+			daily, monthly := tracker.Spent(b.AgentID)
+			action := b.Action
+			if action == "" {
+				action = "stop"
+			}
+			fmt.Printf("  %-12s daily $%.2f/$%.2f  monthly $%.2f/$%.2f  (%s)\n", b.AgentID, daily, b.DailyUSD, monthly, b.MonthlyUSD, action)
+		}
+	}
+}
+
 func whatsappCmd(args []string) {
 	connect := false
 	allowFrom := ""
@@ -720,6 +999,29 @@ func extensionsCmd() {
 			caps = " [" + strings.Join(e.Manifest.Capabilities, ", ") + "]"
 		}
 		fmt.Printf("  %s v%s%s\n", e.Manifest.ID, e.Manifest.Version, caps)
+		for _, t := range e.Manifest.Tools {
+			fmt.Printf("    tool %s -> %s\n", t.Name, t.Endpoint)
+		}
+	}
+}
+
+// registerExtensionTools adds the HTTP tools declared by discovered extensions.
+func registerExtensionTools(loop *agent.Loop, safeMode bool) {
+	wd, _ := os.Getwd()
+	exts, err := extensions.DiscoverFromWorkspace(wd)
+	if err != nil {
+		return
+	}
+	for _, e := range exts {
+		for _, spec := range e.Manifest.Tools {
+			if spec.Name == "" || spec.Endpoint == "" {
+				continue
+			}
+			t := tools.NewHTTPTool(spec.Name, spec.Description, spec.Parameters, spec.Capabilities, spec.Endpoint, safeMode)
+			if err := loop.Tools().Register(t); err != nil {
+				fmt.Fprintf(os.Stderr, "Extension %s: %v\n", e.Manifest.ID, err)
+			}
+		}
 	}
 }
 
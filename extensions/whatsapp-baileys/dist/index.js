@@ -5,6 +5,9 @@
  *
  * Protocol: HTTP callback for inbound, HTTP POST for outbound (JSON-RPC style)
  * Config: Core sets extensions.whatsapp_baileys.url (e.g. http://localhost:3002)
+ * Media: images, documents and voice notes are held in memory for MEDIA_TTL_MS
+ * and served at GET /media/<id>/<filename>; the core downloads them from the
+ * URLs in the inbound payload's media list.
  */
 var __createBinding = (this && this.__createBinding) || (Object.create ? (function(o, m, k, k2) {
     if (k2 === undefined) k2 = k;
@@ -44,6 +47,7 @@ var __importDefault = (this && this.__importDefault) || function (mod) {
 };
 Object.defineProperty(exports, "__esModule", { value: true });
 const baileys_1 = __importStar(require("@whiskeysockets/baileys"));
+const crypto_1 = require("crypto");
 const pino_1 = __importDefault(require("pino"));
 const path = __importStar(require("path"));
 const http = __importStar(require("http"));
@@ -51,6 +55,52 @@ const qrcode_terminal_1 = __importDefault(require("qrcode-terminal"));
 const AUTH_DIR = process.env.SYPHER_WHATSAPP_AUTH || path.join(process.env.HOME || process.env.USERPROFILE || '.', '.sypher-mini', 'whatsapp-auth');
 const PORT = parseInt(process.env.PORT || '3002', 10);
 const CORE_CALLBACK = process.env.SYPHER_CORE_CALLBACK || 'http://localhost:18790/inbound';
+const MEDIA_BASE = process.env.SYPHER_MEDIA_BASE || `http://localhost:${PORT}`;
+const MEDIA_TTL_MS = 10 * 60 * 1000;
+const mediaStore = new Map();
+const extensions = {
+    'image/jpeg': 'jpg',
+    'image/png': 'png',
+    'image/webp': 'webp',
+    'image/gif': 'gif',
+    'application/pdf': 'pdf',
+    'text/plain': 'txt',
+    'audio/ogg': 'ogg',
+    'audio/mpeg': 'mp3',
+    'audio/mp4': 'm4a',
+};
+// storeMedia keeps a downloaded attachment and returns its URL.
+function storeMedia(data, mimeType, fileName) {
+    const now = Date.now();
+    for (const [id, m] of mediaStore) {
+        if (m.expires < now)
+            mediaStore.delete(id);
+    }
+    const id = (0, crypto_1.randomUUID)();
+    const base = mimeType.split(';')[0].trim();
+    const name = (fileName || `attachment.${extensions[base] || 'bin'}`).replace(/[^A-Za-z0-9._-]/g, '_');
+    mediaStore.set(id, { data, mimeType: base, expires: now + MEDIA_TTL_MS });
+    return `${MEDIA_BASE}/media/${id}/${encodeURIComponent(name)}`;
+}
+// extractMedia downloads the image, document or audio of m, if any, and
+// returns its URL and caption.
+async function extractMedia(m) {
+    const msg = m.message;
+    const doc = msg?.documentMessage || msg?.documentWithCaptionMessage?.message?.documentMessage;
+    const media = msg?.imageMessage || doc || msg?.audioMessage;
+    if (!media)
+        return null;
+    try {
+        const data = (await (0, baileys_1.downloadMediaMessage)(m, 'buffer', {}));
+        const url = storeMedia(data, media.mimetype || 'application/octet-stream', doc?.fileName || undefined);
+        const caption = msg?.imageMessage?.caption || doc?.caption || '';
+        return { url, caption };
+    }
+    catch (e) {
+        console.error('Failed to download media:', e);
+        return null;
+    }
+}
 let sock = null;
 async function sendToCore(payload) {
     try {
@@ -94,7 +144,18 @@ async function connect() {
         for (const m of messages) {
             if (m.key.fromMe)
                 continue;
-            if (m.message?.conversation || m.message?.extendedTextMessage?.text) {
+            const media = await extractMedia(m);
+            if (media) {
+                const from = m.key.remoteJid || '';
+                await sendToCore({
+                    type: 'inbound',
+                    from,
+                    content: media.caption,
+                    chat_id: from,
+                    media: [media.url],
+                });
+            }
+            else if (m.message?.conversation || m.message?.extendedTextMessage?.text) {
                 const text = m.message?.conversation || m.message?.extendedTextMessage?.text || '';
                 const from = m.key.remoteJid || '';
                 await sendToCore({
@@ -131,6 +192,18 @@ const server = http.createServer(async (req, res) => {
             }
         });
     }
+    else if (req.method === 'GET' && req.url?.startsWith('/media/')) {
+        const id = req.url.split('/')[2] || '';
+        const m = mediaStore.get(id);
+        if (m && m.expires >= Date.now()) {
+            res.writeHead(200, { 'Content-Type': m.mimeType, 'Content-Length': m.data.length });
+            res.end(m.data);
+        }
+        else {
+            res.writeHead(404);
+            res.end();
+        }
+    }
     else {
         res.writeHead(404);
         res.end();
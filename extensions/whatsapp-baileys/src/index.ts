@@ -4,9 +4,13 @@
  *
  * Protocol: HTTP callback for inbound, HTTP POST for outbound (JSON-RPC style)
  * Config: Core sets extensions.whatsapp_baileys.url (e.g. http://localhost:3002)
+ * Media: images, documents and voice notes are held in memory for MEDIA_TTL_MS
+ * and served at GET /media/<id>/<filename>; the core downloads them from the
+ * URLs in the inbound payload's media list.
  */
 
-import makeWASocket, { useMultiFileAuthState, fetchLatestBaileysVersion } from '@whiskeysockets/baileys';
+import makeWASocket, { useMultiFileAuthState, fetchLatestBaileysVersion, downloadMediaMessage, WAMessage } from '@whiskeysockets/baileys';
+import { randomUUID } from 'crypto';
 import pino from 'pino';
 import * as path from 'path';
 import * as http from 'http';
@@ -15,12 +19,66 @@ import qrcode from 'qrcode-terminal';
 const AUTH_DIR = process.env.SYPHER_WHATSAPP_AUTH || path.join(process.env.HOME || process.env.USERPROFILE || '.', '.sypher-mini', 'whatsapp-auth');
 const PORT = parseInt(process.env.PORT || '3002', 10);
 const CORE_CALLBACK = process.env.SYPHER_CORE_CALLBACK || 'http://localhost:18790/inbound';
+const MEDIA_BASE = process.env.SYPHER_MEDIA_BASE || `http://localhost:${PORT}`;
+const MEDIA_TTL_MS = 10 * 60 * 1000;
 
 interface InboundPayload {
   type: string;
   from: string;
   content: string;
   chat_id: string;
+  media?: string[];
+}
+
+interface StoredMedia {
+  data: Buffer;
+  mimeType: string;
+  expires: number;
+}
+
+const mediaStore = new Map<string, StoredMedia>();
+
+const extensions: Record<string, string> = {
+  'image/jpeg': 'jpg',
+  'image/png': 'png',
+  'image/webp': 'webp',
+  'image/gif': 'gif',
+  'application/pdf': 'pdf',
+  'text/plain': 'txt',
+  'audio/ogg': 'ogg',
+  'audio/mpeg': 'mp3',
+  'audio/mp4': 'm4a',
+};
+
+// storeMedia keeps a downloaded attachment and returns its URL.
+function storeMedia(data: Buffer, mimeType: string, fileName?: string): string {
+  const now = Date.now();
+  for (const [id, m] of mediaStore) {
+    if (m.expires < now) mediaStore.delete(id);
+  }
+  const id = randomUUID();
+  const base = mimeType.split(';')[0].trim();
+  const name = (fileName || `attachment.${extensions[base] || 'bin'}`).replace(/[^A-Za-z0-9._-]/g, '_');
+  mediaStore.set(id, { data, mimeType: base, expires: now + MEDIA_TTL_MS });
+  return `${MEDIA_BASE}/media/${id}/${encodeURIComponent(name)}`;
+}
+
+// extractMedia downloads the image, document or audio of m, if any, and
+// returns its URL and caption.
+async function extractMedia(m: WAMessage): Promise<{ url: string; caption: string } | null> {
+  const msg = m.message;
+  const doc = msg?.documentMessage || msg?.documentWithCaptionMessage?.message?.documentMessage;
+  const media = msg?.imageMessage || doc || msg?.audioMessage;
+  if (!media) return null;
+  try {
+    const data = (await downloadMediaMessage(m, 'buffer', {})) as Buffer;
+    const url = storeMedia(data, media.mimetype || 'application/octet-stream', doc?.fileName || undefined);
+    const caption = msg?.imageMessage?.caption || doc?.caption || '';
+    return { url, caption };
+  } catch (e) {
+    console.error('Failed to download media:', e);
+    return null;
+  }
 }
 
 let sock: Awaited<ReturnType<typeof makeWASocket>> | null = null;
@@ -66,7 +124,17 @@ async function connect() {
   sock.ev.on('messages.upsert', async ({ messages }) => {
     for (const m of messages) {
       if (m.key.fromMe) continue;
-      if (m.message?.conversation || m.message?.extendedTextMessage?.text) {
+      const media = await extractMedia(m);
+      if (media) {
+        const from = m.key.remoteJid || '';
+        await sendToCore({
+          type: 'inbound',
+          from,
+          content: media.caption,
+          chat_id: from,
+          media: [media.url],
+        });
+      } else if (m.message?.conversation || m.message?.extendedTextMessage?.text) {
         const text = m.message?.conversation || m.message?.extendedTextMessage?.text || '';
         const from = m.key.remoteJid || '';
         await sendToCore({
@@ -101,6 +169,16 @@ const server = http.createServer(async (req, res) => {
         res.end(JSON.stringify({ error: String(e) }));
       }
     });
+  } else if (req.method === 'GET' && req.url?.startsWith('/media/')) {
+    const id = req.url.split('/')[2] || '';
+    const m = mediaStore.get(id);
+    if (m && m.expires >= Date.now()) {
+      res.writeHead(200, { 'Content-Type': m.mimeType, 'Content-Length': m.data.length });
+      res.end(m.data);
+    } else {
+      res.writeHead(404);
+      res.end();
+    }
   } else {
     res.writeHead(404);
     res.end();
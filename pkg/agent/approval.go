@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sypherexx/sypher-mini/pkg/approval"
+	"github.com/sypherexx/sypher-mini/pkg/bus"
+	"github.com/sypherexx/sypher-mini/pkg/providers"
+	"github.com/sypherexx/sypher-mini/pkg/task"
+	"github.com/sypherexx/sypher-mini/pkg/tools"
+)
+
+// needsApproval reports whether the call matches policies.approval.
+func (l *Loop) needsApproval(agentID string, tc providers.ToolCall) (string, bool) {
+	if l.policyEval == nil {
+		return "", false
+	}
+	return l.policyEval.RequiresApproval(agentID, tc.Name, tc.Arguments)
+}
+
+func (l *Loop) approvalTimeout() time.Duration {
+	if sec := l.cfg.Policies.Approval.TimeoutSec; sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return approval.DefaultTimeout
+}
+
+// awaitApproval pauses the task in awaiting_approval until the user answers
+// the prompt sent to the task's chat. ok is false when the call was denied or
+// the request timed out; resp is then the tool result to return instead.
+func (l *Loop) awaitApproval(ctx context.Context, taskID, agentID string, tc providers.ToolCall) (resp tools.Response, ok bool) {
+	reason, needed := l.needsApproval(agentID, tc)
+	if !needed {
+		return tools.Response{}, true
+	}
+	channel, chatID := l.messageTool.GetReplyTarget(taskID)
+	if channel == "" {
+		channel, chatID = "cli", "cli"
+	}
+	req := l.approvals.Open(approval.Request{
+		TaskID:     taskID,
+		AgentID:    agentID,
+		ToolCallID: tc.ID,
+		Tool:       tc.Name,
+		Summary:    callSummary(tc),
+		Reason:     reason,
+		Channel:    channel,
+		ChatID:     chatID,
+	})
+	timeout := l.approvalTimeout()
+
+	// The wait for the user does not count against the task timeout
+	t, _ := l.taskMgr.Get(taskID)
+	var prev task.State
+	if t != nil {
+		prev = t.GetState()
+		t.Transition(task.StateAwaitingApproval)
+		defer t.PauseDeadline()()
+	}
+	_ = l.eventBus.Publish(ctx, bus.Event{
+		Type: "task.awaiting_approval",
+		Payload: map[string]interface{}{
+			"task_id":     taskID,
+			"agent_id":    agentID,
+			"approval_id": req.ID,
+			"tool":        tc.Name,
+			"summary":     req.Summary,
+		},
+	})
+	l.msgBus.PublishOutbound(bus.OutboundMessage{
+		Channel:    channel,
+		ChatID:     chatID,
+		Content:    req.Prompt(timeout),
+		ApprovalID: req.ID,
+	})
+
+	decision, by := l.approvals.Wait(ctx, req.ID, timeout)
+	if t != nil && t.GetState() == task.StateAwaitingApproval {
+		t.Transition(prev)
+	}
+	if l.auditLogger != nil {
+		_ = l.auditLogger.LogApproval(taskID, tc.ID, tc.Name, string(decision), by, req.Summary)
+	}
+
+	switch decision {
+	case approval.Approved:
+		return tools.Response{}, true
+	case approval.TimedOut:
+		return tools.ErrorResponse(tc.ID,
+			fmt.Sprintf("No approval for this %s call within %s; it was not run", tc.Name, timeout),
+			"Approval timed out.",
+			tools.CodeTimeout, false), false
+	default:
+		return tools.ErrorResponse(tc.ID,
+			fmt.Sprintf("The user denied this %s call; it was not run. Do not retry it without asking", tc.Name),
+			"Tool call denied.",
+			tools.CodePermissionDenied, false), false
+	}
+}
+
+// handleApprovalReply answers an open approval request when msg is a reply
+// to one. It runs before dispatch: the waiting task holds its session, so the
+// reply must not queue behind it.
+func (l *Loop) handleApprovalReply(msg bus.InboundMessage) bool {
+	ack, id, handled := l.approvals.HandleReply(msg.Channel, msg.ChatID, msg.SenderID, msg.Content)
+	if !handled {
+		return false
+	}
+	l.msgBus.PublishOutbound(bus.OutboundMessage{Channel: msg.Channel, ChatID: msg.ChatID, Content: ack, ApprovalID: id})
+	return true
+}
+
+// callSummary describes a tool call for the approval prompt and audit line.
+func callSummary(tc providers.ToolCall) string {
+	if cmd, ok := tc.Arguments["command"].(string); ok {
+		return cmd
+	}
+	data, _ := json.Marshal(tc.Arguments)
+	s := string(data)
+	if len(s) > 300 {
+		s = s[:300] + "..."
+	}
+	return s
+}
@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sypherexx/sypher-mini/pkg/bus"
+	"github.com/sypherexx/sypher-mini/pkg/observability"
+)
+
+// DefaultMaxConcurrent is the worker limit when task.max_concurrent is unset.
+const DefaultMaxConcurrent = 4
+
+// dispatcher runs messages on a bounded worker pool. Messages with the same
+// session key are processed in arrival order; different sessions run in parallel.
+type dispatcher struct {
+	sem     chan struct{}
+	metrics *observability.Metrics
+	handle  func(ctx context.Context, msg bus.InboundMessage)
+	queues  map[string][]bus.InboundMessage // present = session has an active worker
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+}
+
+func newDispatcher(maxConcurrent int, metrics *observability.Metrics, handle func(context.Context, bus.InboundMessage)) *dispatcher {
+	if maxConcurrent <= 0 {
+		maxConcurrent = DefaultMaxConcurrent
+	}
+	return &dispatcher{
+		sem:     make(chan struct{}, maxConcurrent),
+		metrics: metrics,
+		handle:  handle,
+		queues:  make(map[string][]bus.InboundMessage),
+	}
+}
+
+// Submit queues msg behind any in-progress work for the same session.
+func (d *dispatcher) Submit(ctx context.Context, sessionKey string, msg bus.InboundMessage) {
+	if d.metrics != nil {
+		d.metrics.AddQueueDepth(1)
+	}
+	d.mu.Lock()
+	if q, active := d.queues[sessionKey]; active {
+		d.queues[sessionKey] = append(q, msg)
+		d.mu.Unlock()
+		return
+	}
+	d.queues[sessionKey] = nil
+	d.mu.Unlock()
+
+	d.wg.Add(1)
+	go d.drain(ctx, sessionKey, msg)
+}
+
+// drain processes msg and then any messages queued for the same session.
+func (d *dispatcher) drain(ctx context.Context, sessionKey string, msg bus.InboundMessage) {
+	defer d.wg.Done()
+	for {
+		select {
+		case d.sem <- struct{}{}:
+		case <-ctx.Done():
+			d.mu.Lock()
+			dropped := 1 + len(d.queues[sessionKey])
+			delete(d.queues, sessionKey)
+			d.mu.Unlock()
+			if d.metrics != nil {
+				d.metrics.AddQueueDepth(-dropped)
+			}
+			return
+		}
+		if d.metrics != nil {
+			d.metrics.AddQueueDepth(-1)
+			d.metrics.AddInFlight(1)
+		}
+		d.handle(ctx, msg)
+		if d.metrics != nil {
+			d.metrics.AddInFlight(-1)
+		}
+		<-d.sem
+
+		d.mu.Lock()
+		q := d.queues[sessionKey]
+		if len(q) == 0 {
+			delete(d.queues, sessionKey)
+			d.mu.Unlock()
+			return
+		}
+		msg = q[0]
+		d.queues[sessionKey] = q[1:]
+		d.mu.Unlock()
+	}
+}
+
+// Wait blocks until all workers have exited.
+func (d *dispatcher) Wait() {
+	d.wg.Wait()
+}
@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sypherexx/sypher-mini/pkg/bus"
+	"github.com/sypherexx/sypher-mini/pkg/observability"
+)
+
+func TestDispatcher_ParallelSessions(t *testing.T) {
+	entered := make(chan string, 4)
+	release := make(chan struct{})
+	d := newDispatcher(4, observability.NewMetrics(), func(ctx context.Context, msg bus.InboundMessage) {
+		entered <- msg.Content
+		<-release
+	})
+	ctx := context.Background()
+	d.Submit(ctx, "s1", bus.InboundMessage{Content: "a"})
+	d.Submit(ctx, "s2", bus.InboundMessage{Content: "b"})
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-entered:
+		case <-time.After(time.Second):
+			t.Fatal("different sessions should run concurrently")
+		}
+	}
+	close(release)
+	d.Wait()
+}
+
+func TestDispatcher_SameSessionOrdered(t *testing.T) {
+	metrics := observability.NewMetrics()
+	entered := make(chan string, 4)
+	release := make(chan struct{}, 4)
+	d := newDispatcher(4, metrics, func(ctx context.Context, msg bus.InboundMessage) {
+		entered <- msg.Content
+		<-release
+	})
+	ctx := context.Background()
+	d.Submit(ctx, "s1", bus.InboundMessage{Content: "first"})
+	d.Submit(ctx, "s1", bus.InboundMessage{Content: "second"})
+
+	if got := <-entered; got != "first" {
+		t.Fatalf("expected first, got %q", got)
+	}
+	select {
+	case got := <-entered:
+		t.Fatalf("second message started before first finished: %q", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+	snap := metrics.Snapshot()
+	if snap["in_flight"] != 1 || snap["queue_depth"] != 1 {
+		t.Errorf("expected in_flight=1 queue_depth=1, got %v/%v", snap["in_flight"], snap["queue_depth"])
+	}
+
+	release <- struct{}{}
+	if got := <-entered; got != "second" {
+		t.Fatalf("expected second, got %q", got)
+	}
+	release <- struct{}{}
+	d.Wait()
+	if snap := metrics.Snapshot(); snap["in_flight"] != 0 || snap["queue_depth"] != 0 {
+		t.Errorf("expected idle metrics, got %v", snap)
+	}
+}
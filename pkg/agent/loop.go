@@ -3,22 +3,32 @@ package agent
 import (
 	"context"
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/sypherexx/sypher-mini/pkg/approval"
 	"github.com/sypherexx/sypher-mini/pkg/audit"
 	"github.com/sypherexx/sypher-mini/pkg/bus"
+	"github.com/sypherexx/sypher-mini/pkg/capabilities"
 	"github.com/sypherexx/sypher-mini/pkg/config"
 	"github.com/sypherexx/sypher-mini/pkg/idempotency"
 	"github.com/sypherexx/sypher-mini/pkg/intent"
+	"github.com/sypherexx/sypher-mini/pkg/media"
 	"github.com/sypherexx/sypher-mini/pkg/observability"
 	"github.com/sypherexx/sypher-mini/pkg/process"
 	"github.com/sypherexx/sypher-mini/pkg/providers"
 	"github.com/sypherexx/sypher-mini/pkg/routing"
+	"github.com/sypherexx/sypher-mini/pkg/secrets"
 	"github.com/sypherexx/sypher-mini/pkg/task"
 	"github.com/sypherexx/sypher-mini/pkg/tools"
 	"github.com/sypherexx/sypher-mini/pkg/policy"
 	"github.com/sypherexx/sypher-mini/pkg/replay"
+	"github.com/sypherexx/sypher-mini/pkg/session"
+	"github.com/sypherexx/sypher-mini/pkg/usage"
 )
 
 // Loop is the main agent loop that processes inbound messages.
@@ -28,18 +38,24 @@ type Loop struct {
 	eventBus    *bus.Bus
 	taskMgr     *task.Manager
 	provider    providers.LLMProvider
-	execTool       *tools.ExecTool
-	killTool       *tools.KillTool
-	webFetch       *tools.WebFetchTool
+	fallback    *providers.FallbackProvider
+	toolRegistry   *tools.Registry
+	capabilities   *capabilities.Registry
 	messageTool    *tools.MessageTool
-	tailOutput     *tools.TailOutputTool
-	streamCommand  *tools.StreamCommandTool
 	metrics        *observability.Metrics
+	usage          *usage.Tracker
+	media          *media.Store
+	transcriber    media.Transcriber
 	auditLogger *audit.Logger
 	procTracker *process.Tracker
+	jobs        *tools.JobManager
 	policyEval  *policy.Evaluator
+	approvals   *approval.Manager
+	redactor    *secrets.Redactor
 	replayWriter  *replay.Writer
 	idempotency   *idempotency.Cache
+	sessions      *session.Store
+	summarizer    *Summarizer
 	safeMode      bool
 	running       atomic.Bool
 }
@@ -56,7 +72,11 @@ func NewLoop(cfg *config.Config, msgBus *bus.MessageBus, eventBus *bus.Bus, opts
 	}
 	taskMgr := task.NewManager(cfg.Task.TimeoutSec)
 	fb := providers.NewFallbackProvider(cfg)
-	var provider providers.LLMProvider = fb
+	if opts.SafeMode {
+		// No remote APIs in safe mode; local model servers remain usable
+		fb = fb.LocalOnly()
+	}
+	var provider providers.LLMProvider = providers.NewRouter(fb)
 	if len(fb.Entries()) == 0 {
 		provider = nil
 	}
@@ -70,17 +90,59 @@ func NewLoop(cfg *config.Config, msgBus *bus.MessageBus, eventBus *bus.Bus, opts
 		integrity = "none"
 	}
 	auditLogger := audit.NewWithIntegrity(auditDir, integrity)
+	// Known secret values never reach the LLM or the audit log: config keys,
+	// credential-like server variables, and secrets injected into commands.
+	redactor := secrets.NewRedactor(cfg.SecretValues()...)
+	redactor.Add(secrets.EnvValues(os.Environ())...)
+	auditLogger.SetRedact(redactor.Redact)
+	cmdEnv := tools.NewCommandEnv(cfg, nil, redactor)
 	procTracker := process.New()
 	execTool := tools.NewExecTool(cfg, auditLogger, procTracker, opts.SafeMode)
+	execTool.SetCommandEnv(cmdEnv)
 	killTool := tools.NewKillTool(procTracker, opts.SafeMode)
 	policyEval := policy.NewEvaluator(cfg)
 	webFetch := tools.NewWebFetchTool(cfg, policyEval, opts.SafeMode)
 	messageTool := tools.NewMessageTool(msgBus, opts.SafeMode)
 	tailOutput := tools.NewTailOutputTool(cfg, opts.SafeMode)
 	streamCommand := tools.NewStreamCommandTool(cfg, msgBus, messageTool, opts.SafeMode)
+	streamCommand.SetCommandEnv(cmdEnv)
+	readFile := tools.NewReadFileTool(cfg, policyEval, auditLogger, opts.SafeMode)
+	writeFile := tools.NewWriteFileTool(cfg, policyEval, auditLogger, opts.SafeMode)
+	editFile := tools.NewEditFileTool(cfg, policyEval, auditLogger, opts.SafeMode)
+	listDir := tools.NewListDirTool(cfg, policyEval, auditLogger, opts.SafeMode)
+	searchCode := tools.NewSearchCodeTool(cfg, policyEval, auditLogger, opts.SafeMode)
+	gitTool := tools.NewGitTool(cfg, policyEval, auditLogger, opts.SafeMode)
+	gitTool.SetCommandEnv(cmdEnv)
+	jobStatus := tools.NewJobStatusTool(execTool.Jobs())
+	jobOutput := tools.NewJobOutputTool(execTool.Jobs())
+	jobStop := tools.NewJobStopTool(execTool.Jobs(), opts.SafeMode)
 	replayWriter := replay.NewWriter(cfg)
 	metrics := observability.NewMetrics()
 
+	toolRegistry := tools.NewRegistry()
+	for _, t := range []tools.Tool{execTool, killTool, webFetch, messageTool, tailOutput, streamCommand, readFile, writeFile, editFile, listDir, searchCode, gitTool, jobStatus, jobOutput, jobStop} {
+		_ = toolRegistry.Register(t)
+	}
+	env := tools.Env{
+		Config:      cfg,
+		MsgBus:      msgBus,
+		AuditLogger: auditLogger,
+		ProcTracker: procTracker,
+		PolicyEval:  policyEval,
+		SafeMode:    opts.SafeMode,
+	}
+	for _, f := range tools.Factories() {
+		if err := toolRegistry.Register(f(env)); err != nil {
+			log.Printf("Tool registration skipped: %v", err)
+		}
+	}
+
+	caps, err := capabilities.Load("~/.sypher-mini/capabilities.json")
+	if err != nil {
+		log.Printf("Capabilities load failed, using defaults: %v", err)
+		caps = capabilities.DefaultRegistry()
+	}
+
 	var idemCache *idempotency.Cache
 	if cfg.Idempotency.Enabled {
 		ttl := 60
@@ -90,33 +152,54 @@ func NewLoop(cfg *config.Config, msgBus *bus.MessageBus, eventBus *bus.Bus, opts
 		idemCache = idempotency.New(time.Duration(ttl) * time.Second)
 	}
 
+	transcriber, err := media.NewTranscriber(cfg)
+	if err != nil {
+		log.Printf("Transcription disabled: %v", err)
+	} else if transcriber != nil && transcriber.Remote() && opts.SafeMode {
+		log.Printf("Transcription disabled in safe mode (remote backend)")
+		transcriber = nil
+	}
+
+	sessions := session.NewStore(filepath.Join(defaultWorkspace(cfg), "sessions"), cfg.Session.MaxMessages)
+
 	return &Loop{
 		cfg:         cfg,
 		msgBus:      msgBus,
 		eventBus:    eventBus,
 		taskMgr:     taskMgr,
 		provider:    provider,
-		execTool:    execTool,
-		killTool:    killTool,
-		webFetch:      webFetch,
+		fallback:    fb,
+		toolRegistry:  toolRegistry,
+		capabilities:  caps,
 		messageTool:   messageTool,
-		tailOutput:    tailOutput,
-		streamCommand: streamCommand,
 		replayWriter:  replayWriter,
 		idempotency:   idemCache,
+		sessions:      sessions,
+		summarizer:    NewSummarizer(cfg.Context),
 		metrics:       metrics,
+		usage:         usage.NewTracker(cfg),
+		media:         media.NewStore(cfg),
+		transcriber:   transcriber,
 		auditLogger: auditLogger,
 		procTracker: procTracker,
+		jobs:        execTool.Jobs(),
 		policyEval:  policyEval,
+		approvals:   approval.NewManager(cfg.Policies.Approval.Approvers),
+		redactor:    redactor,
 		safeMode:    opts.SafeMode,
 	}
 }
 
 // Run starts the agent loop. It processes inbound messages until ctx is cancelled.
+// Messages run on a worker pool of task.max_concurrent; messages for the same
+// session are processed in order.
 func (l *Loop) Run(ctx context.Context) error {
 	l.running.Store(true)
 	defer l.running.Store(false)
 
+	d := newDispatcher(l.cfg.Task.MaxConcurrent, l.metrics, l.handleMessage)
+	defer d.Wait()
+
 	for l.running.Load() {
 		select {
 		case <-ctx.Done():
@@ -126,120 +209,77 @@ func (l *Loop) Run(ctx context.Context) error {
 			if !ok {
 				continue
 			}
-
-			response, err := l.processMessage(ctx, msg)
-			if err != nil {
-				response = fmt.Sprintf("Error: %v", err)
-			}
-
-			if response != "" {
-				l.msgBus.PublishOutbound(bus.OutboundMessage{
-					Channel: msg.Channel,
-					ChatID:  msg.ChatID,
-					Content: response,
-				})
+			if l.handleApprovalReply(msg) {
+				continue
 			}
+			_, sessionKey := l.resolveRoute(msg)
+			d.Submit(ctx, sessionKey, msg)
 		}
 	}
 
 	return nil
 }
 
-// toolDefinitions returns tool definitions for the LLM.
-func (l *Loop) toolDefinitions() []providers.ToolDefinition {
-	return []providers.ToolDefinition{
-		{
-			Type: "function",
-			Function: providers.ToolFunctionDefinition{
-				Name:        "exec",
-				Description: "Execute a shell command and return its output. Use with caution. Commands run in the workspace.",
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"command":    map[string]interface{}{"type": "string", "description": "The shell command to run"},
-						"working_dir": map[string]interface{}{"type": "string", "description": "Working directory (optional)"},
-					},
-					"required": []interface{}{"command"},
-				},
-			},
-		},
-		{
-			Type: "function",
-			Function: providers.ToolFunctionDefinition{
-				Name:        "kill",
-				Description: "Kill a process started by Sypher-mini for this task. Only PIDs from exec tool can be killed.",
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"pid": map[string]interface{}{"type": "integer", "description": "Process ID to kill"},
-					},
-					"required": []interface{}{"pid"},
-				},
-			},
-		},
-		{
-			Type: "function",
-			Function: providers.ToolFunctionDefinition{
-				Name:        "web_fetch",
-				Description: "Fetch content from a URL. Use for web search or reading web pages.",
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"url": map[string]interface{}{"type": "string", "description": "URL to fetch"},
-					},
-					"required": []interface{}{"url"},
-				},
-			},
-		},
-		{
-			Type: "function",
-			Function: providers.ToolFunctionDefinition{
-				Name:        "message",
-				Description: "Send a message to the user in the current conversation.",
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"content": map[string]interface{}{"type": "string", "description": "Message content to send"},
-					},
-					"required": []interface{}{"content"},
-				},
-			},
-		},
-		{
-			Type: "function",
-			Function: providers.ToolFunctionDefinition{
-				Name:        "tail_output",
-				Description: "Read the last N lines from a file. Use for live log monitoring.",
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"path":  map[string]interface{}{"type": "string", "description": "File path to read"},
-						"lines": map[string]interface{}{"type": "integer", "description": "Number of lines (default 50, max 1000)"},
-					},
-					"required": []interface{}{"path"},
-				},
-			},
-		},
-		{
+// handleMessage processes one message and publishes the response.
+func (l *Loop) handleMessage(ctx context.Context, msg bus.InboundMessage) {
+	sink := l.newStreamSink(msg)
+	response, err := l.processMessage(withStreamSink(ctx, sink), msg)
+	if err != nil {
+		response = fmt.Sprintf("Error: %v", err)
+	}
+
+	if sink != nil {
+		// Always send the final message so the channel knows the stream ended.
+		l.msgBus.PublishOutbound(sink.Finish(response))
+		return
+	}
+	if response != "" {
+		l.msgBus.PublishOutbound(bus.OutboundMessage{
+			Channel: msg.Channel,
+			ChatID:  msg.ChatID,
+			Content: response,
+		})
+	}
+}
+
+// resolveRoute returns the agent ID and session key for an inbound message.
+func (l *Loop) resolveRoute(msg bus.InboundMessage) (agentID, sessionKey string) {
+	route := routing.Resolve(l.cfg, routing.RouteInput{
+		Channel:   msg.Channel,
+		AccountID: msg.SenderID,
+	})
+	agentID = route.AgentID
+	sessionKey = route.SessionKey
+	if sessionKey == "" {
+		sessionKey = "agent:" + agentID + ":" + msg.Channel + ":" + msg.ChatID
+	}
+	return agentID, sessionKey
+}
+
+// toolDefinitions returns definitions of the tools agentID may use.
+func (l *Loop) toolDefinitions(agentID string) []providers.ToolDefinition {
+	var defs []providers.ToolDefinition
+	for _, t := range l.toolRegistry.List() {
+		if !l.toolAllowed(agentID, t) {
+			continue
+		}
+		defs = append(defs, providers.ToolDefinition{
 			Type: "function",
 			Function: providers.ToolFunctionDefinition{
-				Name:        "stream_command",
-				Description: "Run a command and stream output to the user. Only commands in live_monitoring.allowed_commands are permitted.",
-				Parameters: map[string]interface{}{
-					"type": "object",
-					"properties": map[string]interface{}{
-						"command":     map[string]interface{}{"type": "string", "description": "Command to run"},
-						"working_dir": map[string]interface{}{"type": "string", "description": "Working directory (optional)"},
-					},
-					"required": []interface{}{"command"},
-				},
+				Name:        t.Name(),
+				Description: t.Description(),
+				Parameters:  t.Schema(),
 			},
-		},
+		})
 	}
+	return defs
 }
 
 // processMessage handles a single inbound message.
 func (l *Loop) processMessage(ctx context.Context, msg bus.InboundMessage) (string, error) {
+	// Route to agent
+	agentID, sessionKey := l.resolveRoute(msg)
+
 	// WhatsApp command parsing (config get, agents list, etc.)
 	if msg.Channel == "whatsapp" {
 		if isCmd, cmd, args, tier := intent.ParseWhatsAppCommand(msg.Content, msg.SenderID, &l.cfg.Channels); isCmd && cmd != "" {
@@ -262,29 +302,29 @@ func (l *Loop) processMessage(ctx context.Context, msg bus.InboundMessage) (stri
 		}
 	}
 
-	// Route to agent
-	route := routing.Resolve(l.cfg, routing.RouteInput{
-		Channel:   msg.Channel,
-		AccountID: msg.SenderID,
-	})
-	agentID := route.AgentID
-	sessionKey := route.SessionKey
-	if sessionKey == "" {
-		sessionKey = "agent:" + agentID + ":" + msg.Channel + ":" + msg.ChatID
-	}
-
 	// Idempotency: return cached result if same message within TTL
+	idemKey := msg.Content
+	if len(msg.Media) > 0 {
+		idemKey += "\n" + strings.Join(msg.Media, "\n")
+	}
 	if l.idempotency != nil {
-		if _, result, ok := l.idempotency.Get(sessionKey, msg.Content); ok {
+		if _, result, ok := l.idempotency.Get(sessionKey, idemKey); ok {
 			return result, nil
 		}
 	}
 
+	// Download attachments only once the message is known to need the agent;
+	// voice notes become the message text
+	in := l.fetchMedia(agentID, msg.Media)
+	msg = l.transcribe(ctx, msg, in)
+
 	// Create task (pending -> authorized)
 	t := l.taskMgr.Create(agentID, sessionKey)
 	t.Transition(task.StateAuthorized)
+	t.SetMedia(in.paths())
 	defer func() {
 		l.taskMgr.Remove(t.ID)
+		l.jobs.RemoveTask(t.ID)
 		l.procTracker.RemoveTask(t.ID)
 		l.messageTool.ClearReplyTarget(t.ID)
 	}()
@@ -292,15 +332,24 @@ func (l *Loop) processMessage(ctx context.Context, msg bus.InboundMessage) (stri
 	l.messageTool.SetReplyTarget(t.ID, msg.Channel, msg.ChatID)
 
 	// Emit task.started event
+	started := map[string]interface{}{
+		"task_id":     t.ID,
+		"agent_id":    agentID,
+		"channel":     msg.Channel,
+		"chat_id":     msg.ChatID,
+		"session_key": sessionKey,
+	}
+	replayInput := map[string]interface{}{"content": msg.Content, "channel": msg.Channel}
+	if len(in.attachments) > 0 {
+		started["media"] = in.summary()
+		replayInput["media"] = in.summary()
+	}
+	if len(msg.Metadata) > 0 {
+		replayInput["metadata"] = msg.Metadata
+	}
 	_ = l.eventBus.Publish(ctx, bus.Event{
-		Type: "task.started",
-		Payload: map[string]interface{}{
-			"task_id":     t.ID,
-			"agent_id":    agentID,
-			"channel":     msg.Channel,
-			"chat_id":     msg.ChatID,
-			"session_key": sessionKey,
-		},
+		Type:    "task.started",
+		Payload: started,
 	})
 
 	// Run with timeout
@@ -312,22 +361,27 @@ func (l *Loop) processMessage(ctx context.Context, msg bus.InboundMessage) (stri
 			return context.Canceled
 		}
 
-		if l.provider == nil || l.safeMode {
+		if l.provider == nil {
 			if l.safeMode {
-				result = fmt.Sprintf("Received: %q (LLM disabled in safe mode)", msg.Content)
+				result = fmt.Sprintf("Received: %q (LLM disabled in safe mode - enable providers.ollama or providers.llamacpp for a local model)", msg.Content)
 			} else {
-				result = fmt.Sprintf("Received: %q (no LLM provider configured - set CEREBRAS_API_KEY or OPENAI_API_KEY)", msg.Content)
+				result = fmt.Sprintf("Received: %q (no LLM provider configured - set CEREBRAS_API_KEY or OPENAI_API_KEY, or enable providers.ollama)", msg.Content)
 			}
 			return nil
 		}
 
 		// Build system prompt with bootstrap files (SOUL, AGENT, etc.)
+		sink := streamSinkFrom(ctx)
 		systemPrompt := l.buildSystemPrompt(agentID)
-		messages := []providers.Message{
-			{Role: "system", Content: systemPrompt},
-			{Role: "user", Content: msg.Content},
+		history, err := l.sessions.Load(sessionKey)
+		if err != nil {
+			log.Printf("Session %s load failed: %v", sessionKey, err)
 		}
-		model := l.cfg.Agents.Defaults.Model
+		messages := make([]providers.Message, 0, len(history)+2)
+		messages = append(messages, providers.Message{Role: "system", Content: systemPrompt})
+		messages = append(messages, history...)
+		messages = append(messages, in.userMessage(msg.Content))
+		models := l.cfg.AgentModels(agentID)
 		maxIter := l.cfg.Agents.Defaults.MaxToolIterations
 		if maxIter <= 0 {
 			maxIter = 20
@@ -339,92 +393,60 @@ func (l *Loop) processMessage(ctx context.Context, msg bus.InboundMessage) (stri
 				return context.Canceled
 			}
 
-			// Context summarization: truncate when over threshold (rough: 4 chars = 1 token)
-			if thresh := l.cfg.Context.SummarizeThreshold; thresh > 0 {
-				messages = truncateMessages(messages, thresh)
+			chain, err := l.budgetModels(agentID, models)
+			if err != nil {
+				t.Transition(task.StateFailed)
+				result = fmt.Sprintf("LLM stopped: %v", err)
+				l.saveSession(sessionKey, append(messages, providers.Message{Role: "assistant", Content: result}))
+				return nil
 			}
 
-			toolsDef := l.toolDefinitions()
-			resp, err := l.provider.Chat(ctx, messages, toolsDef, model, map[string]interface{}{
-				"max_tokens": 2048,
-			})
+			// Context summarization: compact older turns when over budget (rough: 4 chars = 1 token)
+			compacted, summaryResp, err := l.summarizer.Compact(ctx, l.provider, messages, chain)
+			if summaryResp != nil {
+				l.recordUsage(t, summaryResp)
+			}
+			if err != nil {
+				log.Printf("Context summarization failed, truncating: %v", err)
+				messages = truncateMessages(messages, l.summarizer.Budget())
+			} else {
+				messages = compacted
+			}
+			toolsDef := l.toolDefinitions(agentID)
+			resp, err := l.chat(ctx, messages, toolsDef, chain, sink.onEvent())
 			if err != nil {
 				t.Transition(task.StateFailed)
 				result = fmt.Sprintf("LLM error: %v", err)
+				// Keep the user's turn and any tool results for the next attempt
+				l.saveSession(sessionKey, append(messages, providers.Message{Role: "assistant", Content: result}))
 				return nil
 			}
+			l.recordUsage(t, resp)
 
 			if len(resp.ToolCalls) == 0 {
 				result = resp.Content
 				if result == "" {
 					result = "(no response)"
 				}
+				messages = append(messages, providers.Message{Role: "assistant", Content: result})
+				l.saveSession(sessionKey, messages)
 				return nil
 			}
 
+			if sink != nil {
+				sink.EndTurn()
+			}
+
 			// Execute tool calls
 			t.Transition(task.StateMonitoring)
-			for _, tc := range resp.ToolCalls {
-				req := tools.Request{
-					ToolCallID: tc.ID,
-					TaskID:     t.ID,
-					AgentID:    agentID,
-					Name:       tc.Name,
-					Args:       tc.Arguments,
-				}
-				if l.policyEval != nil && !l.policyEval.CheckRateLimit(agentID, tc.Name) {
-					toolResp := tools.ErrorResponse(tc.ID, "Rate limit exceeded", "Rate limit exceeded.", tools.CodeRateLimited, true)
-					messages = append(messages, providers.Message{Role: "assistant", Content: resp.Content, ToolCalls: []providers.ToolCall{tc}, ToolCallID: tc.ID})
-					messages = append(messages, providers.Message{Role: "tool", Content: "Error: " + toolResp.ForLLM, ToolCallID: tc.ID})
-					continue
-				}
-				var toolResp tools.Response
-				switch tc.Name {
-				case "exec":
-					toolResp = l.execTool.Execute(ctx, req)
-				case "kill":
-					toolResp = l.killTool.Execute(ctx, req)
-				case "web_fetch":
-					toolResp = l.webFetch.Execute(ctx, req)
-				case "message":
-					toolResp = l.messageTool.Execute(ctx, req)
-				case "tail_output":
-					toolResp = l.tailOutput.Execute(ctx, req)
-				case "stream_command":
-					toolResp = l.streamCommand.Execute(ctx, req)
-				default:
-					toolResp = tools.ErrorResponse(tc.ID, "Unknown tool: "+tc.Name, "Unknown tool.", tools.CodePermissionDenied, false)
-				}
-
-				if l.metrics != nil {
-					l.metrics.IncToolCall(tc.Name)
-					if toolResp.IsError {
-						l.metrics.IncToolError(tc.Name)
-					}
-				}
-
-				// Append assistant message with tool call
-				toolContent := toolResp.ForLLM
-				if toolResp.IsError {
-					toolContent = "Error: " + toolContent
-				}
-				messages = append(messages, providers.Message{
-					Role:       "assistant",
-					Content:    resp.Content,
-					ToolCalls:  []providers.ToolCall{tc},
-					ToolCallID: tc.ID,
-				})
-				messages = append(messages, providers.Message{
-					Role:       "tool",
-					Content:    toolContent,
-					ToolCallID: tc.ID,
-				})
-			}
+			results := l.runToolCalls(ctx, t.ID, agentID, resp.ToolCalls)
+			messages = append(messages, toolTurnMessages(resp.Content, resp.ToolCalls, results)...)
 			t.Transition(task.StateExecuting)
 		}
 
 		t.Transition(task.StateFailed)
 		result = "(max tool iterations reached)"
+		l.saveSession(sessionKey, messages)
 		return nil
 	})
 
@@ -454,7 +476,7 @@ func (l *Loop) processMessage(ctx context.Context, msg bus.InboundMessage) (stri
 		if l.replayWriter != nil {
 			_ = l.replayWriter.Write(replay.Record{
 				TaskID: t.ID,
-				Input:  map[string]string{"content": msg.Content, "channel": msg.Channel},
+				Input:  replayInput,
 				Result: result,
 				Status: "failed",
 			})
@@ -465,18 +487,60 @@ func (l *Loop) processMessage(ctx context.Context, msg bus.InboundMessage) (stri
 	if l.replayWriter != nil {
 		_ = l.replayWriter.Write(replay.Record{
 			TaskID: t.ID,
-			Input:  map[string]string{"content": msg.Content, "channel": msg.Channel},
+			Input:  replayInput,
 			Result: result,
 			Status: "completed",
 		})
 	}
 	if l.idempotency != nil {
-		l.idempotency.Set(sessionKey, msg.Content, t.ID, result)
+		l.idempotency.Set(sessionKey, idemKey, t.ID, result)
 		l.idempotency.Cleanup()
 	}
 	return result, nil
 }
 
+// chat calls the provider with each model of the agent's chain until one succeeds.
+// When onEvent is set, output is streamed to it.
+func (l *Loop) chat(ctx context.Context, messages []providers.Message, toolsDef []providers.ToolDefinition, models []string, onEvent func(providers.StreamEvent)) (*providers.LLMResponse, error) {
+	options := map[string]interface{}{
+		"max_tokens": 2048,
+	}
+	return chatChain(ctx, l.provider, messages, toolsDef, models, options, onEvent)
+}
+
+// chatChain calls provider with each model in turn until one succeeds, or hands
+// the whole chain to providers that route it themselves.
+func chatChain(ctx context.Context, provider providers.LLMProvider, messages []providers.Message, toolsDef []providers.ToolDefinition, models []string, options map[string]interface{}, onEvent func(providers.StreamEvent)) (*providers.LLMResponse, error) {
+	if cp, ok := provider.(providers.ChainProvider); ok {
+		return cp.ChatChain(ctx, messages, toolsDef, models, options, onEvent)
+	}
+	if len(models) == 0 {
+		models = []string{""}
+	}
+	sp, streaming := provider.(providers.StreamingProvider)
+	var lastErr error
+	for i, model := range models {
+		if i > 0 {
+			log.Printf("Model %s failed, falling back to %s: %v", models[i-1], model, lastErr)
+		}
+		var resp *providers.LLMResponse
+		var err error
+		if streaming && onEvent != nil {
+			resp, err = sp.ChatStream(ctx, messages, toolsDef, model, options, onEvent)
+		} else {
+			resp, err = provider.Chat(ctx, messages, toolsDef, model, options)
+		}
+		if err == nil {
+			return resp, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
 // handleWhatsAppCommand handles WhatsApp commands (config, agents, monitors, audit, status).
 func (l *Loop) handleWhatsAppCommand(ctx context.Context, cmd string, args []string, tier intent.WhatsAppTier, msg bus.InboundMessage) (string, error) {
 	switch cmd {
@@ -545,43 +609,46 @@ func (l *Loop) handleWhatsAppCommand(ctx context.Context, cmd string, args []str
 	return "", nil
 }
 
-// truncateMessages keeps system + recent messages when total tokens exceed threshold.
-// Rough estimate: 4 chars = 1 token.
-func truncateMessages(messages []providers.Message, thresholdTokens int) []providers.Message {
-	if len(messages) <= 2 {
-		return messages
+// saveSession persists the conversation (without the system prompt) for the session key.
+func (l *Loop) saveSession(sessionKey string, messages []providers.Message) {
+	if l.sessions == nil || len(messages) == 0 {
+		return
 	}
-	total := 0
-	for _, m := range messages {
-		total += len(m.Content) / 4
+	if messages[0].Role == "system" {
+		messages = messages[1:]
 	}
-	if total <= thresholdTokens {
-		return messages
+	// Images are sent with their turn only; the message text already notes
+	// where each one was saved, so later turns do not re-send them.
+	saved := make([]providers.Message, len(messages))
+	for i, m := range messages {
+		m.Images = nil
+		saved[i] = m
 	}
-	// Keep system (first) + last 6 messages
-	keep := 6
-	if len(messages) <= keep+1 {
-		return messages
+	if err := l.sessions.Save(sessionKey, saved); err != nil {
+		log.Printf("Session %s save failed: %v", sessionKey, err)
 	}
-	out := make([]providers.Message, 0, keep+1)
-	if messages[0].Role == "system" {
-		out = append(out, messages[0])
+}
+
+// defaultWorkspace returns the expanded default agent workspace.
+func defaultWorkspace(cfg *config.Config) string {
+	workspace := config.ExpandPath(cfg.Agents.Defaults.Workspace)
+	if workspace == "" {
+		workspace = config.ExpandPath("~/.sypher-mini/workspace")
 	}
-	start := len(messages) - keep
-	if start < 1 {
-		start = 1
+	return workspace
+}
+
+// agentWorkspace returns the expanded workspace of agentID.
+func (l *Loop) agentWorkspace(agentID string) string {
+	if workspace := l.cfg.AgentWorkspace(agentID); workspace != "" {
+		return workspace
 	}
-	out = append(out, messages[start:]...)
-	return out
+	return defaultWorkspace(l.cfg)
 }
 
 // buildSystemPrompt builds the system prompt with bootstrap files and hard rules.
 func (l *Loop) buildSystemPrompt(agentID string) string {
-	workspace := l.cfg.Agents.Defaults.Workspace
-	if workspace == "" {
-		workspace = config.ExpandPath("~/.sypher-mini/workspace")
-	}
-	bootstrap := LoadBootstrapFiles(workspace, agentID)
+	bootstrap := LoadBootstrapFiles(l.agentWorkspace(agentID), agentID)
 
 	hardRules := `## Hard Rules (non-overridable)
 - ALWAYS use tools for actions; never pretend to execute
@@ -605,6 +672,34 @@ func (l *Loop) CancelTask(taskID string) bool {
 	return l.taskMgr.Cancel(taskID)
 }
 
+// Sessions returns the session history store.
+func (l *Loop) Sessions() *session.Store {
+	return l.sessions
+}
+
+// Tools returns the tool registry. Tools registered before Run are offered to the LLM.
+func (l *Loop) Tools() *tools.Registry {
+	return l.toolRegistry
+}
+
+// ReportProviderHealth publishes each provider's circuit breaker state to h
+// as "provider:<name>" ("ok" while closed).
+func (l *Loop) ReportProviderHealth(h *observability.HealthChecker) {
+	if l.provider == nil || l.fallback == nil {
+		return
+	}
+	set := func(name, state string) {
+		if state == providers.BreakerClosed {
+			state = "ok"
+		}
+		h.Set("provider:"+name, state)
+	}
+	for _, e := range l.fallback.Entries() {
+		set(e.Name, l.fallback.Breakers().Get(e.Name).State())
+	}
+	l.fallback.Breakers().OnChange(set)
+}
+
 // Metrics returns the metrics collector for observability.
 func (l *Loop) Metrics() *observability.Metrics {
 	return l.metrics
@@ -2,10 +2,16 @@ package agent
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/sypherexx/sypher-mini/pkg/bus"
 	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/providers"
 )
 
 func TestLoop_ProcessMessage_IntentFastPath(t *testing.T) {
@@ -61,3 +67,287 @@ func TestLoop_ProcessMessage_SafeMode(t *testing.T) {
 		t.Error("expected response")
 	}
 }
+
+// recordingProvider returns a fixed reply and records the messages it was sent.
+type recordingProvider struct {
+	calls [][]providers.Message
+}
+
+func (p *recordingProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, options map[string]interface{}) (*providers.LLMResponse, error) {
+	p.calls = append(p.calls, append([]providers.Message(nil), messages...))
+	return &providers.LLMResponse{Content: "ack"}, nil
+}
+
+func (p *recordingProvider) GetDefaultModel() string { return "test" }
+
+func TestLoop_ProcessMessage_SessionHistory(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Usage.Dir = t.TempDir()
+	loop := NewLoop(cfg, bus.NewMessageBus(10), bus.New(), nil)
+	prov := &recordingProvider{}
+	loop.provider = prov
+
+	ctx := context.Background()
+	msg := bus.InboundMessage{Channel: "cli", ChatID: "cli", SenderID: "cli"}
+	msg.Content = "first"
+	if _, err := loop.processMessage(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+	msg.Content = "second"
+	if _, err := loop.processMessage(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(prov.calls) != 2 {
+		t.Fatalf("expected 2 LLM calls, got %d", len(prov.calls))
+	}
+	got := prov.calls[1]
+	if len(got) != 4 {
+		t.Fatalf("expected system + 2 history + user, got %d messages", len(got))
+	}
+	if got[1].Content != "first" || got[2].Content != "ack" || got[3].Content != "second" {
+		t.Errorf("unexpected history: %+v", got[1:])
+	}
+}
+
+// modelProvider fails for every model except ok and records the models tried.
+type modelProvider struct {
+	ok     string
+	models []string
+}
+
+func (p *modelProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, options map[string]interface{}) (*providers.LLMResponse, error) {
+	p.models = append(p.models, model)
+	if model != p.ok {
+		return nil, fmt.Errorf("model %s unavailable", model)
+	}
+	return &providers.LLMResponse{Content: "from " + model}, nil
+}
+
+func (p *modelProvider) GetDefaultModel() string { return "test" }
+
+func TestLoop_ProcessMessage_AgentModelChain(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Usage.Dir = t.TempDir()
+	cfg.Agents.List[0].Model = &config.AgentModelConfig{Primary: "p/one", Fallbacks: []string{"p/two"}}
+	loop := NewLoop(cfg, bus.NewMessageBus(10), bus.New(), nil)
+	prov := &modelProvider{ok: "p/two"}
+	loop.provider = prov
+
+	out, err := loop.processMessage(context.Background(), bus.InboundMessage{Channel: "cli", ChatID: "cli", SenderID: "cli", Content: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "from p/two" {
+		t.Errorf("expected fallback model reply, got %q", out)
+	}
+	if len(prov.models) != 2 || prov.models[0] != "p/one" {
+		t.Errorf("expected primary then fallback, got %v", prov.models)
+	}
+}
+
+func TestLoop_ProcessMessage_SavesFailedTurn(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Usage.Dir = t.TempDir()
+	cfg.Agents.List[0].Model = &config.AgentModelConfig{Primary: "p/one"}
+	loop := NewLoop(cfg, bus.NewMessageBus(10), bus.New(), nil)
+	loop.provider = &modelProvider{ok: "p/two"}
+
+	msg := bus.InboundMessage{Channel: "cli", ChatID: "cli", SenderID: "cli", Content: "hi"}
+	out, _ := loop.processMessage(context.Background(), msg)
+	if !strings.HasPrefix(out, "LLM error:") {
+		t.Fatalf("expected LLM error, got %q", out)
+	}
+	_, sessionKey := loop.resolveRoute(msg)
+	history, err := loop.sessions.Load(sessionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Content != "hi" || history[1].Content != out {
+		t.Errorf("failed turn not saved: %+v", history)
+	}
+}
+
+// costlyProvider reports one million prompt tokens of openai/gpt-4o per call.
+type costlyProvider struct {
+	models []string
+}
+
+func (p *costlyProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, options map[string]interface{}) (*providers.LLMResponse, error) {
+	p.models = append(p.models, model)
+	return &providers.LLMResponse{
+		Content:  "from " + model,
+		Provider: "openai",
+		Model:    "gpt-4o",
+		Usage:    &providers.UsageInfo{PromptTokens: 1000000, TotalTokens: 1000000},
+	}, nil
+}
+
+func (p *costlyProvider) GetDefaultModel() string { return "test" }
+
+func TestLoop_ProcessMessage_Budgets(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Usage.Dir = t.TempDir()
+	cfg.Usage.Budgets = []config.Budget{
+		{AgentID: "main", DailyUSD: 1, Action: "downgrade", DowngradeModel: "openai/gpt-4o-mini"},
+		{AgentID: "*", DailyUSD: 4},
+	}
+	loop := NewLoop(cfg, bus.NewMessageBus(10), bus.New(), nil)
+	prov := &costlyProvider{}
+	loop.provider = prov
+	msg := bus.InboundMessage{Channel: "cli", ChatID: "cli", SenderID: "cli", Content: "hi"}
+
+	if _, err := loop.processMessage(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	out, _ := loop.processMessage(context.Background(), msg)
+	if out != "from openai/gpt-4o-mini" {
+		t.Errorf("expected downgrade after daily budget, got %q", out)
+	}
+	out, _ = loop.processMessage(context.Background(), msg)
+	if !strings.Contains(out, "global daily budget exceeded") {
+		t.Errorf("expected stop after global budget, got %q", out)
+	}
+	if len(prov.models) != 2 {
+		t.Errorf("expected 2 LLM calls, got %v", prov.models)
+	}
+	if got := loop.Metrics().LLMRequestsTotal["openai"]; got != 2 {
+		t.Errorf("llm requests = %d", got)
+	}
+	if _, monthly := loop.Usage().Spent("main"); monthly != 5 {
+		t.Errorf("monthly spend = %v, want 5", monthly)
+	}
+}
+
+func TestLoop_ProcessMessage_SummaryUsage(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Usage.Dir = t.TempDir()
+	cfg.Context.SummarizeThreshold = 300
+	cfg.Usage.Budgets = []config.Budget{{AgentID: "*", DailyUSD: 4}}
+	loop := NewLoop(cfg, bus.NewMessageBus(10), bus.New(), nil)
+	prov := &costlyProvider{}
+	loop.provider = prov
+	if err := loop.sessions.Save("agent:main:cli:cli", longHistory()[1:]); err != nil {
+		t.Fatal(err)
+	}
+	msg := bus.InboundMessage{Channel: "cli", ChatID: "cli", SenderID: "cli", Content: "hi"}
+
+	if _, err := loop.processMessage(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if len(prov.models) != 2 {
+		t.Fatalf("expected summary and reply calls, got %v", prov.models)
+	}
+	if got := loop.Metrics().LLMRequestsTotal["openai"]; got != 2 {
+		t.Errorf("summary call not recorded: llm requests = %d", got)
+	}
+	out, _ := loop.processMessage(context.Background(), msg)
+	if !strings.Contains(out, "budget exceeded") || len(prov.models) != 2 {
+		t.Errorf("over budget should stop before summarizing: %q, calls %v", out, prov.models)
+	}
+}
+
+func TestLoop_ProcessMessage_Media(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/media/1/photo.png":
+			w.Write([]byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"))
+		case "/media/2/todo.txt":
+			w.Write([]byte("buy milk"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Usage.Dir = t.TempDir()
+	cfg.Channels.WhatsApp.BaileysURL = srv.URL
+	eventBus := bus.New()
+	var started map[string]interface{}
+	eventBus.SubscribeSync("task.started", func(ctx context.Context, e bus.Event) error {
+		started = e.Payload
+		return nil
+	})
+	loop := NewLoop(cfg, bus.NewMessageBus(10), eventBus, nil)
+	prov := &recordingProvider{}
+	loop.provider = prov
+
+	msg := bus.InboundMessage{Channel: "whatsapp", ChatID: "c1", SenderID: "u1", Content: "look",
+		Media: []string{srv.URL + "/media/1/photo.png", srv.URL + "/media/2/todo.txt", srv.URL + "/media/3/gone.pdf"}}
+	if _, err := loop.processMessage(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+
+	user := prov.calls[0][len(prov.calls[0])-1]
+	mediaDir := filepath.Join(cfg.Agents.Defaults.Workspace, "media")
+	if len(user.Images) != 1 || user.Images[0].MimeType != "image/png" || !strings.HasPrefix(user.Images[0].Path, mediaDir) {
+		t.Errorf("images = %+v", user.Images)
+	}
+	for _, want := range []string{"look", "buy milk", "could not be downloaded"} {
+		if !strings.Contains(user.Content, want) {
+			t.Errorf("user message missing %q: %s", want, user.Content)
+		}
+	}
+	if media, _ := started["media"].([]map[string]string); len(media) != 2 {
+		t.Errorf("task.started media = %v", started["media"])
+	}
+
+	msg.Content = "and now?"
+	msg.Media = nil
+	if _, err := loop.processMessage(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if providers.HasImages(prov.calls[1]) {
+		t.Error("images of an earlier turn were sent again")
+	}
+	if !strings.Contains(prov.calls[1][1].Content, "[image photo.png saved at ") {
+		t.Errorf("history lost the image note: %q", prov.calls[1][1].Content)
+	}
+}
+
+// stubTranscriber returns a fixed transcript.
+type stubTranscriber struct{ text string }
+
+func (s stubTranscriber) Transcribe(ctx context.Context, file string) (string, error) {
+	return s.text, nil
+}
+
+func (s stubTranscriber) Remote() bool { return false }
+
+func TestLoop_ProcessMessage_VoiceNote(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OggS\x00\x02voice"))
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Usage.Dir = t.TempDir()
+	cfg.Channels.WhatsApp.BaileysURL = srv.URL
+	loop := NewLoop(cfg, bus.NewMessageBus(10), bus.New(), nil)
+	loop.transcriber = stubTranscriber{text: "remind me to call Sam"}
+	prov := &recordingProvider{}
+	loop.provider = prov
+
+	msg := bus.InboundMessage{Channel: "whatsapp", ChatID: "c1", SenderID: "u1", Media: []string{srv.URL + "/media/1/voice.ogg"}}
+	if _, err := loop.processMessage(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	user := prov.calls[0][len(prov.calls[0])-1]
+	if !strings.HasPrefix(user.Content, "remind me to call Sam\n\n[voice note voice.ogg saved at ") {
+		t.Errorf("user message = %q", user.Content)
+	}
+
+	in := loop.fetchMedia("main", msg.Media)
+	got := loop.transcribe(context.Background(), bus.InboundMessage{Content: "fyi"}, in)
+	if got.Content != "fyi\n\nremind me to call Sam" || got.Metadata["audio_source"] != msg.Media[0] || !strings.HasSuffix(got.Metadata["audio"], "voice.ogg") {
+		t.Errorf("transcribed message = %+v", got)
+	}
+}
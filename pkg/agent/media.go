@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/sypherexx/sypher-mini/pkg/bus"
+	"github.com/sypherexx/sypher-mini/pkg/media"
+	"github.com/sypherexx/sypher-mini/pkg/providers"
+)
+
+// inboundMedia holds the attachments of one inbound message.
+type inboundMedia struct {
+	attachments []*media.Attachment
+	notes       []string // failures, reported to the LLM alongside the message
+}
+
+// fetchMedia downloads refs into the agent's workspace media directory and
+// extracts document text. Failures become notes rather than failing the message.
+func (l *Loop) fetchMedia(agentID string, refs []string) *inboundMedia {
+	in := &inboundMedia{}
+	if len(refs) == 0 {
+		return in
+	}
+	dir := media.Dir(l.agentWorkspace(agentID))
+	for _, ref := range refs {
+		a, err := l.media.Fetch(ref, dir)
+		if err != nil {
+			log.Printf("Media fetch failed: %v", err)
+			in.notes = append(in.notes, fmt.Sprintf("[attachment could not be downloaded: %v]", err))
+			continue
+		}
+		if err := l.media.Extract(a); err != nil {
+			log.Printf("Media extract failed: %v", err)
+			in.notes = append(in.notes, fmt.Sprintf("[attachment %s saved at %s; text could not be extracted: %v]", a.Name, a.Path, err))
+		}
+		in.attachments = append(in.attachments, a)
+	}
+	return in
+}
+
+// transcribe runs audio attachments through the configured transcriber. The
+// transcript becomes the message content (after any caption) and the audio
+// reference is kept in metadata ("audio", "audio_source").
+func (l *Loop) transcribe(ctx context.Context, msg bus.InboundMessage, in *inboundMedia) bus.InboundMessage {
+	if l.transcriber == nil {
+		return msg
+	}
+	for _, a := range in.attachments {
+		if a.Kind != media.KindAudio {
+			continue
+		}
+		text, err := l.transcriber.Transcribe(ctx, a.Path)
+		if err != nil {
+			log.Printf("Transcription of %s failed: %v", a.Name, err)
+			in.notes = append(in.notes, fmt.Sprintf("[voice note %s could not be transcribed: %v]", a.Name, err))
+			continue
+		}
+		a.Transcript = text
+		if msg.Content == "" {
+			msg.Content = text
+		} else {
+			msg.Content += "\n\n" + text
+		}
+		meta := make(map[string]string, len(msg.Metadata)+2)
+		for k, v := range msg.Metadata {
+			meta[k] = v
+		}
+		meta["audio"] = a.Path
+		meta["audio_source"] = a.Source
+		msg.Metadata = meta
+	}
+	return msg
+}
+
+// paths returns the workspace paths of the attachments.
+func (in *inboundMedia) paths() []string {
+	var out []string
+	for _, a := range in.attachments {
+		out = append(out, a.Path)
+	}
+	return out
+}
+
+// summary describes the attachments for events and replay records.
+func (in *inboundMedia) summary() []map[string]string {
+	var out []map[string]string
+	for _, a := range in.attachments {
+		out = append(out, map[string]string{
+			"name": a.Name,
+			"kind": a.Kind,
+			"mime": a.MimeType,
+			"path": a.Path,
+		})
+	}
+	return out
+}
+
+// userMessage builds the LLM user message for content and its attachments:
+// images are attached as image parts and document text is appended.
+func (in *inboundMedia) userMessage(content string) providers.Message {
+	msg := providers.Message{Role: "user", Content: content}
+	var parts []string
+	if content != "" {
+		parts = append(parts, content)
+	}
+	for _, a := range in.attachments {
+		switch {
+		case a.Kind == media.KindImage:
+			msg.Images = append(msg.Images, providers.ImagePart{MimeType: a.MimeType, Path: a.Path})
+			parts = append(parts, fmt.Sprintf("[image %s saved at %s]", a.Name, a.Path))
+		case a.Kind == media.KindDocument && a.Text != "":
+			parts = append(parts, fmt.Sprintf("[attachment %s saved at %s]\n%s", a.Name, a.Path, a.Text))
+		case a.Kind == media.KindAudio && a.Transcript != "":
+			parts = append(parts, fmt.Sprintf("[voice note %s saved at %s; the message text is its transcript]", a.Name, a.Path))
+		case a.Kind == media.KindAudio:
+			parts = append(parts, fmt.Sprintf("[audio %s saved at %s; no transcript available]", a.Name, a.Path))
+		case a.Kind != media.KindDocument:
+			parts = append(parts, fmt.Sprintf("[attachment %s (%s) saved at %s]", a.Name, a.MimeType, a.Path))
+		}
+	}
+	parts = append(parts, in.notes...)
+	msg.Content = strings.Join(parts, "\n\n")
+	return msg
+}
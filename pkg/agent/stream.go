@@ -0,0 +1,160 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sypherexx/sypher-mini/pkg/bus"
+	"github.com/sypherexx/sypher-mini/pkg/providers"
+)
+
+// Streaming modes (channels.streaming.modes).
+const (
+	StreamModeChunk = "chunk" // send text in pieces as it is generated
+	StreamModeEdit  = "edit"  // resend the full text so far; the channel edits one message
+)
+
+const (
+	defaultMinChunkChars = 200
+	defaultEditInterval  = time.Second
+)
+
+// streamSink turns LLM stream events into partial outbound messages for one inbound message.
+type streamSink struct {
+	msgBus       *bus.MessageBus
+	mode         string
+	channel      string
+	chatID       string
+	streamID     string
+	minChunk     int
+	editInterval time.Duration
+
+	mu       sync.Mutex
+	turn     strings.Builder // text of the current LLM turn
+	sent     int             // chunk mode: bytes of turn already sent
+	lastEdit time.Time
+}
+
+// newStreamSink returns a sink for msg, or nil when its channel does not stream.
+func (l *Loop) newStreamSink(msg bus.InboundMessage) *streamSink {
+	sc := l.cfg.Channels.Streaming
+	mode := sc.Modes[msg.Channel]
+	if mode != StreamModeChunk && mode != StreamModeEdit {
+		return nil
+	}
+	minChunk := sc.MinChunkChars
+	if minChunk <= 0 {
+		minChunk = defaultMinChunkChars
+	}
+	interval := defaultEditInterval
+	if sc.EditIntervalMs > 0 {
+		interval = time.Duration(sc.EditIntervalMs) * time.Millisecond
+	}
+	return &streamSink{
+		msgBus:       l.msgBus,
+		mode:         mode,
+		channel:      msg.Channel,
+		chatID:       msg.ChatID,
+		streamID:     uuid.New().String(),
+		minChunk:     minChunk,
+		editInterval: interval,
+	}
+}
+
+// OnEvent handles one stream event. Tool call fragments are not shown.
+func (s *streamSink) OnEvent(ev providers.StreamEvent) {
+	if ev.Delta == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.turn.WriteString(ev.Delta)
+	switch s.mode {
+	case StreamModeChunk:
+		pending := s.turn.String()[s.sent:]
+		if len(pending) < s.minChunk {
+			return
+		}
+		cut := strings.LastIndex(pending, "\n") + 1
+		if cut == 0 && len(pending) >= 4*s.minChunk {
+			cut = strings.LastIndex(pending, " ") + 1
+		}
+		if cut > 0 {
+			s.publish(pending[:cut], true)
+			s.sent += cut
+		}
+	case StreamModeEdit:
+		if time.Since(s.lastEdit) >= s.editInterval {
+			s.publish(s.turn.String(), true)
+			s.lastEdit = time.Now()
+		}
+	}
+}
+
+// EndTurn is called when an LLM turn ends in tool calls. Chunk mode flushes the
+// rest of the turn; the next turn starts fresh.
+func (s *streamSink) EndTurn() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.mode == StreamModeChunk {
+		if pending := s.turn.String()[s.sent:]; strings.TrimSpace(pending) != "" {
+			s.publish(pending, true)
+		}
+	}
+	s.turn.Reset()
+	s.sent = 0
+}
+
+// Finish returns the final message for response. In chunk mode it carries only
+// the text not yet sent when response continues what was streamed.
+func (s *streamSink) Finish(response string) bus.OutboundMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	content := response
+	if s.mode == StreamModeChunk {
+		if streamed := s.turn.String()[:s.sent]; s.sent > 0 && strings.HasPrefix(response, streamed) {
+			content = response[s.sent:]
+		}
+	}
+	return s.message(content, false)
+}
+
+func (s *streamSink) publish(content string, partial bool) {
+	s.msgBus.PublishOutbound(s.message(content, partial))
+}
+
+func (s *streamSink) message(content string, partial bool) bus.OutboundMessage {
+	return bus.OutboundMessage{
+		Channel:  s.channel,
+		ChatID:   s.chatID,
+		Content:  content,
+		StreamID: s.streamID,
+		Partial:  partial,
+		Replace:  s.mode == StreamModeEdit,
+	}
+}
+
+// onEvent returns the sink's event handler, or nil for a nil sink.
+func (s *streamSink) onEvent() func(providers.StreamEvent) {
+	if s == nil {
+		return nil
+	}
+	return s.OnEvent
+}
+
+type streamSinkKey struct{}
+
+func withStreamSink(ctx context.Context, s *streamSink) context.Context {
+	if s == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, streamSinkKey{}, s)
+}
+
+func streamSinkFrom(ctx context.Context) *streamSink {
+	s, _ := ctx.Value(streamSinkKey{}).(*streamSink)
+	return s
+}
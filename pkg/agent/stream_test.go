@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sypherexx/sypher-mini/pkg/bus"
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/providers"
+)
+
+func TestStreamSink_Chunk(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Usage.Dir = t.TempDir()
+	cfg.Channels.Streaming.MinChunkChars = 10
+	msgBus := bus.NewMessageBus(10)
+	loop := NewLoop(cfg, msgBus, bus.New(), nil)
+
+	sink := loop.newStreamSink(bus.InboundMessage{Channel: "cli", ChatID: "cli"})
+	if sink == nil {
+		t.Fatal("cli should stream by default")
+	}
+	for _, d := range []string{"first line\n", "second", " part"} {
+		sink.OnEvent(providers.StreamEvent{Delta: d})
+	}
+	final := sink.Finish("first line\nsecond part")
+
+	out, ok := msgBus.SubscribeOutbound(context.Background())
+	if !ok || !out.Partial || out.Content != "first line\n" {
+		t.Fatalf("expected partial first line, got %+v", out)
+	}
+	if final.Partial || final.Content != "second part" || final.StreamID != out.StreamID {
+		t.Errorf("final should carry the unsent rest: %+v", final)
+	}
+}
+
+func TestStreamSink_Disabled(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Usage.Dir = t.TempDir()
+	loop := NewLoop(cfg, bus.NewMessageBus(10), bus.New(), nil)
+	if sink := loop.newStreamSink(bus.InboundMessage{Channel: "whatsapp"}); sink != nil {
+		t.Error("whatsapp should not stream unless configured")
+	}
+}
@@ -0,0 +1,194 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/providers"
+)
+
+// SummaryPrefix marks the running summary message injected in place of older turns.
+const SummaryPrefix = "## Summary of earlier conversation\n"
+
+// imageTokens is a rough per-image cost; vision models charge roughly
+// 500-1500 tokens for a typical photo.
+const imageTokens = 1000
+
+const summarizerPrompt = `You compress conversation history for an AI coding assistant.
+Write a concise summary of the transcript below that preserves:
+- the user's original requests and any constraints they stated
+- decisions made and facts learned (file paths, commands, errors, results)
+- tool calls that changed state and their outcomes
+- work that is still outstanding
+If the transcript starts with an earlier summary, merge it into the new one.
+Reply with the summary only.`
+
+// Summarizer compacts older turns into a running summary message using the LLM.
+type Summarizer struct {
+	threshold        int
+	maxTokens        int
+	reservedForTools int
+}
+
+// NewSummarizer creates a summarizer from context config.
+func NewSummarizer(cfg config.ContextConfig) *Summarizer {
+	return &Summarizer{
+		threshold:        cfg.SummarizeThreshold,
+		maxTokens:        cfg.MaxTokens,
+		reservedForTools: cfg.ReservedForTools,
+	}
+}
+
+// Budget returns the token budget for messages: the lower of summarize_threshold
+// and max_tokens minus reserved_for_tools. Zero means unlimited.
+func (s *Summarizer) Budget() int {
+	budget := s.threshold
+	if s.maxTokens > 0 {
+		avail := s.maxTokens - s.reservedForTools
+		if avail > 0 && (budget <= 0 || avail < budget) {
+			budget = avail
+		}
+	}
+	return budget
+}
+
+// Compact returns messages unchanged when they fit the budget. Otherwise older
+// turns are replaced by a summary message produced by provider; the system prompt
+// and the most recent turns are kept verbatim. An assistant tool call is never
+// separated from its tool results. The summary is requested from the models of
+// chain in order; the provider's response, when there is one, is returned so
+// its usage can be recorded.
+func (s *Summarizer) Compact(ctx context.Context, provider providers.LLMProvider, messages []providers.Message, chain []string) ([]providers.Message, *providers.LLMResponse, error) {
+	budget := s.Budget()
+	if budget <= 0 || estimateTokens(messages) <= budget {
+		return messages, nil, nil
+	}
+
+	head := 0
+	if len(messages) > 0 && messages[0].Role == "system" {
+		head = 1
+	}
+	start := splitPoint(messages, head, budget/2)
+	if start <= head {
+		return messages, nil, nil
+	}
+	if provider == nil {
+		return nil, nil, fmt.Errorf("summarize: no LLM provider")
+	}
+
+	maxTokens := budget / 4
+	if maxTokens > 1024 {
+		maxTokens = 1024
+	}
+	if maxTokens < 256 {
+		maxTokens = 256
+	}
+	resp, err := chatChain(ctx, provider, []providers.Message{
+		{Role: "system", Content: summarizerPrompt},
+		{Role: "user", Content: transcript(messages[head:start])},
+	}, nil, chain, map[string]interface{}{"max_tokens": maxTokens}, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("summarize: %w", err)
+	}
+	summary := strings.TrimSpace(resp.Content)
+	if summary == "" {
+		return nil, resp, fmt.Errorf("summarize: empty summary")
+	}
+
+	out := make([]providers.Message, 0, head+1+len(messages)-start)
+	out = append(out, messages[:head]...)
+	out = append(out, providers.Message{Role: "system", Content: SummaryPrefix + summary})
+	out = append(out, messages[start:]...)
+	return out, resp, nil
+}
+
+// truncateMessages is the fallback when summarization fails: it keeps the system
+// prompt and the recent turns that fit the budget, cut at a safe boundary.
+func truncateMessages(messages []providers.Message, thresholdTokens int) []providers.Message {
+	if len(messages) <= 2 || estimateTokens(messages) <= thresholdTokens {
+		return messages
+	}
+	head := 0
+	if messages[0].Role == "system" {
+		head = 1
+	}
+	start := splitPoint(messages, head, thresholdTokens/2)
+	if start <= head {
+		return messages
+	}
+	out := make([]providers.Message, 0, head+len(messages)-start)
+	out = append(out, messages[:head]...)
+	return append(out, messages[start:]...)
+}
+
+// splitPoint returns the index of the first message to keep verbatim so that the
+// kept tail fits keepTokens. The last message is always kept, and the index is
+// moved back so a tool result is never kept without its assistant tool call.
+func splitPoint(messages []providers.Message, head, keepTokens int) int {
+	start := len(messages) - 1
+	used := messageTokens(messages[start])
+	for start-1 > head {
+		next := messageTokens(messages[start-1])
+		if used+next > keepTokens {
+			break
+		}
+		used += next
+		start--
+	}
+	for start > head && messages[start].Role == "tool" {
+		start--
+	}
+	return start
+}
+
+// estimateTokens returns a rough token count (4 chars = 1 token).
+func estimateTokens(messages []providers.Message) int {
+	total := 0
+	for _, m := range messages {
+		total += messageTokens(m)
+	}
+	return total
+}
+
+func messageTokens(m providers.Message) int {
+	n := len(m.Content)
+	for _, tc := range m.ToolCalls {
+		n += len(tc.Name)
+		if args, err := json.Marshal(tc.Arguments); err == nil {
+			n += len(args)
+		}
+	}
+	return n/4 + 4 + len(m.Images)*imageTokens
+}
+
+// transcript renders messages as plain text for the summarizer.
+func transcript(messages []providers.Message) string {
+	var b strings.Builder
+	for _, m := range messages {
+		switch {
+		case m.Role == "system" && strings.HasPrefix(m.Content, SummaryPrefix):
+			b.WriteString("Earlier summary:\n" + strings.TrimPrefix(m.Content, SummaryPrefix) + "\n\n")
+		case m.Role == "tool":
+			b.WriteString("tool result: " + clip(m.Content, 1000) + "\n\n")
+		default:
+			if m.Content != "" {
+				b.WriteString(m.Role + ": " + m.Content + "\n\n")
+			}
+			for _, tc := range m.ToolCalls {
+				args, _ := json.Marshal(tc.Arguments)
+				b.WriteString(fmt.Sprintf("%s called %s(%s)\n\n", m.Role, tc.Name, clip(string(args), 500)))
+			}
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func clip(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/providers"
+)
+
+type summaryProvider struct {
+	transcript string
+	models     []string
+}
+
+func (p *summaryProvider) Chat(ctx context.Context, messages []providers.Message, tools []providers.ToolDefinition, model string, options map[string]interface{}) (*providers.LLMResponse, error) {
+	p.models = append(p.models, model)
+	if model == "down" {
+		return nil, fmt.Errorf("unavailable")
+	}
+	p.transcript = messages[len(messages)-1].Content
+	return &providers.LLMResponse{Content: "user asked to fix the build", Model: model, Usage: &providers.UsageInfo{PromptTokens: 90, CompletionTokens: 10}}, nil
+}
+
+func (p *summaryProvider) GetDefaultModel() string { return "test" }
+
+func longHistory() []providers.Message {
+	filler := strings.Repeat("x", 400)
+	return []providers.Message{
+		{Role: "system", Content: "You are Sypher."},
+		{Role: "user", Content: "fix the build " + filler},
+		{Role: "assistant", Content: filler},
+		{Role: "user", Content: "run the tests " + filler},
+		{Role: "assistant", ToolCalls: []providers.ToolCall{{ID: "c1", Name: "exec", Arguments: map[string]interface{}{"command": "go test ./..."}}}},
+		{Role: "tool", Content: filler, ToolCallID: "c1"},
+		{Role: "tool", Content: "ok", ToolCallID: "c1"},
+	}
+}
+
+func TestSummarizer_Budget(t *testing.T) {
+	s := NewSummarizer(config.ContextConfig{MaxTokens: 1000, ReservedForTools: 400, SummarizeThreshold: 6000})
+	if got := s.Budget(); got != 600 {
+		t.Errorf("Budget() = %d, want 600", got)
+	}
+}
+
+func TestSummarizer_Compact(t *testing.T) {
+	s := NewSummarizer(config.ContextConfig{SummarizeThreshold: 300})
+	prov := &summaryProvider{}
+	out, resp, err := s.Compact(context.Background(), prov, longHistory(), []string{"down", "cheap"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp == nil || resp.Model != "cheap" || strings.Join(prov.models, ",") != "down,cheap" {
+		t.Errorf("summary should fall back along the chain: models %v, resp %+v", prov.models, resp)
+	}
+	if out[0].Content != "You are Sypher." {
+		t.Errorf("system prompt not kept: %+v", out[0])
+	}
+	if out[1].Role != "system" || !strings.HasPrefix(out[1].Content, SummaryPrefix) {
+		t.Fatalf("expected summary message, got %+v", out[1])
+	}
+	if !strings.Contains(prov.transcript, "fix the build") {
+		t.Error("original request missing from summarizer transcript")
+	}
+	for i, m := range out {
+		if m.Role == "tool" && (i == 0 || (out[i-1].Role != "tool" && len(out[i-1].ToolCalls) == 0)) {
+			t.Errorf("tool result at %d separated from its tool call", i)
+		}
+	}
+}
+
+func TestSummarizer_UnderBudget(t *testing.T) {
+	s := NewSummarizer(config.ContextConfig{SummarizeThreshold: 100000})
+	in := longHistory()
+	out, resp, err := s.Compact(context.Background(), nil, in, []string{"test"})
+	if err != nil || resp != nil || len(out) != len(in) {
+		t.Errorf("expected unchanged messages, got %d, %v", len(out), err)
+	}
+}
+
+func TestEstimateTokens_Images(t *testing.T) {
+	text := providers.Message{Role: "user", Content: "look"}
+	photo := text
+	photo.Images = []providers.ImagePart{{MimeType: "image/png", Path: "a.png"}, {MimeType: "image/png", Path: "b.png"}}
+	if got, want := estimateTokens([]providers.Message{photo}), estimateTokens([]providers.Message{text})+2*imageTokens; got != want {
+		t.Errorf("estimateTokens with 2 images = %d, want %d", got, want)
+	}
+}
+
+func TestTruncateMessages_KeepsToolPairs(t *testing.T) {
+	out := truncateMessages(longHistory(), 150)
+	if out[0].Role != "system" {
+		t.Error("system prompt dropped")
+	}
+	if out[1].Role == "tool" {
+		t.Errorf("truncation split a tool call from its result: %+v", out[1])
+	}
+}
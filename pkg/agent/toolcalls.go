@@ -0,0 +1,164 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sypherexx/sypher-mini/pkg/providers"
+	"github.com/sypherexx/sypher-mini/pkg/tools"
+)
+
+// defaultParallelTimeout bounds each concurrently run tool call.
+const defaultParallelTimeout = 60 * time.Second
+
+// lookupTool returns the registered tool by name, or nil if unknown.
+func (l *Loop) lookupTool(name string) tools.Tool {
+	t, ok := l.toolRegistry.Get(name)
+	if !ok {
+		return nil
+	}
+	return t
+}
+
+// toolAllowed reports whether agentID may use the tool: the agent's skills list
+// (tool names or capabilities; empty = all) must include it, and the agent must
+// hold a capability the tool requires.
+func (l *Loop) toolAllowed(agentID string, t tools.Tool) bool {
+	if a := l.cfg.AgentByID(agentID); a != nil && len(a.Skills) > 0 && !skillsInclude(a.Skills, t) {
+		return false
+	}
+	if l.capabilities == nil {
+		return true
+	}
+	return l.capabilities.AllowsTool(agentID, t.Name(), t.Capabilities())
+}
+
+// skillsInclude reports whether skills names the tool or one of its capabilities.
+func skillsInclude(skills []string, t tools.Tool) bool {
+	for _, s := range skills {
+		if strings.EqualFold(s, t.Name()) {
+			return true
+		}
+		for _, c := range t.Capabilities() {
+			if strings.EqualFold(s, c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isParallelSafe reports whether the named tool declares itself safe to run concurrently.
+func (l *Loop) isParallelSafe(name string) bool {
+	ps, ok := l.lookupTool(name).(tools.ParallelSafe)
+	return ok && ps.ParallelSafe()
+}
+
+// runsInParallel reports whether the call may run concurrently: its tool is
+// parallel-safe and it does not wait for approval, which the per-call
+// timeout would cut short.
+func (l *Loop) runsInParallel(agentID string, tc providers.ToolCall) bool {
+	if !l.isParallelSafe(tc.Name) {
+		return false
+	}
+	_, needed := l.needsApproval(agentID, tc)
+	return !needed
+}
+
+// executeTool runs a single tool call with rate limiting and metrics.
+func (l *Loop) executeTool(ctx context.Context, taskID, agentID string, tc providers.ToolCall) tools.Response {
+	if l.policyEval != nil && !l.policyEval.CheckRateLimit(agentID, tc.Name) {
+		return tools.ErrorResponse(tc.ID, "Rate limit exceeded", "Rate limit exceeded.", tools.CodeRateLimited, true)
+	}
+	var toolResp tools.Response
+	if tool := l.lookupTool(tc.Name); tool == nil {
+		toolResp = tools.ErrorResponse(tc.ID, "Unknown tool: "+tc.Name, "Unknown tool.", tools.CodePermissionDenied, false)
+	} else if !l.toolAllowed(agentID, tool) {
+		toolResp = tools.ErrorResponse(tc.ID, "Tool not allowed for agent: "+tc.Name, "Tool not allowed.", tools.CodePermissionDenied, false)
+	} else if resp, ok := l.awaitApproval(ctx, taskID, agentID, tc); !ok {
+		toolResp = resp
+	} else {
+		toolResp = tool.Execute(ctx, tools.Request{
+			ToolCallID: tc.ID,
+			TaskID:     taskID,
+			AgentID:    agentID,
+			Name:       tc.Name,
+			Args:       tc.Arguments,
+		})
+	}
+	toolResp.ForLLM = l.redactor.Redact(toolResp.ForLLM)
+	toolResp.ForUser = l.redactor.Redact(toolResp.ForUser)
+
+	if l.metrics != nil {
+		l.metrics.IncToolCall(tc.Name)
+		if toolResp.IsError {
+			l.metrics.IncToolError(tc.Name)
+		}
+	}
+	return toolResp
+}
+
+// runToolCalls executes the tool calls from one LLM turn. Consecutive
+// parallel-safe calls run concurrently, each under its own timeout; any other
+// call runs on its own and acts as a barrier. Results are in call order.
+func (l *Loop) runToolCalls(ctx context.Context, taskID, agentID string, calls []providers.ToolCall) []tools.Response {
+	timeout := defaultParallelTimeout
+	if sec := l.cfg.Tools.ParallelTimeoutSec; sec > 0 {
+		timeout = time.Duration(sec) * time.Second
+	}
+
+	results := make([]tools.Response, len(calls))
+	for i := 0; i < len(calls); {
+		if !l.runsInParallel(agentID, calls[i]) {
+			results[i] = l.executeTool(ctx, taskID, agentID, calls[i])
+			i++
+			continue
+		}
+		end := i
+		for end < len(calls) && l.runsInParallel(agentID, calls[end]) {
+			end++
+		}
+		var wg sync.WaitGroup
+		for j := i; j < end; j++ {
+			wg.Add(1)
+			go func(j int) {
+				defer wg.Done()
+				callCtx, cancel := context.WithTimeout(ctx, timeout)
+				defer cancel()
+				resp := l.executeTool(callCtx, taskID, agentID, calls[j])
+				if resp.IsError && callCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+					resp = tools.ErrorResponse(calls[j].ID, "Tool call timed out", "Tool call timed out.", tools.CodeTimeout, true)
+				}
+				results[j] = resp
+			}(j)
+		}
+		wg.Wait()
+		i = end
+	}
+	return results
+}
+
+// toolTurnMessages returns one assistant message carrying all tool calls followed
+// by one tool result message per call, as providers expect.
+func toolTurnMessages(content string, calls []providers.ToolCall, results []tools.Response) []providers.Message {
+	out := make([]providers.Message, 0, len(calls)+1)
+	out = append(out, providers.Message{
+		Role:      "assistant",
+		Content:   content,
+		ToolCalls: calls,
+	})
+	for i, tc := range calls {
+		toolContent := results[i].ForLLM
+		if results[i].IsError {
+			toolContent = "Error: " + toolContent
+		}
+		out = append(out, providers.Message{
+			Role:       "tool",
+			Content:    toolContent,
+			ToolCallID: tc.ID,
+		})
+	}
+	return out
+}
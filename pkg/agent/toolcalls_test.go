@@ -0,0 +1,174 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sypherexx/sypher-mini/pkg/bus"
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/providers"
+	"github.com/sypherexx/sypher-mini/pkg/tools"
+)
+
+func TestRunToolCalls_ParallelReadOnly(t *testing.T) {
+	// Each request waits until both have arrived, so sequential execution would time out.
+	var wg sync.WaitGroup
+	wg.Add(2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wg.Done()
+		done := make(chan struct{})
+		go func() { wg.Wait(); close(done) }()
+		select {
+		case <-done:
+			w.Write([]byte("ok"))
+		case <-time.After(2 * time.Second):
+			http.Error(w, "not concurrent", http.StatusGatewayTimeout)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Usage.Dir = t.TempDir()
+	loop := NewLoop(cfg, bus.NewMessageBus(10), bus.New(), nil)
+
+	calls := []providers.ToolCall{
+		{ID: "c1", Name: "web_fetch", Arguments: map[string]interface{}{"url": srv.URL + "/a"}},
+		{ID: "c2", Name: "web_fetch", Arguments: map[string]interface{}{"url": srv.URL + "/b"}},
+	}
+	results := loop.runToolCalls(context.Background(), "t1", "main", calls)
+	for i, r := range results {
+		if r.IsError {
+			t.Errorf("call %d failed: %s", i, r.ForLLM)
+		}
+		if r.ToolCallID != calls[i].ID {
+			t.Errorf("result %d out of order: %s", i, r.ToolCallID)
+		}
+	}
+}
+
+func TestToolTurnMessages(t *testing.T) {
+	calls := []providers.ToolCall{{ID: "c1", Name: "web_fetch"}, {ID: "c2", Name: "exec"}}
+	results := []tools.Response{
+		tools.SuccessResponse("c1", "page", "", ""),
+		tools.ErrorResponse("c2", "blocked", "", tools.CodeSafetyBlocked, false),
+	}
+	msgs := toolTurnMessages("checking", calls, results)
+	if len(msgs) != 3 {
+		t.Fatalf("expected 1 assistant + 2 tool messages, got %d", len(msgs))
+	}
+	if msgs[0].Role != "assistant" || len(msgs[0].ToolCalls) != 2 {
+		t.Errorf("assistant message should carry all tool calls: %+v", msgs[0])
+	}
+	if msgs[1].ToolCallID != "c1" || msgs[2].ToolCallID != "c2" || msgs[2].Content != "Error: blocked" {
+		t.Errorf("unexpected tool results: %+v", msgs[1:])
+	}
+}
+
+type echoTool struct{}
+
+func (echoTool) Name() string                   { return "echo" }
+func (echoTool) Description() string            { return "Echo the input" }
+func (echoTool) Schema() map[string]interface{} { return map[string]interface{}{"type": "object"} }
+func (echoTool) Capabilities() []string         { return []string{"notify_user"} }
+func (echoTool) Execute(ctx context.Context, req tools.Request) tools.Response {
+	return tools.SuccessResponse(req.ToolCallID, "echo", "", "")
+}
+
+func TestLoop_RegisteredToolFilteredByCapability(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Usage.Dir = t.TempDir()
+	loop := NewLoop(cfg, bus.NewMessageBus(10), bus.New(), nil)
+	if err := loop.Tools().Register(echoTool{}); err != nil {
+		t.Fatal(err)
+	}
+
+	hasEcho := func(agentID string) bool {
+		for _, d := range loop.toolDefinitions(agentID) {
+			if d.Function.Name == "echo" {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasEcho("main") {
+		t.Error("main has notify_user and should see echo")
+	}
+	if hasEcho("cursor") {
+		t.Error("cursor lacks notify_user and should not see echo")
+	}
+
+	call := providers.ToolCall{ID: "c1", Name: "echo"}
+	if resp := loop.executeTool(context.Background(), "t1", "cursor", call); resp.Code != tools.CodePermissionDenied {
+		t.Errorf("expected permission denied for cursor, got %+v", resp)
+	}
+	if resp := loop.executeTool(context.Background(), "t1", "main", call); resp.IsError {
+		t.Errorf("expected success for main, got %+v", resp)
+	}
+}
+
+func TestExecuteTool_Approval(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Usage.Dir = t.TempDir()
+	cfg.Policies.Approval.Rules = []config.ApprovalRule{{Tools: []string{"echo"}}}
+	msgBus := bus.NewMessageBus(10)
+	loop := NewLoop(cfg, msgBus, bus.New(), nil)
+	if err := loop.Tools().Register(echoTool{}); err != nil {
+		t.Fatal(err)
+	}
+	loop.messageTool.SetReplyTarget("t1", "cli", "cli")
+	defer loop.messageTool.ClearReplyTarget("t1")
+
+	answer := func(reply string) {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		out, ok := msgBus.SubscribeOutbound(ctx)
+		if !ok || out.ApprovalID == "" {
+			t.Errorf("expected approval prompt, got %+v", out)
+			return
+		}
+		if !loop.handleApprovalReply(bus.InboundMessage{Channel: "cli", ChatID: "cli", SenderID: "cli", Content: reply}) {
+			t.Errorf("reply %q not handled", reply)
+		}
+	}
+
+	call := providers.ToolCall{ID: "c1", Name: "echo"}
+	go answer("approve")
+	if resp := loop.executeTool(context.Background(), "t1", "main", call); resp.IsError {
+		t.Errorf("approved call should run, got %+v", resp)
+	}
+	msgBus.SubscribeOutbound(context.Background()) // ack
+
+	go answer("deny")
+	if resp := loop.executeTool(context.Background(), "t1", "main", call); resp.Code != tools.CodePermissionDenied {
+		t.Errorf("denied call should not run, got %+v", resp)
+	}
+}
+
+type leakTool struct{ echoTool }
+
+func (leakTool) Name() string { return "leak" }
+func (leakTool) Execute(ctx context.Context, req tools.Request) tools.Response {
+	return tools.SuccessResponse(req.ToolCallID, "OPENAI_API_KEY=sk-config-0123456789", "", "")
+}
+
+func TestExecuteTool_RedactsSecrets(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Usage.Dir = t.TempDir()
+	cfg.Providers.OpenAI.APIKey = "sk-config-0123456789"
+	loop := NewLoop(cfg, bus.NewMessageBus(10), bus.New(), nil)
+	if err := loop.Tools().Register(leakTool{}); err != nil {
+		t.Fatal(err)
+	}
+	resp := loop.executeTool(context.Background(), "t1", "main", providers.ToolCall{ID: "c1", Name: "leak"})
+	if resp.ForLLM != "OPENAI_API_KEY=[REDACTED]" {
+		t.Errorf("config key should be redacted, got %q", resp.ForLLM)
+	}
+}
@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"log"
+
+	"github.com/sypherexx/sypher-mini/pkg/providers"
+	"github.com/sypherexx/sypher-mini/pkg/task"
+	"github.com/sypherexx/sypher-mini/pkg/usage"
+)
+
+// budgetModels applies usage budgets to the agent's model chain. A "downgrade"
+// budget replaces the chain with its downgrade model; a "stop" budget returns
+// the exceeded budget as an error.
+func (l *Loop) budgetModels(agentID string, models []string) ([]string, error) {
+	if l.usage == nil {
+		return models, nil
+	}
+	ex := l.usage.Check(agentID)
+	if ex == nil {
+		return models, nil
+	}
+	if ex.Downgrade() {
+		return []string{ex.Budget.DowngradeModel}, nil
+	}
+	return nil, ex
+}
+
+// recordUsage accounts one LLM response to the task, agent, session and provider.
+func (l *Loop) recordUsage(t *task.Task, resp *providers.LLMResponse) {
+	rec := usage.Record{
+		TaskID:     t.ID,
+		AgentID:    t.AgentID,
+		SessionKey: t.SessionKey,
+		Provider:   resp.Provider,
+		Model:      resp.Model,
+	}
+	if rec.Provider == "" {
+		rec.Provider = "unknown"
+	}
+	if resp.Usage != nil {
+		rec.PromptTokens = resp.Usage.PromptTokens
+		rec.CompletionTokens = resp.Usage.CompletionTokens
+	}
+	if l.usage != nil {
+		var err error
+		if rec, err = l.usage.Add(rec); err != nil {
+			log.Printf("Usage record failed: %v", err)
+		}
+	}
+	if l.metrics != nil {
+		l.metrics.IncLLMRequest(rec.Provider)
+		l.metrics.AddLLMUsage(rec.Provider, rec.AgentID, rec.PromptTokens, rec.CompletionTokens, rec.CostUSD)
+	}
+}
+
+// Usage returns the usage tracker.
+func (l *Loop) Usage() *usage.Tracker {
+	return l.usage
+}
@@ -0,0 +1,196 @@
+// Package approval tracks tool calls waiting for the user to approve or deny
+// them, and matches chat replies to them.
+package approval
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultTimeout is how long a request waits for a reply when no timeout is configured.
+const DefaultTimeout = 5 * time.Minute
+
+// Decision is the outcome of a request.
+type Decision string
+
+const (
+	Approved Decision = "approved"
+	Denied   Decision = "denied"
+	TimedOut Decision = "timeout"
+)
+
+// Request is a tool call waiting for approval.
+type Request struct {
+	ID         string
+	TaskID     string
+	AgentID    string
+	ToolCallID string
+	Tool       string
+	Summary    string // what will run, e.g. the command line
+	Reason     string // the rule that matched
+	Channel    string // where the prompt was sent and replies are accepted
+	ChatID     string
+	CreatedAt  time.Time
+}
+
+// Prompt is the message asking the user to decide.
+func (r *Request) Prompt(timeout time.Duration) string {
+	return fmt.Sprintf("Approval needed [%s]: %s wants to run %s: %s\n(%s)\nReply \"approve %s\" or \"deny %s\" within %s.",
+		r.ID, r.AgentID, r.Tool, r.Summary, r.Reason, r.ID, r.ID, timeout.Round(time.Second))
+}
+
+type pending struct {
+	req   Request
+	reply chan reply
+}
+
+type reply struct {
+	decision Decision
+	by       string
+}
+
+// Manager holds the open requests.
+type Manager struct {
+	approvers map[string]bool // nil = anyone in the chat
+	mu        sync.Mutex
+	pending   map[string]*pending
+}
+
+// NewManager creates a manager. If approvers is not empty, only those sender
+// IDs may answer.
+func NewManager(approvers []string) *Manager {
+	m := &Manager{pending: make(map[string]*pending)}
+	if len(approvers) > 0 {
+		m.approvers = make(map[string]bool, len(approvers))
+		for _, a := range approvers {
+			m.approvers[a] = true
+		}
+	}
+	return m
+}
+
+// Open registers req and assigns its ID. The caller sends the prompt and
+// then calls Wait.
+func (m *Manager) Open(req Request) *Request {
+	req.ID = uuid.New().String()[:8]
+	req.CreatedAt = time.Now()
+	m.mu.Lock()
+	m.pending[req.ID] = &pending{req: req, reply: make(chan reply, 1)}
+	m.mu.Unlock()
+	return &req
+}
+
+// Wait blocks until the request is answered, timeout passes or ctx ends, and
+// removes it. It returns the decision and who made it ("" unless answered).
+// A ctx deadline counts as a timeout; other ctx ends deny the call.
+func (m *Manager) Wait(ctx context.Context, id string, timeout time.Duration) (Decision, string) {
+	m.mu.Lock()
+	p, ok := m.pending[id]
+	m.mu.Unlock()
+	if !ok {
+		return Denied, ""
+	}
+	defer func() {
+		m.mu.Lock()
+		delete(m.pending, id)
+		m.mu.Unlock()
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-p.reply:
+		return r.decision, r.by
+	case <-timer.C:
+		return TimedOut, ""
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return TimedOut, ""
+		}
+		return Denied, "cancelled"
+	}
+}
+
+// Pending returns the open requests of a chat, oldest first.
+func (m *Manager) Pending(channel, chatID string) []Request {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Request
+	for _, p := range m.pending {
+		if p.req.Channel == channel && p.req.ChatID == chatID {
+			out = append(out, p.req)
+		}
+	}
+	sort.Slice(out, func(a, b int) bool { return out[a].CreatedAt.Before(out[b].CreatedAt) })
+	return out
+}
+
+// HandleReply treats text from a chat as an answer to one of its open
+// requests: "approve <id>", "deny <id>", or a bare yes/no when only one is
+// open. handled is false when text is not an approval reply (or the chat has
+// nothing open), so it can be processed as a normal message; ack is the
+// message to send back and id the request it answered, if any.
+func (m *Manager) HandleReply(channel, chatID, senderID, text string) (ack, id string, handled bool) {
+	open := m.Pending(channel, chatID)
+	if len(open) == 0 {
+		return "", "", false
+	}
+	fields := strings.Fields(strings.ToLower(strings.TrimPrefix(strings.TrimSpace(text), "/")))
+	if len(fields) == 0 || len(fields) > 2 {
+		return "", "", false
+	}
+	var decision Decision
+	switch fields[0] {
+	case "approve", "approved", "yes", "y", "ok":
+		decision = Approved
+	case "deny", "denied", "reject", "no", "n":
+		decision = Denied
+	default:
+		return "", "", false
+	}
+	if m.approvers != nil && !m.approvers[senderID] {
+		return "You are not allowed to answer approval requests.", "", true
+	}
+
+	var target *Request
+	if len(fields) == 2 {
+		for i := range open {
+			if open[i].ID == fields[1] {
+				target = &open[i]
+			}
+		}
+		if target == nil {
+			return fmt.Sprintf("No open approval request %s.", fields[1]), "", true
+		}
+	} else if len(open) == 1 {
+		target = &open[0]
+	} else {
+		ids := make([]string, len(open))
+		for i, r := range open {
+			ids[i] = r.ID
+		}
+		return fmt.Sprintf("Several requests are waiting (%s); reply with \"%s <id>\".", strings.Join(ids, ", "), fields[0]), "", true
+	}
+
+	m.mu.Lock()
+	p, ok := m.pending[target.ID]
+	m.mu.Unlock()
+	if !ok {
+		return fmt.Sprintf("Approval request %s is no longer open.", target.ID), "", true
+	}
+	select {
+	case p.reply <- reply{decision: decision, by: channel + ":" + senderID}:
+	default:
+		return fmt.Sprintf("Approval request %s was already answered.", target.ID), "", true
+	}
+	word := "Approved"
+	if decision == Denied {
+		word = "Denied"
+	}
+	return fmt.Sprintf("%s %s: %s", word, target.ID, target.Summary), target.ID, true
+}
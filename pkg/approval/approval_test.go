@@ -0,0 +1,89 @@
+package approval
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestManager_ApproveAndDeny(t *testing.T) {
+	m := NewManager(nil)
+	req := m.Open(Request{Tool: "exec", Summary: "rm build.log", Channel: "whatsapp", ChatID: "chat1"})
+
+	if _, _, handled := m.HandleReply("whatsapp", "other", "u1", "yes"); handled {
+		t.Error("reply from another chat should not be handled")
+	}
+	if _, _, handled := m.HandleReply("whatsapp", "chat1", "u1", "what is this?"); handled {
+		t.Error("ordinary message should not be handled")
+	}
+	ack, id, handled := m.HandleReply("whatsapp", "chat1", "u1", "yes")
+	if !handled || id != req.ID {
+		t.Fatalf("expected reply to be handled for %s, got %q %q %v", req.ID, ack, id, handled)
+	}
+	decision, by := m.Wait(context.Background(), req.ID, time.Second)
+	if decision != Approved || by != "whatsapp:u1" {
+		t.Errorf("got %s by %q", decision, by)
+	}
+	if len(m.Pending("whatsapp", "chat1")) != 0 {
+		t.Error("request should be removed after Wait")
+	}
+
+	req = m.Open(Request{Tool: "exec", Channel: "cli", ChatID: "cli"})
+	go m.HandleReply("cli", "cli", "cli", "deny "+req.ID)
+	if decision, _ := m.Wait(context.Background(), req.ID, time.Second); decision != Denied {
+		t.Errorf("expected denied, got %s", decision)
+	}
+}
+
+func TestManager_Timeout(t *testing.T) {
+	m := NewManager(nil)
+	req := m.Open(Request{Tool: "exec", Channel: "cli", ChatID: "cli"})
+	if decision, _ := m.Wait(context.Background(), req.ID, 20*time.Millisecond); decision != TimedOut {
+		t.Errorf("expected timeout, got %s", decision)
+	}
+
+	req = m.Open(Request{Tool: "exec", Channel: "cli", ChatID: "cli"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if decision, _ := m.Wait(ctx, req.ID, time.Second); decision != Denied {
+		t.Errorf("cancelled task should deny, got %s", decision)
+	}
+	req = m.Open(Request{Tool: "exec", Channel: "cli", ChatID: "cli"})
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if decision, _ := m.Wait(ctx, req.ID, time.Second); decision != TimedOut {
+		t.Errorf("expired task deadline should time out, got %s", decision)
+	}
+}
+
+func TestManager_Approvers(t *testing.T) {
+	m := NewManager([]string{"owner"})
+	req := m.Open(Request{Tool: "exec", Channel: "whatsapp", ChatID: "group"})
+	if _, id, handled := m.HandleReply("whatsapp", "group", "guest", "approve"); !handled || id != "" {
+		t.Error("reply from a non-approver should be refused")
+	}
+	if _, id, _ := m.HandleReply("whatsapp", "group", "owner", "approve"); id != req.ID {
+		t.Error("approver reply should answer the request")
+	}
+	if decision, _ := m.Wait(context.Background(), req.ID, time.Second); decision != Approved {
+		t.Errorf("expected approved, got %s", decision)
+	}
+}
+
+func TestManager_SeveralPending(t *testing.T) {
+	m := NewManager(nil)
+	a := m.Open(Request{Tool: "exec", Channel: "cli", ChatID: "cli"})
+	b := m.Open(Request{Tool: "git", Channel: "cli", ChatID: "cli"})
+	if _, id, handled := m.HandleReply("cli", "cli", "cli", "yes"); !handled || id != "" {
+		t.Error("bare yes with two open requests should ask for an id")
+	}
+	if _, id, _ := m.HandleReply("cli", "cli", "cli", "no "+b.ID); id != b.ID {
+		t.Errorf("expected %s to be answered, got %q", b.ID, id)
+	}
+	if decision, _ := m.Wait(context.Background(), b.ID, time.Second); decision != Denied {
+		t.Errorf("expected denied, got %s", decision)
+	}
+	if p := m.Pending("cli", "cli"); len(p) != 1 || p[0].ID != a.ID {
+		t.Errorf("expected only %s pending, got %+v", a.ID, p)
+	}
+}
@@ -15,6 +15,7 @@ import (
 type Logger struct {
 	dir       string
 	integrity string // "none" or "checksum"
+	redact    func(string) string
 	mu        sync.Mutex
 }
 
@@ -35,11 +36,45 @@ func NewWithIntegrity(dir, integrity string) *Logger {
 	return &Logger{dir: dir, integrity: integrity}
 }
 
+// SetRedact sets a function applied to every line before it is written,
+// e.g. to remove secret values. Call it before the logger is used.
+func (l *Logger) SetRedact(fn func(string) string) {
+	l.redact = fn
+}
+
 // LogCommand logs a command execution for a task.
 func (l *Logger) LogCommand(taskID, toolCallID, command, cwd string, exitCode int, outputSummary string) error {
+	return l.write(taskID, fmt.Sprintf("[%s] [%s] %s | exec | cmd=%q cwd=%q exit=%d | %s",
+		taskID, toolCallID, time.Now().Format(time.RFC3339), command, cwd, exitCode, l.summary(outputSummary)))
+}
+
+// LogFileOp logs a file tool operation (read_file, write_file, edit_file, list_dir) for a task.
+func (l *Logger) LogFileOp(taskID, toolCallID, op, path, summary string) error {
+	return l.write(taskID, fmt.Sprintf("[%s] [%s] %s | %s | path=%q | %s",
+		taskID, toolCallID, time.Now().Format(time.RFC3339), op, path, l.summary(summary)))
+}
+
+// LogGitOp logs a git tool operation with HEAD before and after it.
+func (l *Logger) LogGitOp(taskID, toolCallID, op, repo, headBefore, headAfter, summary string) error {
+	return l.write(taskID, fmt.Sprintf("[%s] [%s] %s | git | op=%s repo=%q head=%s..%s | %s",
+		taskID, toolCallID, time.Now().Format(time.RFC3339), op, repo, headBefore, headAfter, l.summary(summary)))
+}
+
+// LogApproval logs the decision on a tool call that required approval:
+// approved, denied or timeout, and who decided.
+func (l *Logger) LogApproval(taskID, toolCallID, tool, decision, by, summary string) error {
+	return l.write(taskID, fmt.Sprintf("[%s] [%s] %s | approval | tool=%s decision=%s by=%q | %s",
+		taskID, toolCallID, time.Now().Format(time.RFC3339), tool, decision, by, l.summary(summary)))
+}
+
+// write appends line to the task's log, adding a checksum when integrity is enabled.
+func (l *Logger) write(taskID, line string) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	if l.redact != nil {
+		line = l.redact(line)
+	}
 	path := filepath.Join(l.dir, taskID+".log")
 	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 	if err != nil {
@@ -47,9 +82,6 @@ func (l *Logger) LogCommand(taskID, toolCallID, command, cwd string, exitCode in
 	}
 	defer f.Close()
 
-	ts := time.Now().Format(time.RFC3339)
-	line := fmt.Sprintf("[%s] [%s] %s | exec | cmd=%q cwd=%q exit=%d | %s",
-		taskID, toolCallID, ts, command, cwd, exitCode, truncate(outputSummary, 200))
 	if l.integrity == "checksum" {
 		sum := sha256.Sum256([]byte(line))
 		line = fmt.Sprintf("%s # %s\n", line, hex.EncodeToString(sum[:8]))
@@ -60,6 +92,15 @@ func (l *Logger) LogCommand(taskID, toolCallID, command, cwd string, exitCode in
 	return err
 }
 
+// summary shortens s for a log line. Redaction runs first so a secret cut
+// in half by truncation is still removed.
+func (l *Logger) summary(s string) string {
+	if l.redact != nil {
+		s = l.redact(s)
+	}
+	return truncate(s, 200)
+}
+
 func truncate(s string, max int) string {
 	if len(s) <= max {
 		return s
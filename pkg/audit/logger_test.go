@@ -3,6 +3,7 @@ package audit
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -24,3 +25,71 @@ func TestLogger_LogCommand(t *testing.T) {
 		t.Error("expected log content")
 	}
 }
+
+func TestLogger_LogFileOp(t *testing.T) {
+	dir := t.TempDir()
+	l := NewWithIntegrity(dir, "checksum")
+
+	if err := l.LogFileOp("task1", "tc1", "edit_file", "/ws/main.go", "replaced=1"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "task1.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := string(data)
+	if !strings.Contains(line, `| edit_file | path="/ws/main.go" | replaced=1 # `) {
+		t.Errorf("unexpected log line: %q", line)
+	}
+}
+
+func TestLogger_LogGitOp(t *testing.T) {
+	dir := t.TempDir()
+	l := New(dir)
+
+	if err := l.LogGitOp("task1", "tc1", "commit", "/ws", "abc1234", "def5678", "1 file changed"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "task1.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `| git | op=commit repo="/ws" head=abc1234..def5678 | 1 file changed`) {
+		t.Errorf("unexpected log line: %q", data)
+	}
+}
+
+func TestLogger_LogApproval(t *testing.T) {
+	dir := t.TempDir()
+	l := New(dir)
+
+	if err := l.LogApproval("task1", "tc1", "exec", "approved", "whatsapp:123", "rm build.log"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "task1.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `| approval | tool=exec decision=approved by="whatsapp:123" | rm build.log`) {
+		t.Errorf("unexpected log line: %q", data)
+	}
+}
+
+func TestLogger_Redact(t *testing.T) {
+	dir := t.TempDir()
+	l := New(dir)
+	l.SetRedact(func(s string) string { return strings.ReplaceAll(s, "sk-secret", "[REDACTED]") })
+
+	out := strings.Repeat("x", 195) + "sk-secret"
+	if err := l.LogCommand("task1", "tc1", "echo sk-secret", "/tmp", 0, out); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "task1.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sk-") {
+		t.Errorf("secret (or part of it) left in log: %s", data)
+	}
+}
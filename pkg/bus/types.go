@@ -12,8 +12,17 @@ type InboundMessage struct {
 }
 
 // OutboundMessage represents an outgoing message to a channel.
+// Streamed responses share a StreamID. Partial marks a message sent while the
+// response is still being generated. With Replace, Content is the full text so
+// far and supersedes earlier messages of the stream (edit-capable channels);
+// without it, Content is the next chunk.
 type OutboundMessage struct {
-	Channel string `json:"channel"`
-	ChatID  string `json:"chat_id"`
-	Content string `json:"content"`
+	Channel  string `json:"channel"`
+	ChatID   string `json:"chat_id"`
+	Content  string `json:"content"`
+	StreamID string `json:"stream_id,omitempty"`
+	Partial  bool   `json:"partial,omitempty"`
+	Replace  bool   `json:"replace,omitempty"`
+	// ApprovalID marks an approval prompt (or its acknowledgement) for that request
+	ApprovalID string `json:"approval_id,omitempty"`
 }
@@ -40,11 +40,15 @@ func Load(path string) (*Registry, error) {
 func DefaultRegistry() *Registry {
 	return &Registry{
 		Tools: map[string][]string{
-			"exec":       {"code_generation", "log_analysis", "deploy_service"},
-			"edit_file":  {"code_generation", "file_edit"},
-			"read_file":  {"log_analysis", "file_edit"},
-			"message":    {"notify_user"},
-			"web_fetch":  {"web_search"},
+			"exec":        {"code_generation", "log_analysis", "deploy_service"},
+			"edit_file":   {"code_generation", "file_edit"},
+			"read_file":   {"log_analysis", "file_edit"},
+			"write_file":  {"code_generation", "file_edit"},
+			"list_dir":    {"log_analysis", "file_edit"},
+			"search_code": {"code_generation", "log_analysis", "file_edit"},
+			"git":         {"code_generation", "file_edit"},
+			"message":     {"notify_user"},
+			"web_fetch":   {"web_search"},
 		},
 		Agents: map[string][]string{
 			"cursor":      {"code_generation"},
@@ -84,6 +88,32 @@ func (r *Registry) ResolveAgents(capability string) []string {
 	return out
 }
 
+// AllowsTool reports whether agentID may use tool. A registry mapping for the
+// tool replaces toolCaps, the capabilities the tool declares itself, so config
+// can narrow a tool as well as widen it. Agents without a registry entry and
+// tools without capabilities are unrestricted.
+func (r *Registry) AllowsTool(agentID, tool string, toolCaps []string) bool {
+	agentCaps, ok := r.Agents[agentID]
+	if !ok {
+		return true
+	}
+	caps, mapped := r.Tools[tool]
+	if !mapped {
+		caps = toolCaps
+	}
+	if len(caps) == 0 {
+		return true
+	}
+	for _, tc := range caps {
+		for _, ac := range agentCaps {
+			if strings.EqualFold(tc, ac) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func expandPath(p string) string {
 	if p == "" {
 		return p
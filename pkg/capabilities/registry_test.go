@@ -39,3 +39,25 @@ func TestResolveAgents(t *testing.T) {
 		t.Error("expected agents for code_generation")
 	}
 }
+
+func TestAllowsTool(t *testing.T) {
+	r := DefaultRegistry()
+	if !r.AllowsTool("cursor", "exec", nil) {
+		t.Error("cursor should be allowed exec via code_generation")
+	}
+	if r.AllowsTool("cursor", "web_fetch", []string{"web_search"}) {
+		t.Error("cursor should not be allowed web_fetch")
+	}
+	if !r.AllowsTool("cursor", "custom", nil) {
+		t.Error("tool without capabilities should be allowed")
+	}
+	if r.AllowsTool("cursor", "web_fetch", []string{"code_generation"}) {
+		t.Error("registry mapping should replace the tool's own capabilities")
+	}
+	if !r.AllowsTool("cursor", "plugin", []string{"code_generation"}) {
+		t.Error("unmapped tool should use its own capabilities")
+	}
+	if !r.AllowsTool("unknown-agent", "web_fetch", nil) {
+		t.Error("agent without registry entry should be unrestricted")
+	}
+}
@@ -56,7 +56,11 @@ func (w *WhatsAppBaileysClient) Run(ctx context.Context) error {
 		if !ok {
 			return ctx.Err()
 		}
-		if out.Channel != "whatsapp" {
+		if out.Channel != "whatsapp" || out.Content == "" {
+			continue
+		}
+		if out.Partial && out.Replace {
+			// No message edits: wait for the final text
 			continue
 		}
 		if err := w.sendWithRateLimit(ctx, out.ChatID, out.Content); err != nil {
@@ -25,11 +25,12 @@ type WhatsAppBridge struct {
 
 // BridgeMessage is the JSON format for bridge messages.
 type BridgeMessage struct {
-	Type    string `json:"type"`
-	From    string `json:"from,omitempty"`
-	To      string `json:"to,omitempty"`
-	Content string `json:"content,omitempty"`
-	ChatID  string `json:"chat_id,omitempty"`
+	Type    string   `json:"type"`
+	From    string   `json:"from,omitempty"`
+	To      string   `json:"to,omitempty"`
+	Content string   `json:"content,omitempty"`
+	ChatID  string   `json:"chat_id,omitempty"`
+	Media   []string `json:"media,omitempty"` // inbound attachment URLs
 }
 
 // NewWhatsAppBridge creates a WhatsApp bridge channel.
@@ -106,6 +107,7 @@ func (w *WhatsAppBridge) Run(ctx context.Context) error {
 					ChatID:   msg.ChatID,
 					Content:  msg.Content,
 					SenderID: msg.From,
+					Media:    msg.Media,
 				})
 			}
 		}
@@ -129,7 +131,11 @@ func (w *WhatsAppBridge) forwardOutbound(ctx context.Context, conn *websocket.Co
 		if !ok {
 			return
 		}
-		if out.Channel != "whatsapp" {
+		if out.Channel != "whatsapp" || out.Content == "" {
+			continue
+		}
+		if out.Partial && out.Replace {
+			// No message edits: wait for the final text
 			continue
 		}
 		payload := BridgeMessage{
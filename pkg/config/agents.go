@@ -0,0 +1,43 @@
+package config
+
+// AgentByID returns the agent with the given ID, or nil if it is not in agents.list.
+func (c *Config) AgentByID(id string) *AgentConfig {
+	for i := range c.Agents.List {
+		if c.Agents.List[i].ID == id {
+			return &c.Agents.List[i]
+		}
+	}
+	return nil
+}
+
+// AgentWorkspace returns the expanded workspace for the agent, falling back to
+// agents.defaults.workspace.
+func (c *Config) AgentWorkspace(id string) string {
+	if a := c.AgentByID(id); a != nil && a.Workspace != "" {
+		return ExpandPath(a.Workspace)
+	}
+	return ExpandPath(c.Agents.Defaults.Workspace)
+}
+
+// AgentModels returns the agent's model chain: primary first, then fallbacks.
+// The primary defaults to agents.defaults.model.
+func (c *Config) AgentModels(id string) []string {
+	primary := c.Agents.Defaults.Model
+	var fallbacks []string
+	if a := c.AgentByID(id); a != nil && a.Model != nil {
+		if a.Model.Primary != "" {
+			primary = a.Model.Primary
+		}
+		fallbacks = a.Model.Fallbacks
+	}
+	var out []string
+	seen := make(map[string]bool)
+	for _, m := range append([]string{primary}, fallbacks...) {
+		if m == "" || seen[m] {
+			continue
+		}
+		seen[m] = true
+		out = append(out, m)
+	}
+	return out
+}
@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+
+	"github.com/sypherexx/sypher-mini/pkg/secrets"
 )
 
 // Config holds the full Sypher-mini configuration.
@@ -25,7 +27,21 @@ type Config struct {
 	Monitors            MonitorsConfig  `json:"monitors,omitempty"`
 	Replay              ReplayConfig      `json:"replay,omitempty"`
 	Idempotency         IdempotencyConfig `json:"idempotency,omitempty"`
+	Session             SessionConfig     `json:"session,omitempty"`
+	Usage               UsageConfig       `json:"usage,omitempty"`
+	Media               MediaConfig       `json:"media,omitempty"`
+	Secrets             SecretsConfig     `json:"secrets,omitempty"`
 	mu                  sync.RWMutex
+	resolverOnce        sync.Once
+	resolver            *secrets.Resolver
+}
+
+// SecretsConfig selects where named secrets are read from: "secret://name"
+// config values and agents.list[].secrets.
+type SecretsConfig struct {
+	Backend string `json:"backend,omitempty"`  // "env" (default), "keychain", "secret_service" or "file"
+	File    string `json:"file,omitempty"`     // file backend: default ~/.sypher-mini/secrets.enc
+	KeyFile string `json:"key_file,omitempty"` // file backend without SYPHER_SECRETS_PASSPHRASE: default ~/.sypher-mini/secrets.key
 }
 
 // IdempotencyConfig holds session dedup config.
@@ -34,6 +50,56 @@ type IdempotencyConfig struct {
 	TTLSec  int  `json:"ttl_sec"`
 }
 
+// SessionConfig holds per-session conversation history config.
+type SessionConfig struct {
+	MaxMessages int `json:"max_messages"` // history cap per session; 0 = default (50)
+}
+
+// UsageConfig configures token accounting, pricing and budgets.
+type UsageConfig struct {
+	Dir     string       `json:"dir,omitempty"` // default ~/.sypher-mini/usage
+	Pricing []ModelPrice `json:"pricing,omitempty"`
+	Budgets []Budget     `json:"budgets,omitempty"`
+}
+
+// ModelPrice overrides the built-in price of a model in USD per million tokens.
+// Model "*" applies to every model of the provider without its own entry.
+type ModelPrice struct {
+	Provider      string  `json:"provider"`
+	Model         string  `json:"model"`
+	InputPerMTok  float64 `json:"input_per_mtok"`
+	OutputPerMTok float64 `json:"output_per_mtok"`
+}
+
+// Budget caps estimated LLM spend for an agent ("*" = all agents combined).
+type Budget struct {
+	AgentID        string  `json:"agent_id"`
+	DailyUSD       float64 `json:"daily_usd,omitempty"`
+	MonthlyUSD     float64 `json:"monthly_usd,omitempty"`
+	Action         string  `json:"action,omitempty"`          // "stop" (default) or "downgrade"
+	DowngradeModel string  `json:"downgrade_model,omitempty"` // provider/model used once exceeded with action "downgrade"
+}
+
+// MediaConfig controls inbound media attachments (images, documents, audio).
+// Files are stored under {agent workspace}/media.
+type MediaConfig struct {
+	MaxMB         int                 `json:"max_mb,omitempty"`         // largest accepted file (default 20)
+	MaxTextChars  int                 `json:"max_text_chars,omitempty"` // extracted document text cap (default 20000)
+	TrustedURLs   []string            `json:"trusted_urls,omitempty"`   // media servers allowed on private addresses (the WhatsApp extension always is)
+	Transcription TranscriptionConfig `json:"transcription,omitempty"`
+}
+
+// TranscriptionConfig selects how inbound audio is turned into text.
+type TranscriptionConfig struct {
+	Backend    string `json:"backend,omitempty"`     // "openai" (any /audio/transcriptions API), "whisper_cpp", or "" (off)
+	APIBase    string `json:"api_base,omitempty"`    // openai: default providers.openai.api_base or https://api.openai.com/v1
+	APIKey     string `json:"api_key,omitempty"`     // openai: default providers.openai.api_key
+	Model      string `json:"model,omitempty"`       // openai: default whisper-1; whisper_cpp: path to the ggml model file
+	Binary     string `json:"binary,omitempty"`      // whisper_cpp: default whisper-cli
+	Language   string `json:"language,omitempty"`    // ISO-639-1 hint; default auto-detect
+	TimeoutSec int    `json:"timeout_sec,omitempty"` // default 120
+}
+
 // ReplayConfig holds replay persistence config.
 type ReplayConfig struct {
 	Enabled bool   `json:"enabled"`
@@ -78,8 +144,9 @@ type ContextConfig struct {
 
 // ToolsConfig holds tool-specific config.
 type ToolsConfig struct {
-	Exec          ExecToolConfig          `json:"exec,omitempty"`
-	LiveMonitoring LiveMonitoringConfig   `json:"live_monitoring,omitempty"`
+	Exec               ExecToolConfig       `json:"exec,omitempty"`
+	LiveMonitoring     LiveMonitoringConfig `json:"live_monitoring,omitempty"`
+	ParallelTimeoutSec int                  `json:"parallel_timeout_sec,omitempty"` // per-call timeout for concurrently run tools
 }
 
 // LiveMonitoringConfig holds config for tail_output and stream_command.
@@ -89,8 +156,54 @@ type LiveMonitoringConfig struct {
 
 // ExecToolConfig holds exec tool config.
 type ExecToolConfig struct {
-	CustomDenyPatterns []string `json:"custom_deny_patterns,omitempty"`
-	TimeoutSec         int      `json:"timeout_sec"`
+	CustomDenyPatterns []string        `json:"custom_deny_patterns,omitempty"` // regexes on the raw command line, checked before rules
+	TimeoutSec         int             `json:"timeout_sec"`
+	Rules              ExecRulesConfig `json:"rules,omitempty"`
+	Sandbox            SandboxConfig   `json:"sandbox,omitempty"`
+	Env                ExecEnvConfig   `json:"env,omitempty"`
+}
+
+// ExecEnvConfig controls the environment of exec and stream_command. Commands
+// start from a minimal allowlist of server variables, not the full environment.
+type ExecEnvConfig struct {
+	Allow []string          `json:"allow,omitempty"` // extra names passed through; "PREFIX_*" matches a prefix
+	Set   map[string]string `json:"set,omitempty"`   // fixed values
+}
+
+// ExecRulesConfig is the structured command policy of exec and
+// stream_command, evaluated on the parsed command line.
+type ExecRulesConfig struct {
+	AllowedExecutables  []string               `json:"allowed_executables,omitempty"`   // empty = any executable without a deny rule
+	Commands            map[string]CommandRule `json:"commands,omitempty"`              // per executable; replaces the built-in rule of that name
+	DenyRedirectTargets []string               `json:"deny_redirect_targets,omitempty"` // globs, added to the built-in block device list
+	AllowRedirects      *bool                  `json:"allow_redirects,omitempty"`       // default true
+	AllowSubshells      *bool                  `json:"allow_subshells,omitempty"`       // ( ), $( ), backticks; default true
+}
+
+// CommandRule restricts one executable (or one subcommand of it).
+type CommandRule struct {
+	Deny            bool                   `json:"deny,omitempty"`
+	ForbiddenFlags  []string               `json:"forbidden_flags,omitempty"`  // "-r" also matches "-rf"; "--force" also "--force=x"
+	ForbiddenArgs   []string               `json:"forbidden_args,omitempty"`   // globs matched against each argument
+	ForbiddenValues map[string][]string    `json:"forbidden_values,omitempty"` // flag -> globs for its value, case-insensitive ("-s": ["KILL"])
+	Subcommands     map[string]CommandRule `json:"subcommands,omitempty"`      // keyed by the first non-flag argument
+	ValueFlags      []string               `json:"value_flags,omitempty"`      // flags whose value precedes the subcommand (git -C dir)
+	DenyPipeInput   bool                   `json:"deny_pipe_input,omitempty"`  // e.g. no "curl ... | sh"
+	AllowRedirects  *bool                  `json:"allow_redirects,omitempty"`  // overrides rules.allow_redirects
+	AllowSubshells  *bool                  `json:"allow_subshells,omitempty"`  // overrides rules.allow_subshells for substitutions in its arguments
+}
+
+// SandboxConfig selects and tunes the executor that runs exec and
+// stream_command processes.
+type SandboxConfig struct {
+	Backend       string            `json:"backend,omitempty"`         // "host" or "bwrap"; empty = by deployment mode
+	Modes         map[string]string `json:"modes,omitempty"`           // deployment.mode -> backend
+	BwrapPath     string            `json:"bwrap_path,omitempty"`      // default: bwrap from PATH
+	ReadOnlyPaths []string          `json:"read_only_paths,omitempty"` // extra host paths mounted read-only
+	CgroupParent  string            `json:"cgroup_parent,omitempty"`   // cgroup v2 dir for per-command cgroups
+	MemoryMB      int               `json:"memory_mb,omitempty"`       // 0 = no limit
+	CPUPercent    int               `json:"cpu_percent,omitempty"`     // 100 = one core; 0 = no limit
+	PidsMax       int               `json:"pids_max,omitempty"`        // 0 = no limit
 }
 
 // AuditConfig holds audit logger config.
@@ -105,6 +218,24 @@ type PoliciesConfig struct {
 	Files       []FilePolicy   `json:"files,omitempty"`
 	Network     []NetPolicy    `json:"network,omitempty"`
 	RateLimits  []RateLimit    `json:"rate_limits,omitempty"`
+	Approval    ApprovalPolicy `json:"approval,omitempty"`
+}
+
+// ApprovalPolicy marks tool calls that need confirmation from the user
+// before they run.
+type ApprovalPolicy struct {
+	Rules      []ApprovalRule `json:"rules,omitempty"`
+	TimeoutSec int            `json:"timeout_sec,omitempty"` // default 300; no reply = denied
+	Approvers  []string       `json:"approvers,omitempty"`   // sender IDs that may answer; empty = anyone in the originating chat
+}
+
+// ApprovalRule matches tool calls. All conditions that are set must hold;
+// a rule with none set matches every call.
+type ApprovalRule struct {
+	Tools            []string `json:"tools,omitempty"`             // tool names; "*" = any
+	AgentIDs         []string `json:"agent_ids,omitempty"`         // "*" = any
+	Patterns         []string `json:"patterns,omitempty"`          // regexes on the command (exec, stream_command) or the JSON arguments
+	OutsideWorkspace bool     `json:"outside_workspace,omitempty"` // a path argument resolves outside the agent workspace
 }
 
 // FilePolicy defines per-file access.
@@ -160,6 +291,9 @@ type AgentConfig struct {
 	Command          string            `json:"command,omitempty"`
 	Args             []string          `json:"args,omitempty"`
 	AllowedCommands  []string          `json:"allowed_commands,omitempty"`
+	GitPush          []string          `json:"git_push,omitempty"` // remotes the git tool may push to; "*" = any, empty = no push
+	Executor         string            `json:"executor,omitempty"` // "host" or "bwrap"; overrides tools.exec.sandbox
+	Secrets          map[string]string `json:"secrets,omitempty"`  // env var -> secret name, injected into exec and stream_command
 }
 
 // PeerMatch matches a peer for binding.
@@ -183,7 +317,15 @@ type AgentBinding struct {
 
 // ChannelsConfig holds channel configurations.
 type ChannelsConfig struct {
-	WhatsApp WhatsAppConfig `json:"whatsapp"`
+	WhatsApp  WhatsAppConfig  `json:"whatsapp"`
+	Streaming StreamingConfig `json:"streaming,omitempty"`
+}
+
+// StreamingConfig controls partial output while the LLM is still generating.
+type StreamingConfig struct {
+	Modes          map[string]string `json:"modes,omitempty"`            // channel -> "chunk" | "edit"; unset = off
+	MinChunkChars  int               `json:"min_chunk_chars,omitempty"`  // chunk mode: send once this much text ends at a line break (default 200)
+	EditIntervalMs int               `json:"edit_interval_ms,omitempty"` // edit mode: min time between updates (default 1000)
 }
 
 // WhatsAppConfig holds WhatsApp channel config.
@@ -204,6 +346,37 @@ type ProvidersConfig struct {
 	OpenAI          ProviderConfig         `json:"openai"`
 	Anthropic       ProviderConfig         `json:"anthropic"`
 	Gemini          ProviderConfig         `json:"gemini"`
+	Ollama          LocalProviderConfig    `json:"ollama,omitempty"`
+	LlamaCpp        LocalProviderConfig    `json:"llamacpp,omitempty"`
+	Catalog         []CatalogEntry         `json:"catalog,omitempty"` // overrides built-in model tiers
+	CircuitBreaker  CircuitBreakerConfig   `json:"circuit_breaker,omitempty"`
+}
+
+// CircuitBreakerConfig controls when a failing provider is skipped.
+type CircuitBreakerConfig struct {
+	FailureThreshold int `json:"failure_threshold,omitempty"` // consecutive failures before opening (default 3)
+	OpenSec          int `json:"open_sec,omitempty"`          // time open before a half-open probe (default 30)
+}
+
+// LocalProviderConfig configures a local model server (Ollama, llama.cpp).
+// Local providers need no API key and stay available in --safe mode.
+type LocalProviderConfig struct {
+	Enabled bool   `json:"enabled"`
+	APIBase string `json:"api_base,omitempty"` // default http://localhost:11434 (ollama), http://localhost:8080 (llamacpp)
+	APIKey  string `json:"api_key,omitempty"`  // llama.cpp --api-key, if set
+	Model   string `json:"model,omitempty"`
+	Tools   string `json:"tools,omitempty"`   // "native", "prompt" or "auto" (default: native, prompt if unsupported)
+	Trusted bool   `json:"trusted,omitempty"` // keep in safe mode although api_base is not a loopback address
+}
+
+// CatalogEntry rates a provider model for routing strategies. Tiers are 1 (low) to 3 (high).
+type CatalogEntry struct {
+	Provider   string `json:"provider"`
+	Model      string `json:"model"`
+	Cost       int    `json:"cost"`
+	Latency    int    `json:"latency"`
+	Capability int    `json:"capability"`
+	Vision     bool   `json:"vision,omitempty"` // model accepts image inputs
 }
 
 // ProviderConfig holds a single provider's config.
@@ -214,8 +387,9 @@ type ProviderConfig struct {
 
 // TaskConfig holds task lifecycle config.
 type TaskConfig struct {
-	TimeoutSec int `json:"timeout_sec"`
-	RetryMax   int `json:"retry_max"`
+	TimeoutSec    int `json:"timeout_sec"`
+	RetryMax      int `json:"retry_max"`
+	MaxConcurrent int `json:"max_concurrent"` // worker pool size; same-session messages stay ordered
 }
 
 // DeploymentConfig holds deployment mode config.
@@ -257,12 +431,18 @@ func Load(path string) (*Config, error) {
 	if cfg.Task.RetryMax == 0 {
 		cfg.Task.RetryMax = 2
 	}
+	if cfg.Task.MaxConcurrent == 0 {
+		cfg.Task.MaxConcurrent = 4
+	}
 	if cfg.Providers.RoutingStrategy == "" {
 		cfg.Providers.RoutingStrategy = "cheap_first"
 	}
 	if cfg.Deployment.Mode == "" {
 		cfg.Deployment.Mode = "local_dev"
 	}
+	if cfg.Channels.Streaming.Modes == nil {
+		cfg.Channels.Streaming.Modes = map[string]string{"cli": "chunk"}
+	}
 
 	return &cfg, nil
 }
@@ -288,10 +468,9 @@ func DefaultConfig() *Config {
 			},
 			List: []AgentConfig{
 				{
-					ID:        "main",
-					Default:   true,
-					Name:      "Sypher",
-					Workspace: workspace,
+					ID:      "main",
+					Default: true,
+					Name:    "Sypher",
 				},
 			},
 		},
@@ -305,13 +484,17 @@ func DefaultConfig() *Config {
 				BridgeURL: "ws://localhost:3001",
 				AllowFrom: []string{},
 			},
+			Streaming: StreamingConfig{
+				Modes: map[string]string{"cli": "chunk"},
+			},
 		},
 		Providers: ProvidersConfig{
 			RoutingStrategy: "cheap_first",
 		},
 		Task: TaskConfig{
-			TimeoutSec: 300,
-			RetryMax:   2,
+			TimeoutSec:    300,
+			RetryMax:      2,
+			MaxConcurrent: 4,
 		},
 		Deployment: DeploymentConfig{
 			Mode: "local_dev",
@@ -328,6 +511,9 @@ func DefaultConfig() *Config {
 			CacheToolOutputs:   true,
 			CacheMaxEntries:    10,
 		},
+		Session: SessionConfig{
+			MaxMessages: 50,
+		},
 	}
 }
 
@@ -349,6 +535,44 @@ func (c *Config) Save(path string) error {
 	return os.WriteFile(path, data, 0600)
 }
 
+// SecretResolver returns the resolver for secrets.backend, created on first use.
+func (c *Config) SecretResolver() *secrets.Resolver {
+	c.resolverOnce.Do(func() {
+		c.resolver = secrets.New(secrets.Backend(c.Secrets.Backend), secrets.Options{
+			File:    ExpandPath(c.Secrets.File),
+			KeyFile: ExpandPath(c.Secrets.KeyFile),
+		})
+	})
+	return c.resolver
+}
+
+// ResolveSecret returns v, or the secret it names when v is a "secret://name"
+// reference ("" if that secret is not set). Config values such as
+// providers.*.api_key go through it, so Save keeps the reference, not the key.
+func (c *Config) ResolveSecret(v string) string {
+	name, ok := secrets.ParseRef(v)
+	if !ok {
+		return v
+	}
+	return c.SecretResolver().Get(name)
+}
+
+// SecretValues returns the credentials held in the config (provider and
+// transcription API keys, with references resolved), for redaction.
+func (c *Config) SecretValues() []string {
+	c.mu.RLock()
+	p := c.Providers
+	keys := []string{p.Cerebras.APIKey, p.OpenAI.APIKey, p.Anthropic.APIKey, p.Gemini.APIKey, p.Ollama.APIKey, p.LlamaCpp.APIKey, c.Media.Transcription.APIKey}
+	c.mu.RUnlock()
+	var out []string
+	for _, v := range keys {
+		if v = c.ResolveSecret(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 // ExpandPath expands ~ to home directory.
 func ExpandPath(p string) string {
 	if p == "" {
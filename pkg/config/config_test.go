@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -33,3 +34,62 @@ func TestExpandPath(t *testing.T) {
 		t.Errorf("ExpandPath(abs) = %q, want %q", got, absPath)
 	}
 }
+
+func TestAgentModelsAndWorkspace(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Agents.List = append(cfg.Agents.List, AgentConfig{
+		ID:        "coding",
+		Workspace: "/tmp/coding",
+		Model:     &AgentModelConfig{Primary: "openai/gpt-4o", Fallbacks: []string{"cerebras/llama-3.1-70b", "openai/gpt-4o"}},
+	})
+
+	got := cfg.AgentModels("coding")
+	if len(got) != 2 || got[0] != "openai/gpt-4o" || got[1] != "cerebras/llama-3.1-70b" {
+		t.Errorf("AgentModels(coding) = %v", got)
+	}
+	if got := cfg.AgentModels("main"); len(got) != 1 || got[0] != cfg.Agents.Defaults.Model {
+		t.Errorf("AgentModels(main) = %v, want defaults", got)
+	}
+	if got := cfg.AgentWorkspace("coding"); got != "/tmp/coding" {
+		t.Errorf("AgentWorkspace(coding) = %q", got)
+	}
+	if got := cfg.AgentWorkspace("main"); got != cfg.Agents.Defaults.Workspace {
+		t.Errorf("AgentWorkspace(main) = %q, want defaults", got)
+	}
+}
+
+func TestResolveSecret(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SYPHER_SECRETS_PASSPHRASE", "")
+	cfg := DefaultConfig()
+	cfg.Secrets = SecretsConfig{Backend: "file", File: filepath.Join(dir, "secrets.enc"), KeyFile: filepath.Join(dir, "secrets.key")}
+	if err := cfg.SecretResolver().Set("openai", "sk-from-store"); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Providers.OpenAI.APIKey = "secret://openai"
+	cfg.Providers.Gemini.APIKey = "plain-gemini-key"
+	cfg.Providers.Anthropic.APIKey = "secret://missing"
+
+	if got := cfg.ResolveSecret(cfg.Providers.OpenAI.APIKey); got != "sk-from-store" {
+		t.Errorf("reference: got %q", got)
+	}
+	if got := cfg.ResolveSecret(cfg.Providers.Gemini.APIKey); got != "plain-gemini-key" {
+		t.Errorf("plain value: got %q", got)
+	}
+	if got := cfg.ResolveSecret(cfg.Providers.Anthropic.APIKey); got != "" {
+		t.Errorf("missing secret: got %q", got)
+	}
+	values := cfg.SecretValues()
+	if len(values) != 2 || values[0] != "sk-from-store" || values[1] != "plain-gemini-key" {
+		t.Errorf("SecretValues = %v", values)
+	}
+
+	path := filepath.Join(dir, "config.json")
+	if err := cfg.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"secret://openai"`) || strings.Contains(string(data), "sk-from-store") {
+		t.Error("Save should keep the reference, not the resolved key")
+	}
+}
@@ -0,0 +1,119 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// systemPaths are mounted read-only so ordinary tools work in the sandbox.
+// Paths missing on the host are skipped; symlinks (e.g. /bin -> usr/bin on
+// merged-/usr systems) are recreated as symlinks.
+var systemPaths = []string{"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/etc", "/opt"}
+
+// Limits are cgroup v2 resource limits. Zero values mean no limit.
+type Limits struct {
+	CgroupParent string // default /sys/fs/cgroup/sypher
+	MemoryMB     int
+	CPUPercent   int // 100 = one full core
+	PidsMax      int
+}
+
+func (l Limits) enabled() bool {
+	return l.MemoryMB > 0 || l.CPUPercent > 0 || l.PidsMax > 0
+}
+
+// Bwrap runs commands under bubblewrap: new namespaces, a read-only view of
+// the system, a private /tmp, and the workspace as the only writable mount.
+type Bwrap struct {
+	Path          string   // bwrap binary; default "bwrap" from PATH
+	ReadOnlyPaths []string // extra host paths visible read-only
+	Network       bool     // keep the host network namespace
+	Limits        Limits
+}
+
+// Name returns the backend name.
+func (b *Bwrap) Name() string { return BackendBwrap }
+
+// Command returns bwrap running spec.Command (or spec.Args) in the sandbox. It fails rather
+// than falling back to the host when bwrap or the cgroup limits are unavailable.
+func (b *Bwrap) Command(ctx context.Context, spec Spec) (*exec.Cmd, func(), error) {
+	if runtime.GOOS != "linux" {
+		return nil, nil, fmt.Errorf("bwrap sandbox requires Linux")
+	}
+	path := b.Path
+	if path == "" {
+		path = "bwrap"
+	}
+	bin, err := exec.LookPath(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("bubblewrap not available (%v); install bwrap or set the executor to host", err)
+	}
+	args, err := b.args(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Env = spec.Env
+	cleanup := func() {}
+	if b.Limits.enabled() {
+		if cleanup, err = applyLimits(cmd, b.Limits); err != nil {
+			return nil, nil, fmt.Errorf("cgroup limits: %w", err)
+		}
+	}
+	return cmd, cleanup, nil
+}
+
+// args builds the bwrap command line for spec.
+func (b *Bwrap) args(spec Spec) ([]string, error) {
+	ws, err := filepath.Abs(spec.Workspace)
+	if err != nil || spec.Workspace == "" {
+		return nil, fmt.Errorf("sandbox needs a workspace")
+	}
+	if err := os.MkdirAll(ws, 0755); err != nil {
+		return nil, err
+	}
+	dir := spec.Dir
+	if dir == "" {
+		dir = ws
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return nil, err
+	}
+
+	args := []string{"--die-with-parent", "--new-session", "--unshare-all"}
+	if b.Network {
+		args = append(args, "--share-net")
+	}
+	for _, p := range append(append([]string{}, systemPaths...), b.ReadOnlyPaths...) {
+		info, err := os.Lstat(p)
+		if err != nil {
+			continue
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(p)
+			if err != nil {
+				continue
+			}
+			args = append(args, "--symlink", target, p)
+			continue
+		}
+		args = append(args, "--ro-bind", p, p)
+	}
+	// The workspace is bound last so it stays writable even below /tmp or a read-only path
+	args = append(args,
+		"--proc", "/proc",
+		"--dev", "/dev",
+		"--tmpfs", "/tmp",
+		"--bind", ws, ws,
+		"--setenv", "HOME", ws,
+		"--chdir", dir,
+		"--")
+	if len(spec.Args) > 0 {
+		return append(args, spec.Args...), nil
+	}
+	return append(args, "sh", "-c", spec.Command), nil
+}
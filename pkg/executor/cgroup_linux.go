@@ -0,0 +1,85 @@
+//go:build linux
+
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+const defaultCgroupParent = "/sys/fs/cgroup/sypher"
+
+// applyLimits creates a leaf cgroup with the limits and makes cmd start in it,
+// so every process the command spawns is accounted there from the first
+// instruction. cleanup kills leftovers and removes the cgroup.
+func applyLimits(cmd *exec.Cmd, l Limits) (func(), error) {
+	parent := l.CgroupParent
+	if parent == "" {
+		parent = defaultCgroupParent
+	}
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return nil, err
+	}
+	// Best effort: a delegated parent may already have the controllers enabled
+	_ = os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("+cpu +memory +pids"), 0644)
+
+	leaf, err := os.MkdirTemp(parent, "exec-")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeLimits(leaf, l); err != nil {
+		_ = os.Remove(leaf)
+		return nil, err
+	}
+	f, err := os.Open(leaf)
+	if err != nil {
+		_ = os.Remove(leaf)
+		return nil, err
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(f.Fd())
+	return func() {
+		f.Close()
+		removeCgroup(leaf)
+	}, nil
+}
+
+// writeLimits writes the cgroup v2 limit files of dir.
+func writeLimits(dir string, l Limits) error {
+	files := map[string]string{}
+	if l.MemoryMB > 0 {
+		files["memory.max"] = fmt.Sprint(int64(l.MemoryMB) << 20)
+	}
+	if l.CPUPercent > 0 {
+		files["cpu.max"] = fmt.Sprintf("%d 100000", l.CPUPercent*1000)
+	}
+	if l.PidsMax > 0 {
+		files["pids.max"] = fmt.Sprint(l.PidsMax)
+	}
+	for name, value := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(value), 0644); err != nil {
+			return fmt.Errorf("set %s (is %s a cgroup v2 directory with the controller enabled?): %w", name, filepath.Dir(dir), err)
+		}
+	}
+	return nil
+}
+
+// removeCgroup kills whatever is still in the cgroup and removes it. A cgroup
+// can only be removed once empty, so removal is retried briefly.
+func removeCgroup(dir string) {
+	_ = os.WriteFile(filepath.Join(dir, "cgroup.kill"), []byte("1"), 0644)
+	for i := 0; i < 20; i++ {
+		if err := os.Remove(dir); !errors.Is(err, syscall.EBUSY) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteLimits(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeLimits(dir, Limits{MemoryMB: 512, CPUPercent: 150, PidsMax: 64}); err != nil {
+		t.Fatal(err)
+	}
+	for file, want := range map[string]string{
+		"memory.max": "536870912",
+		"cpu.max":    "150000 100000",
+		"pids.max":   "64",
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", file, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package executor
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+func applyLimits(cmd *exec.Cmd, l Limits) (func(), error) {
+	return nil, fmt.Errorf("cgroup limits require Linux")
+}
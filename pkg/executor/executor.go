@@ -0,0 +1,106 @@
+// Package executor builds the processes that exec and stream_command run,
+// either directly on the host or inside a sandbox.
+package executor
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/policy"
+)
+
+// Backend names.
+const (
+	BackendHost  = "host"
+	BackendBwrap = "bwrap"
+)
+
+// defaultModes maps deployment modes to a backend when the config names none.
+// A multi-user deployment must not run agent commands with the server's
+// full privileges, so it is sandboxed unless configured otherwise.
+var defaultModes = map[string]string{
+	"multi_user": BackendBwrap,
+}
+
+// Spec describes one command to run.
+type Spec struct {
+	Command   string   // shell command line
+	Args      []string // program and arguments run without a shell; used instead of Command when set
+	Dir       string   // working directory
+	Workspace string   // agent workspace; the only writable path in a sandbox
+	Env       []string // nil = inherit the server environment
+}
+
+// Executor turns a Spec into a process. The caller starts and waits for the
+// returned command and must call cleanup once it has exited.
+type Executor interface {
+	Name() string
+	Command(ctx context.Context, spec Spec) (cmd *exec.Cmd, cleanup func(), err error)
+}
+
+// Backend returns the backend name for the agent: agents.list[].executor,
+// then tools.exec.sandbox.modes[deployment.mode], then
+// tools.exec.sandbox.backend, then the deployment mode's default.
+func Backend(cfg *config.Config, agentID string) string {
+	if a := cfg.AgentByID(agentID); a != nil && a.Executor != "" {
+		return a.Executor
+	}
+	sb := cfg.Tools.Exec.Sandbox
+	if b := sb.Modes[cfg.Deployment.Mode]; b != "" {
+		return b
+	}
+	if sb.Backend != "" {
+		return sb.Backend
+	}
+	if b := defaultModes[cfg.Deployment.Mode]; b != "" {
+		return b
+	}
+	return BackendHost
+}
+
+// ForAgent returns the executor configured for the agent.
+func ForAgent(cfg *config.Config, agentID string) (Executor, error) {
+	switch b := Backend(cfg, agentID); b {
+	case BackendHost:
+		return Host{}, nil
+	case BackendBwrap:
+		sb := cfg.Tools.Exec.Sandbox
+		return &Bwrap{
+			Path:          sb.BwrapPath,
+			ReadOnlyPaths: sb.ReadOnlyPaths,
+			Network:       policy.NewEvaluator(cfg).CanUseNetwork(agentID),
+			Limits: Limits{
+				CgroupParent: sb.CgroupParent,
+				MemoryMB:     sb.MemoryMB,
+				CPUPercent:   sb.CPUPercent,
+				PidsMax:      sb.PidsMax,
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown executor backend %q", b)
+	}
+}
+
+// Host runs commands directly with the server's privileges.
+type Host struct{}
+
+// Name returns the backend name.
+func (Host) Name() string { return BackendHost }
+
+// Command returns a shell running spec.Command, or spec.Args directly.
+func (Host) Command(ctx context.Context, spec Spec) (*exec.Cmd, func(), error) {
+	var cmd *exec.Cmd
+	if len(spec.Args) > 0 {
+		cmd = exec.CommandContext(ctx, spec.Args[0], spec.Args[1:]...)
+	} else if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/c", spec.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", spec.Command)
+	}
+	cmd.Dir = spec.Dir
+	cmd.Env = spec.Env
+	return cmd, func() {}, nil
+}
@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+)
+
+func TestBackend_Selection(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if b := Backend(cfg, "main"); b != BackendHost {
+		t.Errorf("local_dev default: got %q", b)
+	}
+	cfg.Deployment.Mode = "multi_user"
+	if b := Backend(cfg, "main"); b != BackendBwrap {
+		t.Errorf("multi_user default: got %q", b)
+	}
+	cfg.Tools.Exec.Sandbox.Backend = BackendHost
+	cfg.Tools.Exec.Sandbox.Modes = map[string]string{"headless_server": BackendBwrap}
+	if b := Backend(cfg, "main"); b != BackendHost {
+		t.Errorf("sandbox.backend should override the mode default: got %q", b)
+	}
+	cfg.Deployment.Mode = "headless_server"
+	if b := Backend(cfg, "main"); b != BackendBwrap {
+		t.Errorf("sandbox.modes should win over sandbox.backend: got %q", b)
+	}
+	cfg.Agents.List = []config.AgentConfig{{ID: "main", Executor: BackendHost}}
+	if b := Backend(cfg, "main"); b != BackendHost {
+		t.Errorf("agent executor should win: got %q", b)
+	}
+
+	cfg.Agents.List[0].Executor = "docker"
+	if _, err := ForAgent(cfg, "main"); err == nil {
+		t.Error("unknown backend should be an error")
+	}
+}
+
+func TestBwrap_Args(t *testing.T) {
+	ws := t.TempDir()
+	b := &Bwrap{}
+	args, err := b.args(Spec{Command: "make test", Workspace: ws})
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := strings.Join(args, " ")
+	for _, want := range []string{"--unshare-all", "--tmpfs /tmp --bind " + ws + " " + ws, "--chdir " + ws, "-- sh -c make test"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("args missing %q: %s", want, line)
+		}
+	}
+	if strings.Contains(line, "--share-net") {
+		t.Error("network should be off unless allowed")
+	}
+	if strings.Contains(line, "--bind / ") {
+		t.Error("only the workspace may be writable")
+	}
+
+	b.Network = true
+	args, _ = b.args(Spec{Command: "true", Workspace: ws})
+	if !strings.Contains(strings.Join(args, " "), "--share-net") {
+		t.Error("allowed network should share the host network")
+	}
+
+	args, _ = b.args(Spec{Args: []string{"git", "status"}, Workspace: ws})
+	if line := strings.Join(args, " "); !strings.HasSuffix(line, "-- git status") {
+		t.Errorf("argv should run without a shell: %s", line)
+	}
+
+	if _, err := b.args(Spec{Command: "true"}); err == nil {
+		t.Error("sandbox without workspace should fail")
+	}
+}
+
+func TestBwrap_UnavailableFailsClosed(t *testing.T) {
+	b := &Bwrap{Path: "/nonexistent/bwrap"}
+	if _, _, err := b.Command(context.Background(), Spec{Command: "true", Workspace: t.TempDir()}); err == nil {
+		t.Error("missing bwrap must not fall back to the host")
+	}
+}
+
+func TestHost_Command(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	dir := t.TempDir()
+	cmd, cleanup, err := Host{}.Command(context.Background(), Spec{Command: "pwd", Dir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	out, err := cmd.Output()
+	if err != nil || strings.TrimSpace(string(out)) != dir {
+		t.Errorf("pwd = %q, %v", out, err)
+	}
+}
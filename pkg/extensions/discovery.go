@@ -15,6 +15,17 @@ type Manifest struct {
 	SypherMiniVersion  string   `json:"sypher_mini_version"`
 	Capabilities       []string `json:"capabilities"`
 	Entry              string   `json:"entry"`
+	Tools              []ToolSpec `json:"tools,omitempty"`
+}
+
+// ToolSpec declares a tool served by the extension over HTTP.
+// The endpoint receives a tools.Request as JSON and returns a tools.Response.
+type ToolSpec struct {
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	Parameters   map[string]interface{} `json:"parameters,omitempty"`
+	Capabilities []string               `json:"capabilities,omitempty"`
+	Endpoint     string                 `json:"endpoint"`
 }
 
 // DiscoveredExtension holds a discovered extension with its manifest.
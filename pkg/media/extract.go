@@ -0,0 +1,326 @@
+package media
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	pdftotextTimeout = 30 * time.Second
+	maxStreamBytes   = 16 << 20
+	maxDecodedBytes  = 64 << 20 // all streams of one PDF together
+)
+
+// readText reads up to limit bytes of a text file, replacing invalid UTF-8.
+func readText(file string, limit int) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, int64(limit)))
+	if err != nil {
+		return "", err
+	}
+	return strings.ToValidUTF8(string(data), "�"), nil
+}
+
+// pdfText extracts text from a PDF. pdftotext (poppler) is used when it is
+// installed; otherwise a built-in extractor reads the text operators of the
+// page content streams, which covers simple documents but not CID fonts.
+// The built-in extractor stops once maxChars of text are collected.
+func pdfText(file string, maxChars int) (string, error) {
+	if bin, err := exec.LookPath("pdftotext"); err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), pdftotextTimeout)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, bin, "-layout", "-enc", "UTF-8", file, "-").Output()
+		if err == nil && len(bytes.TrimSpace(out)) > 0 {
+			return string(out), nil
+		}
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	if !bytes.HasPrefix(data, []byte("%PDF")) {
+		return "", fmt.Errorf("not a PDF file")
+	}
+	text := extractPDF(data, maxChars)
+	if text == "" {
+		return "", fmt.Errorf("no extractable text (scanned or unsupported PDF; install pdftotext)")
+	}
+	return text, nil
+}
+
+// extractPDF returns the text shown by the content streams in data, reading
+// streams only until maxChars of text are collected (0 = no limit).
+func extractPDF(data []byte, maxChars int) string {
+	var b strings.Builder
+	chars := 0
+	pdfStreams(data, maxDecodedBytes, func(content []byte) bool {
+		if t := contentText(content); t != "" {
+			b.WriteString(t)
+			b.WriteString("\n")
+			chars += utf8.RuneCountInString(t) + 1
+		}
+		return maxChars <= 0 || chars < maxChars
+	})
+	return collapseBlankLines(b.String())
+}
+
+// skipStreamTypes mark stream dictionaries that never hold page text.
+var skipStreamTypes = [][]byte{
+	[]byte("/Image"), []byte("/FontFile"), []byte("/Length1"), []byte("/XRef"),
+	[]byte("/ObjStm"), []byte("/Metadata"), []byte("/EmbeddedFile"),
+}
+
+// pdfStreams passes the decoded content of the uncompressed and
+// Flate-compressed streams in data to fn, one at a time, until fn returns
+// false or budget bytes have been decoded in total. Streams with other
+// filters are skipped.
+func pdfStreams(data []byte, budget int, fn func(content []byte) bool) {
+	kwStream, kwEnd := []byte("stream"), []byte("endstream")
+	for i := 0; i < len(data) && budget > 0; {
+		j := bytes.Index(data[i:], kwStream)
+		if j < 0 {
+			break
+		}
+		at := i + j
+		start := at + len(kwStream)
+		if at >= 3 && string(data[at-3:at]) == "end" {
+			i = start
+			continue
+		}
+		if start < len(data) && data[start] == '\r' {
+			start++
+		}
+		if start < len(data) && data[start] == '\n' {
+			start++
+		}
+		end := bytes.Index(data[start:], kwEnd)
+		if end < 0 {
+			break
+		}
+		i = start + end + len(kwEnd)
+
+		dict := data[max(0, at-1024):at]
+		if k := bytes.LastIndex(dict, []byte(" obj")); k >= 0 {
+			dict = dict[k:]
+		}
+		if containsAny(dict, skipStreamTypes) {
+			continue
+		}
+		raw := data[start : start+end]
+		switch {
+		case bytes.Contains(dict, []byte("/FlateDecode")):
+			r, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				continue
+			}
+			// Keep what was decoded even if the stream is truncated
+			raw, _ = io.ReadAll(io.LimitReader(r, int64(min(maxStreamBytes, budget))))
+			r.Close()
+		case bytes.Contains(dict, []byte("/Filter")):
+			continue
+		}
+		budget -= len(raw)
+		if !fn(raw) {
+			return
+		}
+	}
+}
+
+func containsAny(b []byte, subs [][]byte) bool {
+	for _, s := range subs {
+		if bytes.Contains(b, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// contentText interprets the text operators of a content stream: strings
+// shown by Tj, TJ, ' and " are collected, line moves become newlines, and
+// large TJ kerning gaps become spaces.
+func contentText(c []byte) string {
+	var b strings.Builder
+	newline := func() {
+		if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	inArray := false
+	for i := 0; i < len(c); i++ {
+		ch := c[i]
+		switch {
+		case ch == '%':
+			for i < len(c) && c[i] != '\n' && c[i] != '\r' {
+				i++
+			}
+		case ch == '(':
+			s, n := readLiteral(c[i:])
+			b.WriteString(s)
+			i += n - 1
+		case ch == '<' && i+1 < len(c) && c[i+1] != '<':
+			end := bytes.IndexByte(c[i:], '>')
+			if end < 0 {
+				return b.String()
+			}
+			b.WriteString(decodeHexString(c[i+1 : i+end]))
+			i += end
+		case ch == '<' || ch == '>':
+			i++ // dictionary delimiter
+		case ch == '[':
+			inArray = true
+		case ch == ']':
+			inArray = false
+		case ch == '-' || ch == '.' || (ch >= '0' && ch <= '9'):
+			j := i + 1
+			for j < len(c) && (c[j] == '.' || (c[j] >= '0' && c[j] <= '9')) {
+				j++
+			}
+			if inArray {
+				if v, err := strconv.ParseFloat(string(c[i:j]), 64); err == nil && v < -180 {
+					b.WriteByte(' ')
+				}
+			}
+			i = j - 1
+		case ch == '\'' || ch == '"':
+			newline()
+		case isRegular(ch):
+			j := i
+			for j < len(c) && isRegular(c[j]) {
+				j++
+			}
+			switch string(c[i:j]) {
+			case "Td", "TD", "T*", "ET":
+				newline()
+			}
+			i = j - 1
+		}
+	}
+	return b.String()
+}
+
+func isRegular(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch == '*'
+}
+
+// readLiteral decodes a PDF literal string starting at s[0] == '('. It
+// returns the text and the number of bytes consumed.
+func readLiteral(s []byte) (string, int) {
+	var out []byte
+	depth := 0
+	i := 0
+	for ; i < len(s); i++ {
+		ch := s[i]
+		switch ch {
+		case '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return decodePDFString(out), i + 1
+			}
+		case '\\':
+			i++
+			if i >= len(s) {
+				break
+			}
+			switch e := s[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b', 'f':
+			case '\r':
+				if i+1 < len(s) && s[i+1] == '\n' {
+					i++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					v := 0
+					n := 0
+					for n < 3 && i < len(s) && s[i] >= '0' && s[i] <= '7' {
+						v = v*8 + int(s[i]-'0')
+						i++
+						n++
+					}
+					i--
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+			continue
+		}
+		out = append(out, ch)
+	}
+	return decodePDFString(out), i
+}
+
+// decodeHexString decodes a hex string, keeping it only if it is readable
+// text; glyph IDs of CID fonts are dropped.
+func decodeHexString(h []byte) string {
+	h = bytes.Join(bytes.Fields(h), nil)
+	if len(h)%2 == 1 {
+		h = append(h, '0')
+	}
+	out := make([]byte, 0, len(h)/2)
+	for i := 0; i+1 < len(h); i += 2 {
+		v, err := strconv.ParseUint(string(h[i:i+2]), 16, 8)
+		if err != nil {
+			return ""
+		}
+		out = append(out, byte(v))
+	}
+	s := decodePDFString(out)
+	for _, r := range s {
+		if r < 0x20 && r != '\n' && r != '\t' {
+			return ""
+		}
+	}
+	return s
+}
+
+// decodePDFString decodes UTF-16BE (with BOM) or PDFDocEncoding, treated as Latin-1.
+func decodePDFString(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		u := make([]uint16, 0, len(b)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return string(utf16.Decode(u))
+	}
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return string(r)
+}
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+func collapseBlankLines(s string) string {
+	return strings.TrimSpace(blankLines.ReplaceAllString(s, "\n\n"))
+}
@@ -0,0 +1,294 @@
+// Package media downloads inbound attachments (images, documents, audio) into
+// the agent workspace and extracts text from documents for the LLM.
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/utils"
+)
+
+// Attachment kinds.
+const (
+	KindImage    = "image"
+	KindDocument = "document"
+	KindAudio    = "audio"
+	KindOther    = "other"
+)
+
+const (
+	defaultMaxMB        = 20
+	defaultMaxTextChars = 20000
+	downloadTimeout     = 60 * time.Second
+	maxRedirects        = 5
+)
+
+// visionTypes are the image types providers accept as image input.
+var visionTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Attachment is an inbound media file stored in the workspace.
+type Attachment struct {
+	Source     string `json:"source"` // URL the file was downloaded from
+	Path       string `json:"path"`
+	Name       string `json:"name"`
+	MimeType   string `json:"mime_type"`
+	Kind       string `json:"kind"`
+	Text       string `json:"-"` // extracted document text
+	Transcript string `json:"-"` // audio transcript
+}
+
+// Store downloads attachments and extracts their text.
+type Store struct {
+	maxBytes     int64
+	maxTextChars int
+	trusted      []string // host:port of media servers allowed on private addresses
+	client       *http.Client
+}
+
+// NewStore creates a store configured by the media section. Downloads may
+// only reach public addresses, plus the WhatsApp extension endpoint and
+// media.trusted_urls, so an inbound message cannot make the gateway fetch
+// loopback, LAN or cloud metadata URLs.
+func NewStore(cfg *config.Config) *Store {
+	s := &Store{maxBytes: defaultMaxMB << 20, maxTextChars: defaultMaxTextChars}
+	if cfg != nil {
+		if cfg.Media.MaxMB > 0 {
+			s.maxBytes = int64(cfg.Media.MaxMB) << 20
+		}
+		if cfg.Media.MaxTextChars > 0 {
+			s.maxTextChars = cfg.Media.MaxTextChars
+		}
+		for _, ref := range append([]string{cfg.Channels.WhatsApp.BaileysURL}, cfg.Media.TrustedURLs...) {
+			if hostPort := endpoint(ref); hostPort != "" {
+				s.trusted = append(s.trusted, hostPort)
+			}
+		}
+	}
+	dialer := &net.Dialer{Timeout: 30 * time.Second, Control: s.checkAddr}
+	s.client = &http.Client{
+		Timeout: downloadTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+	return s
+}
+
+// endpoint returns the host:port a URL connects to, or "" if it is not an
+// http(s) URL.
+func endpoint(ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	port := u.Port()
+	switch {
+	case port != "":
+	case u.Scheme == "http":
+		port = "80"
+	case u.Scheme == "https":
+		port = "443"
+	default:
+		return ""
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// checkAddr runs before every connection, including those made for
+// redirects, with the resolved address, so DNS tricks cannot reach a
+// private address either.
+func (s *Store) checkAddr(network, address string, _ syscall.RawConn) error {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("unexpected media address %s", address)
+	}
+	if publicIP(ip) || s.trustedAddr(ip, port) {
+		return nil
+	}
+	return fmt.Errorf("media address %s is not public", address)
+}
+
+// trustedAddr reports whether ip:port belongs to a trusted media server.
+func (s *Store) trustedAddr(ip net.IP, port string) bool {
+	for _, hostPort := range s.trusted {
+		host, p, _ := net.SplitHostPort(hostPort)
+		if p != port {
+			continue
+		}
+		if literal := net.ParseIP(host); literal != nil {
+			if literal.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		addrs, err := net.DefaultResolver.LookupIPAddr(context.Background(), host)
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if a.IP.Equal(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// publicIP reports whether ip is a globally routable unicast address.
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsMulticast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		// 0.0.0.0/8 and 100.64.0.0/10 (carrier-grade NAT)
+		return ip4[0] != 0 && !(ip4[0] == 100 && ip4[1]&0xc0 == 64)
+	}
+	return true
+}
+
+// Dir returns the media directory of a workspace.
+func Dir(workspace string) string {
+	return filepath.Join(workspace, "media")
+}
+
+// Fetch downloads ref into dir and classifies it. Only http(s) URLs are
+// accepted, so a channel cannot point the agent at arbitrary local files,
+// and only public or trusted addresses are dialed (see NewStore).
+func (s *Store) Fetch(ref, dir string) (*Attachment, error) {
+	u, err := url.Parse(ref)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("unsupported media reference %q", ref)
+	}
+	name := utils.SanitizeFilename(path.Base(u.Path))
+	if name == "" || name == "." || name == "/" {
+		name = "attachment"
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("create media dir: %w", err)
+	}
+	local := utils.DownloadFile(ref, name, utils.DownloadOptions{
+		Timeout:  downloadTimeout,
+		Dir:      dir,
+		MaxBytes: s.maxBytes,
+		Client:   s.client,
+	})
+	if local == "" {
+		return nil, fmt.Errorf("download %s failed (unreachable, not a public address, not found or over %d MB)", name, s.maxBytes>>20)
+	}
+	mimeType := detectType(local)
+	return &Attachment{
+		Source:   ref,
+		Path:     local,
+		Name:     name,
+		MimeType: mimeType,
+		Kind:     kindOf(name, mimeType),
+	}, nil
+}
+
+// Extract fills a document's Text. Other kinds are left unchanged.
+func (s *Store) Extract(a *Attachment) error {
+	if a.Kind != KindDocument {
+		return nil
+	}
+	var text string
+	var err error
+	if a.MimeType == "application/pdf" {
+		text, err = pdfText(a.Path, s.maxTextChars)
+	} else {
+		text, err = readText(a.Path, s.maxTextChars*4)
+	}
+	if err != nil {
+		return fmt.Errorf("extract %s: %w", a.Name, err)
+	}
+	a.Text = truncate(strings.TrimSpace(text), s.maxTextChars)
+	return nil
+}
+
+// detectType sniffs the file content. The extension decides for types
+// content sniffing cannot tell apart (e.g. source code vs plain text).
+func detectType(file string) string {
+	sniffed := "application/octet-stream"
+	if f, err := os.Open(file); err == nil {
+		buf := make([]byte, 512)
+		n, _ := f.Read(buf)
+		f.Close()
+		sniffed = http.DetectContentType(buf[:n])
+	}
+	base, _, _ := mime.ParseMediaType(sniffed)
+	if strings.HasPrefix(base, "image/") || strings.HasPrefix(base, "audio/") || base == "application/pdf" || base == "application/ogg" {
+		return base
+	}
+	if byExt := mime.TypeByExtension(strings.ToLower(filepath.Ext(file))); byExt != "" {
+		if t, _, err := mime.ParseMediaType(byExt); err == nil {
+			return t
+		}
+	}
+	return base
+}
+
+// kindOf classifies a file by name and MIME type.
+func kindOf(name, mimeType string) string {
+	switch {
+	case visionTypes[mimeType]:
+		return KindImage
+	case utils.IsAudioFile(name, mimeType):
+		return KindAudio
+	case mimeType == "application/pdf", isTextType(mimeType):
+		return KindDocument
+	}
+	return KindOther
+}
+
+func isTextType(mimeType string) bool {
+	if strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+	switch mimeType {
+	case "application/json", "application/xml", "application/x-yaml", "application/yaml",
+		"application/javascript", "application/x-sh", "application/toml":
+		return true
+	}
+	return false
+}
+
+// truncate cuts s to max runes, marking the cut.
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if max <= 0 || len(r) <= max {
+		return s
+	}
+	return string(r[:max]) + "\n[truncated]"
+}
@@ -0,0 +1,208 @@
+package media
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+)
+
+// testPDF builds a one-page PDF whose content stream is Flate-compressed.
+func testPDF(t *testing.T, content string) []byte {
+	t.Helper()
+	var z bytes.Buffer
+	w := zlib.NewWriter(&z)
+	w.Write([]byte(content))
+	w.Close()
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n")
+	b.WriteString("2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 >> endobj\n")
+	b.WriteString("3 0 obj << /Type /Page /Parent 2 0 R /Contents 4 0 R >> endobj\n")
+	fmt.Fprintf(&b, "4 0 obj << /Length %d /Filter /FlateDecode >>\nstream\n", z.Len())
+	b.Write(z.Bytes())
+	b.WriteString("\nendstream\nendobj\ntrailer << /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func TestExtractPDF(t *testing.T) {
+	pdf := testPDF(t, "BT /F1 12 Tf 72 712 Td (Quarterly report) Tj 0 -14 Td [(Revenue) -250 (grew \\(a lot\\))] TJ ET")
+	got := extractPDF(pdf, 0)
+	want := "Quarterly report\nRevenue grew (a lot)"
+	if got != want {
+		t.Errorf("extractPDF = %q, want %q", got, want)
+	}
+}
+
+// multiStreamPDF builds a PDF with one Flate-compressed content stream per entry.
+func multiStreamPDF(contents []string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	for i, content := range contents {
+		var z bytes.Buffer
+		w := zlib.NewWriter(&z)
+		w.Write([]byte(content))
+		w.Close()
+		fmt.Fprintf(&b, "%d 0 obj << /Length %d /Filter /FlateDecode >>\nstream\n", i+1, z.Len())
+		b.Write(z.Bytes())
+		b.WriteString("\nendstream\nendobj\n")
+	}
+	b.WriteString("%%EOF\n")
+	return b.Bytes()
+}
+
+func TestExtractPDF_Limits(t *testing.T) {
+	var pages []string
+	for i := 1; i <= 50; i++ {
+		pages = append(pages, fmt.Sprintf("BT (Page %d) Tj ET", i))
+	}
+	pdf := multiStreamPDF(pages)
+	if got := extractPDF(pdf, 20); !strings.Contains(got, "Page 2") || strings.Contains(got, "Page 4") {
+		t.Errorf("extraction should stop near maxChars, got %q", got)
+	}
+
+	bomb := multiStreamPDF([]string{strings.Repeat(" ", 1<<20), strings.Repeat(" ", 1<<20), "BT (late) Tj ET"})
+	n := 0
+	pdfStreams(bomb, 1<<20, func([]byte) bool { n++; return true })
+	if n != 1 {
+		t.Errorf("decoding should stop at the byte budget, read %d streams", n)
+	}
+}
+
+func TestStore_FetchAndExtract(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	files := map[string][]byte{
+		"/media/1/photo.jpg": png, // content wins over a wrong extension
+		"/media/2/notes.txt": []byte("# Notes\nship it"),
+		"/media/3/doc.pdf":   testPDF(t, "BT (Hello PDF) Tj ET"),
+		"/media/4/voice.ogg": []byte("OggS\x00\x02rest"),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Channels.WhatsApp.BaileysURL = srv.URL
+	s := NewStore(cfg)
+	dir := Dir(t.TempDir())
+	cases := []struct {
+		path, kind, mime, text string
+	}{
+		{"/media/1/photo.jpg", KindImage, "image/png", ""},
+		{"/media/2/notes.txt", KindDocument, "text/plain", "# Notes\nship it"},
+		{"/media/3/doc.pdf", KindDocument, "application/pdf", "Hello PDF"},
+		{"/media/4/voice.ogg", KindAudio, "application/ogg", ""},
+	}
+	for _, c := range cases {
+		a, err := s.Fetch(srv.URL+c.path, dir)
+		if err != nil {
+			t.Fatalf("Fetch %s: %v", c.path, err)
+		}
+		if !strings.HasPrefix(a.Path, dir) {
+			t.Errorf("%s stored at %s, want under %s", c.path, a.Path, dir)
+		}
+		if a.Kind != c.kind || a.MimeType != c.mime {
+			t.Errorf("%s: kind=%s mime=%s, want %s %s", c.path, a.Kind, a.MimeType, c.kind, c.mime)
+		}
+		if err := s.Extract(a); err != nil {
+			t.Errorf("Extract %s: %v", c.path, err)
+		}
+		if a.Text != c.text {
+			t.Errorf("%s text = %q, want %q", c.path, a.Text, c.text)
+		}
+	}
+
+	if _, err := s.Fetch(srv.URL+"/media/missing.png", dir); err == nil {
+		t.Error("expected error for missing file")
+	}
+	if _, err := s.Fetch("/etc/passwd", dir); err == nil {
+		t.Error("expected local paths to be rejected")
+	}
+}
+
+func TestStore_Limits(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("a", 300)))
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Media.MaxTextChars = 100
+	cfg.Media.TrustedURLs = []string{srv.URL}
+	s := NewStore(cfg)
+	a, err := s.Fetch(srv.URL+"/big.txt", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Extract(a); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(a.Text, "[truncated]") || len(a.Text) > 120 {
+		t.Errorf("text not truncated: %d chars", len(a.Text))
+	}
+
+	s.maxBytes = 10
+	dir := t.TempDir()
+	if _, err := s.Fetch(srv.URL+"/big.txt", dir); err == nil {
+		t.Error("expected error for file over the size limit")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("partial download left behind: %v", entries)
+	}
+}
+
+func TestStore_PrivateAddresses(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("secret"))
+	}))
+	defer internal.Close()
+	ext := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, internal.URL+"/secret.txt", http.StatusFound)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ext.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Channels.WhatsApp.BaileysURL = ext.URL
+	s := NewStore(cfg)
+	dir := t.TempDir()
+	if _, err := s.Fetch(ext.URL+"/media/ok.txt", dir); err != nil {
+		t.Errorf("extension media refused: %v", err)
+	}
+	for _, ref := range []string{
+		internal.URL + "/secret.txt",
+		ext.URL + "/redirect",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.1/x.png",
+	} {
+		if _, err := s.Fetch(ref, dir); err == nil {
+			t.Errorf("Fetch %s should be refused", ref)
+		}
+	}
+
+	for ip, want := range map[string]bool{
+		"8.8.8.8": true, "2606:4700::1111": true, "127.0.0.1": false, "10.1.2.3": false,
+		"192.168.1.1": false, "169.254.169.254": false, "100.64.0.1": false, "0.0.0.0": false,
+		"::1": false, "fe80::1": false, "fd00::1": false, "::ffff:127.0.0.1": false,
+	} {
+		if got := publicIP(net.ParseIP(ip)); got != want {
+			t.Errorf("publicIP(%s) = %v, want %v", ip, got, want)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+)
+
+// Transcription backends.
+const (
+	BackendOpenAI     = "openai"
+	BackendWhisperCpp = "whisper_cpp"
+)
+
+const defaultTranscribeTimeout = 120 * time.Second
+
+// Transcriber turns an audio file into text.
+type Transcriber interface {
+	Transcribe(ctx context.Context, file string) (string, error)
+	// Remote reports whether audio leaves the machine (disabled in safe mode).
+	Remote() bool
+}
+
+// NewTranscriber returns the transcriber selected by media.transcription, or
+// nil when transcription is off.
+func NewTranscriber(cfg *config.Config) (Transcriber, error) {
+	tc := cfg.Media.Transcription
+	timeout := defaultTranscribeTimeout
+	if tc.TimeoutSec > 0 {
+		timeout = time.Duration(tc.TimeoutSec) * time.Second
+	}
+	switch strings.ToLower(tc.Backend) {
+	case "":
+		return nil, nil
+	case BackendOpenAI:
+		apiBase, apiKey := tc.APIBase, tc.APIKey
+		if apiBase == "" {
+			apiBase = cfg.Providers.OpenAI.APIBase
+		}
+		if apiBase == "" {
+			apiBase = "https://api.openai.com/v1"
+		}
+		if apiKey == "" {
+			apiKey = cfg.Providers.OpenAI.APIKey
+		}
+		apiKey = cfg.ResolveSecret(apiKey)
+		model := tc.Model
+		if model == "" {
+			model = "whisper-1"
+		}
+		return &openAITranscriber{
+			apiBase:    strings.TrimRight(apiBase, "/"),
+			apiKey:     apiKey,
+			model:      model,
+			language:   tc.Language,
+			httpClient: &http.Client{Timeout: timeout},
+		}, nil
+	case BackendWhisperCpp:
+		if tc.Model == "" {
+			return nil, fmt.Errorf("media.transcription.model must point to a whisper.cpp model file")
+		}
+		binary := tc.Binary
+		if binary == "" {
+			binary = "whisper-cli"
+		}
+		return &whisperCppTranscriber{
+			binary:   binary,
+			model:    config.ExpandPath(tc.Model),
+			language: tc.Language,
+			timeout:  timeout,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown transcription backend %q (use %s or %s)", tc.Backend, BackendOpenAI, BackendWhisperCpp)
+}
+
+// openAITranscriber calls an OpenAI-compatible /audio/transcriptions endpoint
+// (OpenAI, Groq, a local faster-whisper server, ...).
+type openAITranscriber struct {
+	apiBase    string
+	apiKey     string
+	model      string
+	language   string
+	httpClient *http.Client
+}
+
+func (t *openAITranscriber) Remote() bool { return true }
+
+func (t *openAITranscriber) Transcribe(ctx context.Context, file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", filepath.Base(file))
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return "", fmt.Errorf("read audio: %w", err)
+	}
+	_ = w.WriteField("model", t.model)
+	_ = w.WriteField("response_format", "json")
+	if t.language != "" {
+		_ = w.WriteField("language", t.language)
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", t.apiBase+"/audio/transcriptions", &body)
+	if err != nil {
+		return "", fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	if t.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(data))
+	}
+	var out struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return "", fmt.Errorf("unmarshal response: %w", err)
+	}
+	return strings.TrimSpace(out.Text), nil
+}
+
+// whisperCppTranscriber runs the whisper.cpp CLI. whisper.cpp reads WAV, so
+// other formats (WhatsApp voice notes are Ogg/Opus) are converted with ffmpeg first.
+type whisperCppTranscriber struct {
+	binary   string
+	model    string
+	language string
+	timeout  time.Duration
+}
+
+func (t *whisperCppTranscriber) Remote() bool { return false }
+
+func (t *whisperCppTranscriber) Transcribe(ctx context.Context, file string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	input := file
+	if !strings.EqualFold(filepath.Ext(file), ".wav") {
+		wav, err := os.CreateTemp("", "sypher-audio-*.wav")
+		if err != nil {
+			return "", err
+		}
+		wav.Close()
+		defer os.Remove(wav.Name())
+		conv := exec.CommandContext(ctx, "ffmpeg", "-y", "-loglevel", "error", "-i", file, "-ar", "16000", "-ac", "1", "-c:a", "pcm_s16le", wav.Name())
+		if out, err := conv.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("convert to wav (ffmpeg): %v: %s", err, strings.TrimSpace(string(out)))
+		}
+		input = wav.Name()
+	}
+
+	args := []string{"-m", t.model, "-f", input, "-nt", "-np"}
+	if t.language != "" {
+		args = append(args, "-l", t.language)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.binary, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s: %v: %s", t.binary, err, strings.TrimSpace(stderr.String()))
+	}
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, " "), nil
+}
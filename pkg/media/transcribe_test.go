@@ -0,0 +1,96 @@
+package media
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+)
+
+func TestOpenAITranscriber(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		file.Close()
+		fmt.Fprintf(w, `{"text":" %s %s %s "}`, header.Filename, r.FormValue("model"), r.FormValue("language"))
+	}))
+	defer srv.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Providers.OpenAI.APIKey = "sk-test"
+	cfg.Media.Transcription = config.TranscriptionConfig{Backend: "openai", APIBase: srv.URL + "/v1", Language: "de"}
+	tr, err := NewTranscriber(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tr.Remote() {
+		t.Error("openai transcriber should be remote")
+	}
+	audio := filepath.Join(t.TempDir(), "voice.ogg")
+	os.WriteFile(audio, []byte("OggS"), 0600)
+	got, err := tr.Transcribe(context.Background(), audio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "voice.ogg whisper-1 de" {
+		t.Errorf("transcript = %q", got)
+	}
+}
+
+func TestWhisperCppTranscriber(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script stub")
+	}
+	dir := t.TempDir()
+	bin := filepath.Join(dir, "whisper-cli")
+	script := "#!/bin/sh\necho\necho '  Hello there.'\necho ' General Kenobi.'\n"
+	if err := os.WriteFile(bin, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.Media.Transcription = config.TranscriptionConfig{Backend: "whisper_cpp", Binary: bin, Model: filepath.Join(dir, "ggml-base.bin")}
+	tr, err := NewTranscriber(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.Remote() {
+		t.Error("whisper.cpp transcriber should be local")
+	}
+	audio := filepath.Join(dir, "note.wav")
+	os.WriteFile(audio, []byte("RIFF"), 0600)
+	got, err := tr.Transcribe(context.Background(), audio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Hello there. General Kenobi." {
+		t.Errorf("transcript = %q", got)
+	}
+}
+
+func TestNewTranscriber_Config(t *testing.T) {
+	cfg := config.DefaultConfig()
+	if tr, err := NewTranscriber(cfg); tr != nil || err != nil {
+		t.Errorf("expected no transcriber by default, got %v, %v", tr, err)
+	}
+	cfg.Media.Transcription.Backend = "whisper_cpp"
+	if _, err := NewTranscriber(cfg); err == nil {
+		t.Error("expected error for whisper_cpp without a model")
+	}
+	cfg.Media.Transcription.Backend = "vosk"
+	if _, err := NewTranscriber(cfg); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
@@ -11,19 +11,29 @@ import (
 type Metrics struct {
 	mu sync.RWMutex
 
-	ToolCallsTotal   map[string]int
-	ToolErrorsTotal  map[string]int
-	LLMRequestsTotal map[string]int
-	TaskCompleted    int
-	TaskFailed       int
+	ToolCallsTotal      map[string]int
+	ToolErrorsTotal     map[string]int
+	LLMRequestsTotal    map[string]int
+	LLMPromptTokens     map[string]int     // by provider
+	LLMCompletionTokens map[string]int     // by provider
+	LLMCostUSD          map[string]float64 // by provider
+	AgentCostUSD        map[string]float64 // by agent
+	TaskCompleted       int
+	TaskFailed          int
+	QueueDepth          int
+	InFlight            int
 }
 
 // NewMetrics creates a new metrics collector.
 func NewMetrics() *Metrics {
 	return &Metrics{
-		ToolCallsTotal:   make(map[string]int),
-		ToolErrorsTotal:  make(map[string]int),
-		LLMRequestsTotal: make(map[string]int),
+		ToolCallsTotal:      make(map[string]int),
+		ToolErrorsTotal:     make(map[string]int),
+		LLMRequestsTotal:    make(map[string]int),
+		LLMPromptTokens:     make(map[string]int),
+		LLMCompletionTokens: make(map[string]int),
+		LLMCostUSD:          make(map[string]float64),
+		AgentCostUSD:        make(map[string]float64),
 	}
 }
 
@@ -48,6 +58,16 @@ func (m *Metrics) IncLLMRequest(provider string) {
 	m.LLMRequestsTotal[provider]++
 }
 
+// AddLLMUsage adds token counts and estimated cost for one LLM response.
+func (m *Metrics) AddLLMUsage(provider, agentID string, promptTokens, completionTokens int, costUSD float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.LLMPromptTokens[provider] += promptTokens
+	m.LLMCompletionTokens[provider] += completionTokens
+	m.LLMCostUSD[provider] += costUSD
+	m.AgentCostUSD[agentID] += costUSD
+}
+
 // IncTaskCompleted increments completed task count.
 func (m *Metrics) IncTaskCompleted() {
 	m.mu.Lock()
@@ -62,6 +82,20 @@ func (m *Metrics) IncTaskFailed() {
 	m.TaskFailed++
 }
 
+// AddQueueDepth adjusts the number of inbound messages waiting for a worker.
+func (m *Metrics) AddQueueDepth(delta int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.QueueDepth += delta
+}
+
+// AddInFlight adjusts the number of messages currently being processed.
+func (m *Metrics) AddInFlight(delta int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.InFlight += delta
+}
+
 // Snapshot returns a copy of current metrics.
 func (m *Metrics) Snapshot() map[string]interface{} {
 	m.mu.RLock()
@@ -74,16 +108,18 @@ func (m *Metrics) Snapshot() map[string]interface{} {
 	for k, v := range m.ToolErrorsTotal {
 		toolErrors[k] = v
 	}
-	llmReqs := make(map[string]int)
-	for k, v := range m.LLMRequestsTotal {
-		llmReqs[k] = v
-	}
 	return map[string]interface{}{
-		"tool_calls_total":   toolCalls,
-		"tool_errors_total":  toolErrors,
-		"llm_requests_total": llmReqs,
-		"task_completed":     m.TaskCompleted,
-		"task_failed":        m.TaskFailed,
+		"tool_calls_total":            toolCalls,
+		"tool_errors_total":           toolErrors,
+		"llm_requests_total":          copyInts(m.LLMRequestsTotal),
+		"llm_prompt_tokens_total":     copyInts(m.LLMPromptTokens),
+		"llm_completion_tokens_total": copyInts(m.LLMCompletionTokens),
+		"llm_cost_usd_total":          copyFloats(m.LLMCostUSD),
+		"agent_cost_usd_total":        copyFloats(m.AgentCostUSD),
+		"task_completed":              m.TaskCompleted,
+		"task_failed":                 m.TaskFailed,
+		"queue_depth":                 m.QueueDepth,
+		"in_flight":                   m.InFlight,
 	}
 }
 
@@ -99,6 +135,12 @@ func (m *Metrics) PrometheusFormat() string {
 	b.WriteString("# HELP sypher_task_failed Total failed tasks\n")
 	b.WriteString("# TYPE sypher_task_failed counter\n")
 	b.WriteString(fmt.Sprintf("sypher_task_failed %d\n", m.TaskFailed))
+	b.WriteString("# HELP sypher_queue_depth Inbound messages waiting for a worker\n")
+	b.WriteString("# TYPE sypher_queue_depth gauge\n")
+	b.WriteString(fmt.Sprintf("sypher_queue_depth %d\n", m.QueueDepth))
+	b.WriteString("# HELP sypher_in_flight Messages currently being processed\n")
+	b.WriteString("# TYPE sypher_in_flight gauge\n")
+	b.WriteString(fmt.Sprintf("sypher_in_flight %d\n", m.InFlight))
 
 	// tool_calls_total
 	b.WriteString("# HELP sypher_tool_calls_total Total tool calls by tool\n")
@@ -124,5 +166,53 @@ func (m *Metrics) PrometheusFormat() string {
 		b.WriteString(fmt.Sprintf("sypher_tool_errors_total{tool=%q} %d\n", tool, m.ToolErrorsTotal[tool]))
 	}
 
+	// LLM requests, tokens and estimated cost
+	b.WriteString("# HELP sypher_llm_requests_total Total LLM responses by provider\n")
+	b.WriteString("# TYPE sypher_llm_requests_total counter\n")
+	for _, p := range sortedKeys(m.LLMRequestsTotal) {
+		b.WriteString(fmt.Sprintf("sypher_llm_requests_total{provider=%q} %d\n", p, m.LLMRequestsTotal[p]))
+	}
+	b.WriteString("# HELP sypher_llm_tokens_total Total LLM tokens by provider and type\n")
+	b.WriteString("# TYPE sypher_llm_tokens_total counter\n")
+	for _, p := range sortedKeys(m.LLMPromptTokens) {
+		b.WriteString(fmt.Sprintf("sypher_llm_tokens_total{provider=%q,type=\"prompt\"} %d\n", p, m.LLMPromptTokens[p]))
+		b.WriteString(fmt.Sprintf("sypher_llm_tokens_total{provider=%q,type=\"completion\"} %d\n", p, m.LLMCompletionTokens[p]))
+	}
+	b.WriteString("# HELP sypher_llm_cost_usd_total Estimated LLM cost in USD by provider\n")
+	b.WriteString("# TYPE sypher_llm_cost_usd_total counter\n")
+	for _, p := range sortedKeys(m.LLMCostUSD) {
+		b.WriteString(fmt.Sprintf("sypher_llm_cost_usd_total{provider=%q} %g\n", p, m.LLMCostUSD[p]))
+	}
+	b.WriteString("# HELP sypher_agent_cost_usd_total Estimated LLM cost in USD by agent\n")
+	b.WriteString("# TYPE sypher_agent_cost_usd_total counter\n")
+	for _, a := range sortedKeys(m.AgentCostUSD) {
+		b.WriteString(fmt.Sprintf("sypher_agent_cost_usd_total{agent=%q} %g\n", a, m.AgentCostUSD[a]))
+	}
+
 	return b.String()
 }
+
+func copyInts(src map[string]int) map[string]int {
+	out := make(map[string]int, len(src))
+	for k, v := range src {
+		out[k] = v
+	}
+	return out
+}
+
+func copyFloats(src map[string]float64) map[string]float64 {
+	out := make(map[string]float64, len(src))
+	for k, v := range src {
+		out[k] = v
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,96 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+)
+
+// pathArgs are the tool arguments that name files or directories.
+var pathArgs = []string{"path", "working_dir", "repo"}
+
+// RequiresApproval reports whether a tool call matches an approval rule
+// (policies.approval.rules) and returns a short reason naming the rule.
+func (e *Evaluator) RequiresApproval(agentID, tool string, args map[string]interface{}) (string, bool) {
+	for i, r := range e.cfg.Policies.Approval.Rules {
+		if len(r.Tools) > 0 && !matchesName(r.Tools, tool) {
+			continue
+		}
+		if len(r.AgentIDs) > 0 && !matchesName(r.AgentIDs, agentID) {
+			continue
+		}
+		reason := fmt.Sprintf("approval rule %d", i+1)
+		if len(r.Patterns) > 0 {
+			subject := approvalSubject(args)
+			pattern := ""
+			for _, p := range r.Patterns {
+				if re, err := regexp.Compile(p); err == nil && re.MatchString(subject) {
+					pattern = p
+					break
+				}
+			}
+			if pattern == "" {
+				continue
+			}
+			reason += fmt.Sprintf(": matches %q", pattern)
+		}
+		if r.OutsideWorkspace {
+			p, ok := e.outsideWorkspace(agentID, args)
+			if !ok {
+				continue
+			}
+			reason += fmt.Sprintf(": %s is outside the workspace", p)
+		}
+		return reason, true
+	}
+	return "", false
+}
+
+// approvalSubject is the text approval patterns match: the command of exec
+// and stream_command, otherwise the JSON arguments.
+func approvalSubject(args map[string]interface{}) string {
+	if cmd, ok := args["command"].(string); ok {
+		return cmd
+	}
+	data, _ := json.Marshal(args)
+	return string(data)
+}
+
+// outsideWorkspace returns the first path argument that resolves outside the
+// agent's workspace. Relative paths are taken relative to the workspace.
+func (e *Evaluator) outsideWorkspace(agentID string, args map[string]interface{}) (string, bool) {
+	workspace := e.cfg.AgentWorkspace(agentID)
+	if workspace == "" {
+		workspace = config.ExpandPath(e.cfg.Agents.Defaults.Workspace)
+	}
+	wsAbs, err := filepath.Abs(workspace)
+	if err != nil || workspace == "" {
+		return "", false
+	}
+	for _, key := range pathArgs {
+		p, _ := args[key].(string)
+		if p == "" {
+			continue
+		}
+		abs := config.ExpandPath(p)
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(wsAbs, abs)
+		}
+		if !within(filepath.Clean(abs), wsAbs) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+func matchesName(names []string, name string) bool {
+	for _, n := range names {
+		if n == "*" || n == name {
+			return true
+		}
+	}
+	return false
+}
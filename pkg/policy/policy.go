@@ -35,11 +35,25 @@ func (e *Evaluator) CanAccessFile(agentID, path, access string) bool {
 	if err != nil {
 		abs = path
 	}
+	// Hooks or config written into a git directory would run on the next git call
+	if access != "read" && inGitDir(abs) {
+		return false
+	}
 
-	// Workspace root always allowed for bound agents
-	workspace := config.ExpandPath(e.cfg.Agents.Defaults.Workspace)
-	wsAbs, _ := filepath.Abs(workspace)
-	if strings.HasPrefix(abs, wsAbs) {
+	// The server keeps conversation history and usage records under the
+	// default workspace; no agent may read or change them
+	defaults := e.cfg.Agents.Defaults.Workspace
+	if defaults == "" {
+		defaults = "~/.sypher-mini/workspace"
+	}
+	for _, dir := range internalDirs {
+		if withinWorkspace(abs, filepath.Join(config.ExpandPath(defaults), dir)) {
+			return false
+		}
+	}
+
+	// The agent's own workspace (the default one when it has none) is always allowed
+	if workspace := e.cfg.AgentWorkspace(agentID); workspace != "" && withinWorkspace(abs, workspace) {
 		return true
 	}
 
@@ -68,6 +82,35 @@ func (e *Evaluator) CanAccessFile(agentID, path, access string) bool {
 	return false
 }
 
+// internalDirs are server-owned subdirectories of the default workspace.
+var internalDirs = []string{"sessions", "usage"}
+
+// withinWorkspace reports whether path is inside dir, comparing against both
+// dir and its symlink-resolved form since callers may pass resolved paths.
+func withinWorkspace(path, dir string) bool {
+	dirAbs, _ := filepath.Abs(dir)
+	if within(path, dirAbs) {
+		return true
+	}
+	real, err := filepath.EvalSymlinks(dirAbs)
+	return err == nil && within(path, real)
+}
+
+// inGitDir reports whether path is a .git directory (or file) or inside one.
+func inGitDir(path string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if part == ".git" {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether path is dir or inside it.
+func within(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
 // CanAccessNetwork returns true if the agent can access the given host.
 func (e *Evaluator) CanAccessNetwork(agentID, host string) bool {
 	// No policies = allow (permissive default)
@@ -110,6 +153,20 @@ func (e *Evaluator) CanAccessNetwork(agentID, host string) bool {
 	return false
 }
 
+// CanUseNetwork reports whether a sandboxed process of the agent may have a
+// network at all. A namespace cannot filter by host, so any allowed domain
+// grants it. Unlike CanAccessNetwork this is strict: no policies = no network.
+func (e *Evaluator) CanUseNetwork(agentID string) bool {
+	for _, n := range e.cfg.Policies.Network {
+		for _, aid := range n.AgentIDs {
+			if (aid == "*" || aid == agentID) && len(n.AllowDomains) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // CheckRateLimit returns true if the request is within rate limit.
 func (e *Evaluator) CheckRateLimit(agentID, toolName string) bool {
 	e.mu.Lock()
@@ -33,3 +33,97 @@ func TestEvaluator_CanAccessFile(t *testing.T) {
 		t.Error("workspace should be accessible")
 	}
 }
+
+func TestEvaluator_CanAccessFile_Policies(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = "/srv/ws"
+	cfg.Agents.List = []config.AgentConfig{{ID: "ops", Workspace: "/srv/ops"}}
+	cfg.Policies.Files = []config.FilePolicy{
+		{Path: "/var/log/**", AgentIDs: []string{"*"}, Access: "read"},
+		{Path: "/srv/shared/**", AgentIDs: []string{"ops"}, Access: "read_write"},
+	}
+	e := NewEvaluator(cfg)
+
+	cases := []struct {
+		agent, path, access string
+		want                bool
+	}{
+		{"main", "/srv/ws/a.txt", "write", true},
+		{"main", "/srv/ws-other/a.txt", "read", false},
+		{"ops", "/srv/ops/a.txt", "write", true},
+		{"main", "/srv/ops/a.txt", "read", false},
+		{"main", "/var/log/syslog", "read", true},
+		{"main", "/var/log/syslog", "write", false},
+		{"ops", "/srv/shared/x", "write", true},
+		{"main", "/srv/shared/x", "read", false},
+		{"main", "/etc/passwd", "read", false},
+		{"main", "/srv/ws/repo/.git/config", "read", true},
+		{"main", "/srv/ws/repo/.git/hooks/pre-commit", "write", false},
+		{"main", "/srv/ws/repo/.git", "write", false},
+		{"main", "/srv/ws/repo/.gitignore", "write", true},
+		{"ops", "/srv/ws/a.txt", "read", false},
+		{"main", "/srv/ws/sessions/cli_cli.json", "read", false},
+		{"main", "/srv/ws/sessions", "write", false},
+		{"main", "/srv/ws/usage/2026-10.jsonl", "read", false},
+		{"main", "/srv/ws/sessions-notes.md", "write", true},
+	}
+	for _, c := range cases {
+		if got := e.CanAccessFile(c.agent, c.path, c.access); got != c.want {
+			t.Errorf("CanAccessFile(%s, %s, %s) = %v, want %v", c.agent, c.path, c.access, got, c.want)
+		}
+	}
+}
+
+func TestEvaluator_CanUseNetwork(t *testing.T) {
+	cfg := config.DefaultConfig()
+	e := NewEvaluator(cfg)
+	if e.CanUseNetwork("main") {
+		t.Error("no network policy should mean no sandbox network")
+	}
+	cfg.Policies.Network = []config.NetPolicy{
+		{AgentIDs: []string{"fetcher"}, AllowDomains: []string{"*.example.com"}},
+		{AgentIDs: []string{"*"}, DenyDomains: []string{"*"}},
+	}
+	if !e.CanUseNetwork("fetcher") {
+		t.Error("agent with allowed domains should get a network")
+	}
+	if e.CanUseNetwork("main") {
+		t.Error("deny-only policy should not grant a network")
+	}
+}
+
+func TestEvaluator_RequiresApproval(t *testing.T) {
+	cfg := config.DefaultConfig()
+	ws := t.TempDir()
+	cfg.Agents.Defaults.Workspace = ws
+	e := NewEvaluator(cfg)
+	if _, ok := e.RequiresApproval("main", "exec", map[string]interface{}{"command": "ls"}); ok {
+		t.Error("no rules should mean no approval")
+	}
+	cfg.Policies.Approval.Rules = []config.ApprovalRule{
+		{Tools: []string{"git"}},
+		{Tools: []string{"exec"}, Patterns: []string{`^curl\b`}},
+		{AgentIDs: []string{"cursor"}, Tools: []string{"write_file"}},
+		{Tools: []string{"write_file", "edit_file"}, OutsideWorkspace: true},
+	}
+	cases := []struct {
+		agent, tool string
+		args        map[string]interface{}
+		want        bool
+	}{
+		{"main", "git", map[string]interface{}{"action": "status"}, true},
+		{"main", "exec", map[string]interface{}{"command": "curl example.com"}, true},
+		{"main", "exec", map[string]interface{}{"command": "ls -la"}, false},
+		{"cursor", "write_file", map[string]interface{}{"path": "a.txt"}, true},
+		{"main", "write_file", map[string]interface{}{"path": "a.txt"}, false},
+		{"main", "write_file", map[string]interface{}{"path": "../escape.txt"}, true},
+		{"main", "edit_file", map[string]interface{}{"path": "/etc/hosts"}, true},
+		{"main", "read_file", map[string]interface{}{"path": "/etc/hosts"}, false},
+	}
+	for _, c := range cases {
+		reason, got := e.RequiresApproval(c.agent, c.tool, c.args)
+		if got != c.want {
+			t.Errorf("RequiresApproval(%s, %s, %v) = %v (%s), want %v", c.agent, c.tool, c.args, got, reason, c.want)
+		}
+	}
+}
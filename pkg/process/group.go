@@ -0,0 +1,26 @@
+package process
+
+import "time"
+
+// DefaultGrace is how long Terminate waits after SIGTERM before SIGKILL.
+const DefaultGrace = 3 * time.Second
+
+// Terminate stops the process group led by pid: SIGTERM first, then SIGKILL
+// for whatever is still running after grace. A pid that does not lead a
+// group is signalled on its own. Already exited processes are not an error.
+func Terminate(pid int, grace time.Duration) error {
+	if !Alive(pid) {
+		return nil
+	}
+	if err := terminateGroup(pid); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(grace)
+	for time.Now().Before(deadline) {
+		if !Alive(pid) {
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return KillGroup(pid)
+}
@@ -0,0 +1,52 @@
+//go:build !windows
+
+package process
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// SetGroup makes cmd start in its own process group (pgid = pid), so the
+// shell and everything it spawns can be signalled together.
+func SetGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// KillGroup sends SIGKILL to the process group led by pid.
+func KillGroup(pid int) error {
+	return signalGroup(pid, syscall.SIGKILL)
+}
+
+// Alive reports whether the process group led by pid, or the process itself,
+// still exists.
+func Alive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	return syscall.Kill(-pid, 0) == nil || syscall.Kill(pid, 0) == nil
+}
+
+func terminateGroup(pid int) error {
+	return signalGroup(pid, syscall.SIGTERM)
+}
+
+// signalGroup signals the group led by pid, or only pid when it leads none
+// (e.g. a PID recorded before process groups were used).
+func signalGroup(pid int, sig syscall.Signal) error {
+	if pid <= 0 {
+		return syscall.EINVAL
+	}
+	err := syscall.Kill(-pid, sig)
+	if errors.Is(err, syscall.ESRCH) {
+		err = syscall.Kill(pid, sig)
+	}
+	if errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+//go:build !windows
+
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTracker_RemoveTaskTerminatesGroup(t *testing.T) {
+	// The shell leads the group; the background sleep is a grandchild-style survivor
+	cmd := exec.Command("sh", "-c", "sleep 30 & echo $!; wait")
+	SetGroup(cmd)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	var child int
+	if _, err := fmt.Fscan(out, &child); err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = cmd.Wait() }()
+
+	tr := New()
+	tr.RecordGroup("task1", cmd.Process.Pid)
+	tr.RemoveTask("task1")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !exited(child) {
+		if time.Now().After(deadline) {
+			t.Fatal("child of the group survived RemoveTask")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if tr.CanKill("task1", cmd.Process.Pid) {
+		t.Error("RemoveTask should clear PIDs")
+	}
+}
+
+// exited reports whether pid is gone or a zombie waiting to be reaped by init.
+func exited(pid int) bool {
+	if syscall.Kill(pid, 0) != nil {
+		return true
+	}
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	return err == nil && strings.Contains(string(stat), ") Z ")
+}
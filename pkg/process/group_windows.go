@@ -0,0 +1,45 @@
+//go:build windows
+
+package process
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// SetGroup makes cmd start in a new process group.
+func SetGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// KillGroup kills pid and its descendants.
+func KillGroup(pid int) error {
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pid)).Run(); err != nil {
+		if p, ferr := os.FindProcess(pid); ferr == nil {
+			return p.Kill()
+		}
+		return err
+	}
+	return nil
+}
+
+// Alive reports whether pid still exists.
+func Alive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	out, err := exec.Command("tasklist", "/FI", "PID eq "+strconv.Itoa(pid), "/NH").Output()
+	return err == nil && strings.Contains(string(out), " "+strconv.Itoa(pid)+" ")
+}
+
+// terminateGroup has no graceful equivalent of SIGTERM for console
+// process trees, so the tree is killed.
+func terminateGroup(pid int) error {
+	return KillGroup(pid)
+}
@@ -6,7 +6,7 @@ import (
 
 // Tracker tracks PIDs started by Sypher-mini per task.
 type Tracker struct {
-	taskPIDs map[string]map[int]bool
+	taskPIDs map[string]map[int]bool // pid -> leads a process group Sypher-mini started
 	mu       sync.RWMutex
 }
 
@@ -19,12 +19,22 @@ func New() *Tracker {
 
 // Record records a PID for a task.
 func (t *Tracker) Record(taskID string, pid int) {
+	t.record(taskID, pid, false)
+}
+
+// RecordGroup records the leader of a process group started for a task (see
+// SetGroup). RemoveTask terminates such groups.
+func (t *Tracker) RecordGroup(taskID string, pid int) {
+	t.record(taskID, pid, true)
+}
+
+func (t *Tracker) record(taskID string, pid int, group bool) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	if t.taskPIDs[taskID] == nil {
 		t.taskPIDs[taskID] = make(map[int]bool)
 	}
-	t.taskPIDs[taskID][pid] = true
+	t.taskPIDs[taskID][pid] = group
 }
 
 // CanKill returns true if the PID belongs to the task and can be killed.
@@ -35,12 +45,36 @@ func (t *Tracker) CanKill(taskID string, pid int) bool {
 	if !ok {
 		return false
 	}
-	return pids[pid]
+	_, ok = pids[pid]
+	return ok
 }
 
-// RemoveTask removes all PIDs for a task (e.g. on completion).
-func (t *Tracker) RemoveTask(taskID string) {
+// Forget drops a PID whose process group has fully exited, so a later reuse
+// of the PID is never signalled.
+func (t *Tracker) Forget(taskID string, pid int) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	delete(t.taskPIDs[taskID], pid)
+}
+
+// RemoveTask removes all PIDs for a task (e.g. on completion) and terminates
+// the process groups it started that are still running.
+func (t *Tracker) RemoveTask(taskID string) {
+	t.mu.Lock()
+	pids := t.taskPIDs[taskID]
 	delete(t.taskPIDs, taskID)
+	t.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for pid, group := range pids {
+		if !group {
+			continue
+		}
+		wg.Add(1)
+		go func(pid int) {
+			defer wg.Done()
+			_ = Terminate(pid, DefaultGrace)
+		}(pid)
+	}
+	wg.Wait()
 }
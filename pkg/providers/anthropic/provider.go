@@ -41,13 +41,46 @@ func normalizeModel(model string) string {
 	return model
 }
 
+// resolveModel strips a provider prefix and applies the default model.
+func (p *Provider) resolveModel(model string) string {
+	if model = normalizeModel(model); model == "" {
+		return p.defaultModel
+	}
+	return model
+}
+
 // Chat sends a request to Anthropic Messages API.
 func (p *Provider) Chat(ctx context.Context, messages []types.Message, tools []types.ToolDefinition, model string, options map[string]interface{}) (*types.LLMResponse, error) {
+	req, err := p.newRequest(ctx, messages, model, options, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, types.NewRequestError("anthropic", p.resolveModel(model), err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, types.NewHTTPError("anthropic", p.resolveModel(model), resp, body)
+	}
+
+	return parseResponse(body)
+}
+
+// newRequest builds a Messages API request.
+func (p *Provider) newRequest(ctx context.Context, messages []types.Message, model string, options map[string]interface{}, stream bool) (*http.Request, error) {
 	if p.apiKey == "" {
-		return nil, fmt.Errorf("anthropic: API key not configured")
+		return nil, types.NewAuthError("anthropic", p.resolveModel(model), fmt.Errorf("anthropic: API key not configured"))
 	}
 
-	model = normalizeModel(model)
+	model = p.resolveModel(model)
 	maxTokens := 2048
 	if mt, ok := asInt(options["max_tokens"]); ok && mt > 0 {
 		maxTokens = mt
@@ -58,16 +91,24 @@ func (p *Provider) Chat(ctx context.Context, messages []types.Message, tools []t
 	var anthropicMessages []map[string]interface{}
 	for _, m := range messages {
 		if m.Role == "system" {
-			system = m.Content
+			// Multiple system messages (prompt + running summary) are joined
+			if system != "" {
+				system += "\n\n"
+			}
+			system += m.Content
 			continue
 		}
 		if m.Role == "tool" {
 			// Anthropic uses tool_result in assistant turn; skip for simple impl
 			continue
 		}
+		var content interface{} = m.Content
+		if len(m.Images) > 0 {
+			content = contentBlocks(m)
+		}
 		anthropicMessages = append(anthropicMessages, map[string]interface{}{
 			"role":    m.Role,
-			"content": m.Content,
+			"content": content,
 		})
 	}
 
@@ -79,6 +120,9 @@ func (p *Provider) Chat(ctx context.Context, messages []types.Message, tools []t
 	if system != "" {
 		reqBody["system"] = system
 	}
+	if stream {
+		reqBody["stream"] = true
+	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
@@ -93,23 +137,30 @@ func (p *Provider) Chat(ctx context.Context, messages []types.Message, tools []t
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-api-key", p.apiKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
+	return req, nil
+}
 
-	resp, err := p.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+// contentBlocks converts a message with images to image and text blocks.
+// Unreadable images are noted in the text.
+func contentBlocks(m types.Message) []map[string]interface{} {
+	text := m.Content
+	var blocks []map[string]interface{}
+	for _, img := range m.Images {
+		data, err := img.Base64()
+		if err != nil {
+			text += fmt.Sprintf("\n[image unavailable: %v]", err)
+			continue
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type": "image",
+			"source": map[string]string{
+				"type":       "base64",
+				"media_type": img.MimeType,
+				"data":       data,
+			},
+		})
 	}
-
-	return parseResponse(body)
+	return append(blocks, map[string]interface{}{"type": "text", "text": text})
 }
 
 func parseResponse(body []byte) (*types.LLMResponse, error) {
@@ -0,0 +1,119 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sypherexx/sypher-mini/pkg/providers/types"
+)
+
+// streamEvent is one Messages API stream event (message_start, content_block_delta, ...).
+type streamEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		Usage struct {
+			InputTokens int `json:"input_tokens"`
+		} `json:"usage"`
+	} `json:"message"`
+	Delta struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage struct {
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// ChatStream sends a streaming Messages API request and calls onEvent for each text delta.
+func (p *Provider) ChatStream(ctx context.Context, messages []types.Message, tools []types.ToolDefinition, model string, options map[string]interface{}, onEvent func(types.StreamEvent)) (*types.LLMResponse, error) {
+	req, err := p.newRequest(ctx, messages, model, options, true)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, types.NewRequestError("anthropic", p.resolveModel(model), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, types.NewHTTPError("anthropic", p.resolveModel(model), resp, body)
+	}
+	return parseStream(resp.Body, onEvent)
+}
+
+// parseStream reads Messages API stream events.
+func parseStream(r io.Reader, onEvent func(types.StreamEvent)) (*types.LLMResponse, error) {
+	var acc types.StreamAccumulator
+	usage := &types.UsageInfo{}
+	var streamErr error
+	err := types.ReadSSE(r, func(data string) bool {
+		var ev streamEvent
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			streamErr = fmt.Errorf("unmarshal stream event: %w", err)
+			return false
+		}
+		switch ev.Type {
+		case "message_start":
+			usage.PromptTokens = ev.Message.Usage.InputTokens
+		case "content_block_delta":
+			if ev.Delta.Type == "text_delta" && ev.Delta.Text != "" {
+				se := types.StreamEvent{Delta: ev.Delta.Text}
+				acc.Add(se)
+				onEvent(se)
+			}
+		case "message_delta":
+			if ev.Delta.StopReason != "" {
+				acc.FinishReason = ev.Delta.StopReason
+			}
+			usage.CompletionTokens = ev.Usage.OutputTokens
+		case "message_stop":
+			return false
+		case "error":
+			if ev.Error != nil {
+				streamErr = &types.FailoverError{
+					Reason:   streamErrorReason(ev.Error.Type),
+					Provider: "anthropic",
+					Wrapped:  fmt.Errorf("stream error (%s): %s", ev.Error.Type, ev.Error.Message),
+				}
+			} else {
+				streamErr = fmt.Errorf("stream error")
+			}
+			return false
+		}
+		return true
+	})
+	if streamErr != nil {
+		return nil, streamErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read stream: %w", err)
+	}
+	usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	acc.Usage = usage
+	return acc.Response(), nil
+}
+
+// streamErrorReason maps an Anthropic error type to a FailoverReason.
+func streamErrorReason(errType string) types.FailoverReason {
+	switch errType {
+	case "authentication_error", "permission_error":
+		return types.FailoverAuth
+	case "rate_limit_error":
+		return types.FailoverRateLimit
+	case "invalid_request_error":
+		return types.FailoverFormat
+	}
+	return types.FailoverUnknown
+}
@@ -0,0 +1,37 @@
+package anthropic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sypherexx/sypher-mini/pkg/providers/types"
+)
+
+func TestParseStream(t *testing.T) {
+	body := strings.Join([]string{
+		"event: message_start",
+		`data: {"type":"message_start","message":{"usage":{"input_tokens":12}}}`,
+		"",
+		"event: content_block_delta",
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi "}}`,
+		"",
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"there"}}`,
+		"",
+		`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":3}}`,
+		"",
+		`data: {"type":"message_stop"}`,
+		"",
+	}, "\n")
+
+	var got strings.Builder
+	resp, err := parseStream(strings.NewReader(body), func(ev types.StreamEvent) { got.WriteString(ev.Delta) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != "Hi there" || resp.Content != "Hi there" {
+		t.Errorf("unexpected content %q / %q", got.String(), resp.Content)
+	}
+	if resp.FinishReason != "end_turn" || resp.Usage.TotalTokens != 15 {
+		t.Errorf("unexpected finish/usage: %s %+v", resp.FinishReason, resp.Usage)
+	}
+}
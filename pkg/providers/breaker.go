@@ -0,0 +1,218 @@
+package providers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+)
+
+// Circuit breaker states.
+const (
+	BreakerClosed   = "closed"    // requests flow normally
+	BreakerOpen     = "open"      // provider is skipped until the open period ends
+	BreakerHalfOpen = "half_open" // one probe request is allowed through
+)
+
+const (
+	defaultFailureThreshold = 3
+	defaultOpenDuration     = 30 * time.Second
+	maxOpenDuration         = 10 * time.Minute
+)
+
+// CircuitBreaker tracks consecutive failures of one provider. Auth errors and
+// rate limits without a short retry delay open it at once; other failures open
+// it after the failure threshold. Format and context length errors are the
+// request's fault and do not count.
+type CircuitBreaker struct {
+	threshold int
+	openFor   time.Duration
+	now       func() time.Time
+	onChange  func(state string)
+
+	mu        sync.Mutex
+	state     string
+	failures  int
+	openUntil time.Time
+	backoff   time.Duration // current open period; doubles on failed probes
+	probing   bool
+}
+
+// NewCircuitBreaker creates a closed breaker. onChange may be nil; it is called
+// with the breaker locked and must not call back into it.
+func NewCircuitBreaker(threshold int, openFor time.Duration, onChange func(state string)) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = defaultFailureThreshold
+	}
+	if openFor <= 0 {
+		openFor = defaultOpenDuration
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		openFor:   openFor,
+		now:       time.Now,
+		onChange:  onChange,
+		state:     BreakerClosed,
+		backoff:   openFor,
+	}
+}
+
+// Allow reports whether a request may be sent. Once the open period ends the
+// breaker goes half-open and lets a single probe through.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Before(b.openUntil) {
+			return false
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// Success closes the breaker.
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+	b.backoff = b.openFor
+	b.setState(BreakerClosed)
+}
+
+// Failure records a failed request. It returns true when the breaker is open afterwards.
+func (b *CircuitBreaker) Failure(fe *FailoverError) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if fe.Reason == FailoverFormat || fe.Reason == FailoverContextLength {
+		// The provider answered; only the request was bad
+		b.probing = false
+		return b.state == BreakerOpen
+	}
+	b.failures++
+	switch {
+	case b.state == BreakerHalfOpen:
+		// Failed probe: back off longer
+		b.backoff *= 2
+		if b.backoff > maxOpenDuration {
+			b.backoff = maxOpenDuration
+		}
+		b.open(b.backoff)
+	case fe.Reason == FailoverAuth || (fe.Reason == FailoverRateLimit && decide(fe) != actionRetry):
+		d := b.backoff
+		if fe.RetryAfter > d {
+			d = fe.RetryAfter
+		}
+		b.open(d)
+	case b.failures >= b.threshold:
+		b.open(b.backoff)
+	}
+	b.probing = false
+	return b.state == BreakerOpen
+}
+
+// Abort releases a half-open probe whose outcome is unknown (e.g. the caller
+// gave up), so the next request can probe again.
+func (b *CircuitBreaker) Abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State returns the current state.
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && !b.now().Before(b.openUntil) {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+func (b *CircuitBreaker) open(d time.Duration) {
+	b.openUntil = b.now().Add(d)
+	b.setState(BreakerOpen)
+}
+
+func (b *CircuitBreaker) setState(state string) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	if b.onChange != nil {
+		b.onChange(state)
+	}
+}
+
+// Breakers holds one circuit breaker per provider.
+type Breakers struct {
+	threshold int
+	openFor   time.Duration
+
+	mu       sync.Mutex
+	byName   map[string]*CircuitBreaker
+	onChange func(provider, state string)
+}
+
+// NewBreakers creates breakers configured by providers.circuit_breaker.
+func NewBreakers(cfg *config.Config) *Breakers {
+	b := &Breakers{byName: make(map[string]*CircuitBreaker)}
+	if cfg != nil {
+		b.threshold = cfg.Providers.CircuitBreaker.FailureThreshold
+		b.openFor = time.Duration(cfg.Providers.CircuitBreaker.OpenSec) * time.Second
+	}
+	return b
+}
+
+// Get returns the breaker for provider, creating it closed.
+func (b *Breakers) Get(provider string) *CircuitBreaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	cb, ok := b.byName[provider]
+	if !ok {
+		cb = NewCircuitBreaker(b.threshold, b.openFor, func(state string) { b.notify(provider, state) })
+		b.byName[provider] = cb
+	}
+	return cb
+}
+
+// OnChange registers fn to be called when a breaker changes state.
+func (b *Breakers) OnChange(fn func(provider, state string)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onChange = fn
+}
+
+// States returns the current state of every breaker.
+func (b *Breakers) States() map[string]string {
+	b.mu.Lock()
+	breakers := make(map[string]*CircuitBreaker, len(b.byName))
+	for k, v := range b.byName {
+		breakers[k] = v
+	}
+	b.mu.Unlock()
+	out := make(map[string]string, len(breakers))
+	for k, v := range breakers {
+		out[k] = v.State()
+	}
+	return out
+}
+
+func (b *Breakers) notify(provider, state string) {
+	b.mu.Lock()
+	fn := b.onChange
+	b.mu.Unlock()
+	if fn != nil {
+		fn(provider, state)
+	}
+}
@@ -0,0 +1,84 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_OpensAndProbes(t *testing.T) {
+	now := time.Now()
+	var states []string
+	b := NewCircuitBreaker(2, time.Minute, func(s string) { states = append(states, s) })
+	b.now = func() time.Time { return now }
+
+	timeout := &FailoverError{Reason: FailoverTimeout}
+	if b.Failure(timeout) {
+		t.Fatal("opened before threshold")
+	}
+	if b.Failure(&FailoverError{Reason: FailoverFormat}); b.State() != BreakerClosed {
+		t.Fatal("format errors must not count")
+	}
+	if !b.Failure(timeout) || b.Allow() {
+		t.Fatal("expected open breaker to reject")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.Allow() {
+		t.Fatal("expected half-open probe")
+	}
+	if b.Allow() {
+		t.Fatal("only one probe at a time")
+	}
+	b.Failure(timeout)
+	now = now.Add(time.Minute)
+	if b.Allow() {
+		t.Fatal("failed probe should double the open period")
+	}
+	now = now.Add(time.Minute)
+	if !b.Allow() {
+		t.Fatal("expected second probe")
+	}
+	b.Success()
+	if b.State() != BreakerClosed || !b.Allow() {
+		t.Fatal("successful probe should close the breaker")
+	}
+	want := []string{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if fmt.Sprint(states) != fmt.Sprint(want) {
+		t.Errorf("transitions = %v, want %v", states, want)
+	}
+}
+
+func TestCircuitBreaker_RateLimitOpensAtOnce(t *testing.T) {
+	b := NewCircuitBreaker(3, time.Second, nil)
+	if !b.Failure(ClassifyError("anthropic", "", errors.New("API error (status 429): retry in 120s"))) {
+		t.Fatal("rate limit should open the breaker")
+	}
+	if until := time.Until(b.openUntil); until < 85*time.Second {
+		t.Errorf("open period %v should follow the suggested retry delay", until)
+	}
+}
+
+func TestFallbackProvider_SkipsOpenBreaker(t *testing.T) {
+	down := &stubProvider{name: "anthropic", fail: true}
+	up := &stubProvider{name: "openai"}
+	f := &FallbackProvider{
+		entries:  []ProviderEntry{{Provider: down, Name: "anthropic"}, {Provider: up, Name: "openai"}},
+		breakers: NewBreakers(nil),
+	}
+	for i := 0; i < 3; i++ {
+		resp, err := f.Chat(context.Background(), nil, nil, "", nil)
+		if err != nil || resp.Content != "openai:" {
+			t.Fatalf("request %d: %v %v", i, resp, err)
+		}
+	}
+	// Threshold 3: the failing provider is tried until its breaker opens, then skipped
+	if len(down.models) != 3 {
+		t.Errorf("expected 3 calls to the failing provider, got %d", len(down.models))
+	}
+	if got := f.Breakers().States()["anthropic"]; got != BreakerOpen {
+		t.Errorf("anthropic breaker = %s", got)
+	}
+}
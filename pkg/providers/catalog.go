@@ -0,0 +1,158 @@
+package providers
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+)
+
+// Tier is a relative rating from 1 (low) to 3 (high).
+type Tier int
+
+// ModelInfo describes a provider model in the catalog.
+type ModelInfo struct {
+	Provider   string
+	Model      string
+	Cost       Tier // 1 = cheapest
+	Latency    Tier // 1 = fastest
+	Capability Tier // 3 = most capable
+	Vision     bool // accepts image inputs
+}
+
+// DefaultCatalog holds the built-in tiers. The first entry per provider is its default model.
+var DefaultCatalog = []ModelInfo{
+	{Provider: "cerebras", Model: "llama-3.1-70b", Cost: 1, Latency: 1, Capability: 2},
+	{Provider: "cerebras", Model: "llama-3.1-8b", Cost: 1, Latency: 1, Capability: 1},
+	{Provider: "openai", Model: "gpt-4o-mini", Cost: 1, Latency: 2, Capability: 2, Vision: true},
+	{Provider: "openai", Model: "gpt-4o", Cost: 3, Latency: 2, Capability: 3, Vision: true},
+	{Provider: "anthropic", Model: "claude-3-5-sonnet-20241022", Cost: 3, Latency: 3, Capability: 3, Vision: true},
+	{Provider: "anthropic", Model: "claude-3-5-haiku-20241022", Cost: 2, Latency: 2, Capability: 2},
+	{Provider: "gemini", Model: "gemini-1.5-flash", Cost: 1, Latency: 2, Capability: 2, Vision: true},
+	{Provider: "gemini", Model: "gemini-1.5-pro", Cost: 2, Latency: 3, Capability: 3, Vision: true},
+	{Provider: "ollama", Model: "llama3.1", Cost: 1, Latency: 3, Capability: 1},
+	{Provider: "llamacpp", Model: "default", Cost: 1, Latency: 3, Capability: 1},
+}
+
+// Catalog looks up model tiers and orders providers by routing strategy.
+type Catalog struct {
+	models []ModelInfo
+}
+
+// NewCatalog creates a catalog from DefaultCatalog plus providers.catalog overrides.
+// An override replaces the built-in entry for the same provider and model.
+func NewCatalog(cfg *config.Config) *Catalog {
+	models := append([]ModelInfo(nil), DefaultCatalog...)
+	if cfg != nil {
+		for _, o := range cfg.Providers.Catalog {
+			m := ModelInfo{
+				Provider:   strings.ToLower(o.Provider),
+				Model:      o.Model,
+				Cost:       clampTier(o.Cost),
+				Latency:    clampTier(o.Latency),
+				Capability: clampTier(o.Capability),
+				Vision:     o.Vision,
+			}
+			replaced := false
+			for i := range models {
+				if models[i].Provider == m.Provider && models[i].Model == m.Model {
+					models[i] = m
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				models = append(models, m)
+			}
+		}
+	}
+	return &Catalog{models: models}
+}
+
+// Lookup returns the tiers for provider/model. An unknown model falls back to the
+// provider's default entry; an unknown provider gets middle tiers.
+func (c *Catalog) Lookup(provider, model string) ModelInfo {
+	provider = strings.ToLower(provider)
+	var first *ModelInfo
+	for i := range c.models {
+		m := &c.models[i]
+		if m.Provider != provider {
+			continue
+		}
+		if m.Model == model {
+			return *m
+		}
+		if first == nil {
+			first = m
+		}
+	}
+	if first != nil {
+		return *first
+	}
+	return ModelInfo{Provider: provider, Model: model, Cost: 2, Latency: 2, Capability: 2}
+}
+
+// SupportsVision reports whether provider/model accepts image inputs. The model
+// may carry the provider prefix.
+func (c *Catalog) SupportsVision(provider, model string) bool {
+	if idx := strings.Index(model, "/"); idx > 0 && strings.EqualFold(model[:idx], provider) {
+		model = model[idx+1:]
+	}
+	return c.Lookup(provider, model).Vision
+}
+
+// Order returns entries sorted for the strategy, adjusted by observed stats (may be nil).
+func (c *Catalog) Order(strategy RoutingStrategy, entries []ProviderEntry, stats *ProviderStats) []ProviderEntry {
+	type scored struct {
+		e     ProviderEntry
+		score float64
+	}
+	list := make([]scored, 0, len(entries))
+	for _, e := range entries {
+		model := ""
+		if e.Provider != nil {
+			model = e.Provider.GetDefaultModel()
+		}
+		info := c.Lookup(e.Name, model)
+		if stats != nil {
+			info.Latency = stats.LatencyTier(e.Name, info.Latency)
+		}
+		score := strategyScore(strategy, info)
+		if stats != nil {
+			score += stats.ErrorRate(e.Name) * errorPenalty
+		}
+		list = append(list, scored{e, score})
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].score < list[j].score })
+	out := make([]ProviderEntry, len(list))
+	for i, s := range list {
+		out[i] = s.e
+	}
+	return out
+}
+
+// errorPenalty is the score added at a 100% error rate; it outweighs any tier difference.
+const errorPenalty = 30
+
+// strategyScore ranks a model for the strategy; lower is preferred. The primary
+// criterion is weighted by 10 and the secondary breaks ties.
+func strategyScore(strategy RoutingStrategy, m ModelInfo) float64 {
+	switch strategy {
+	case RoutingFastFirst:
+		return float64(m.Latency*10 + m.Cost)
+	case RoutingPowerfulFirst:
+		return float64((4-m.Capability)*10 + m.Latency)
+	default: // cheap_first
+		return float64(m.Cost*10 + m.Latency)
+	}
+}
+
+func clampTier(t int) Tier {
+	if t < 1 {
+		return 2
+	}
+	if t > 3 {
+		return 3
+	}
+	return Tier(t)
+}
@@ -0,0 +1,98 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+)
+
+func testEntries() []ProviderEntry {
+	return []ProviderEntry{
+		{Name: "anthropic", Provider: &stubProvider{name: "anthropic"}},
+		{Name: "cerebras", Provider: &stubProvider{name: "cerebras"}},
+		{Name: "openai", Provider: &stubProvider{name: "openai"}},
+	}
+}
+
+func names(entries []ProviderEntry) []string {
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = e.Name
+	}
+	return out
+}
+
+func TestCatalog_OrderByStrategy(t *testing.T) {
+	c := NewCatalog(nil)
+	cases := map[RoutingStrategy]string{
+		RoutingCheapFirst:    "cerebras",
+		RoutingFastFirst:     "cerebras",
+		RoutingPowerfulFirst: "anthropic",
+	}
+	for strategy, first := range cases {
+		got := names(c.Order(strategy, testEntries(), nil))
+		if got[0] != first {
+			t.Errorf("%s: expected %s first, got %v", strategy, first, got)
+		}
+	}
+}
+
+func TestCatalog_ConfigOverride(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Providers.Catalog = []config.CatalogEntry{{Provider: "anthropic", Model: "claude-3-5-sonnet-20241022", Cost: 1, Latency: 1, Capability: 3}}
+	got := names(NewCatalog(cfg).Order(RoutingCheapFirst, testEntries(), nil))
+	if got[0] != "anthropic" {
+		t.Errorf("override should make anthropic cheapest, got %v", got)
+	}
+}
+
+func TestCatalog_OrderAdjustsForErrors(t *testing.T) {
+	stats := NewProviderStats()
+	for i := 0; i < 5; i++ {
+		stats.Record("cerebras", time.Second, errors.New("503"))
+	}
+	got := names(NewCatalog(nil).Order(RoutingCheapFirst, testEntries(), stats))
+	if got[len(got)-1] != "cerebras" {
+		t.Errorf("failing provider should move last, got %v", got)
+	}
+}
+
+func TestProviderStats_LatencyTier(t *testing.T) {
+	stats := NewProviderStats()
+	stats.Record("openai", 10*time.Second, nil)
+	if tier := stats.LatencyTier("openai", 1); tier != 1 {
+		t.Errorf("expected default tier before enough samples, got %d", tier)
+	}
+	stats.Record("openai", 10*time.Second, nil)
+	stats.Record("openai", 10*time.Second, nil)
+	if tier := stats.LatencyTier("openai", 1); tier != 3 {
+		t.Errorf("expected slow tier 3, got %d", tier)
+	}
+}
+
+func TestFallbackProvider_ImagesOnlyForVisionModels(t *testing.T) {
+	text := &stubProvider{name: "cerebras"}
+	vision := &stubProvider{name: "openai"}
+	fb := &FallbackProvider{catalog: NewCatalog(nil), retryMax: 0}
+	messages := []Message{{Role: "user", Content: "what is this?", Images: []ImagePart{{MimeType: "image/png", Data: "aGk="}}}}
+
+	if _, err := fb.chatEntry(context.Background(), ProviderEntry{Name: "cerebras", Provider: text}, messages, nil, "", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := text.last[0]; len(got.Images) != 0 || !strings.Contains(got.Content, "1 image omitted") {
+		t.Errorf("text model got %+v", got)
+	}
+	if _, err := fb.chatEntry(context.Background(), ProviderEntry{Name: "openai", Provider: vision}, messages, nil, "openai/gpt-4o", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := vision.last[0]; len(got.Images) != 1 || got.Content != "what is this?" {
+		t.Errorf("vision model got %+v", got)
+	}
+	if len(messages[0].Images) != 1 {
+		t.Error("caller's messages were modified")
+	}
+}
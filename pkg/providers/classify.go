@@ -0,0 +1,88 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sypherexx/sypher-mini/pkg/providers/types"
+)
+
+// statusRegex finds the HTTP status in "API error (status 429): ..." messages.
+var statusRegex = regexp.MustCompile(`status (\d{3})`)
+
+// ClassifyError returns err as a FailoverError for provider and model. Typed
+// errors from providers are returned as-is with missing fields filled in;
+// other errors are classified from their message.
+func ClassifyError(provider, model string, err error) *FailoverError {
+	if err == nil {
+		return nil
+	}
+	var fe *FailoverError
+	if errors.As(err, &fe) {
+		if fe.Provider == "" {
+			fe.Provider = provider
+		}
+		if fe.Model == "" {
+			fe.Model = model
+		}
+		return fe
+	}
+	s := err.Error()
+	fe = &FailoverError{Provider: provider, Model: model, Wrapped: err, RetryAfter: types.ParseRetryAfterBody(s)}
+	if m := statusRegex.FindStringSubmatch(s); len(m) == 2 {
+		fe.Status, _ = strconv.Atoi(m[1])
+	}
+	switch {
+	case fe.Status != 0:
+		fe.Reason = types.ReasonForStatus(fe.Status, s)
+	case strings.Contains(s, "API key not configured"):
+		fe.Reason = FailoverAuth
+	case errors.Is(err, context.DeadlineExceeded) || strings.Contains(s, "Client.Timeout"):
+		fe.Reason = FailoverTimeout
+	case strings.Contains(s, "RESOURCE_EXHAUSTED") || strings.Contains(s, "quota"):
+		fe.Reason = FailoverRateLimit
+	default:
+		fe.Reason = FailoverUnknown
+	}
+	return fe
+}
+
+// failoverAction is what the fallback provider does after a failed attempt.
+type failoverAction int
+
+const (
+	actionRetry    failoverAction = iota // retry the same provider after backoff
+	actionFailover                       // stop using this provider, try the next
+	actionFailFast                       // stop: another provider would fail the same way
+)
+
+// maxRetryWait is the longest suggested wait that is slept through before
+// retrying; longer rate limits fail over instead.
+const maxRetryWait = 30 * time.Second
+
+// decide picks the action for a classified failure. Auth errors are never
+// retried; malformed requests are not sent to other providers.
+func decide(fe *FailoverError) failoverAction {
+	switch fe.Reason {
+	case FailoverAuth, FailoverContextLength:
+		return actionFailover
+	case FailoverRateLimit:
+		if fe.RetryAfter > 0 && fe.RetryAfter <= maxRetryWait {
+			return actionRetry
+		}
+		return actionFailover
+	case FailoverFormat:
+		return actionFailFast
+	}
+	return actionRetry
+}
+
+// failFast reports whether err should end the whole request.
+func failFast(err error) bool {
+	var fe *FailoverError
+	return errors.As(err, &fe) && decide(fe) == actionFailFast
+}
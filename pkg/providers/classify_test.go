@@ -0,0 +1,72 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// errorProvider fails with a fixed error and counts calls.
+type errorProvider struct {
+	err   error
+	calls int
+}
+
+func (p *errorProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	p.calls++
+	return nil, p.err
+}
+
+func (p *errorProvider) GetDefaultModel() string { return "m" }
+
+func TestFallbackProvider_FailureDecisions(t *testing.T) {
+	cases := []struct {
+		name      string
+		err       *FailoverError
+		calls     int  // calls to the failing provider (retry_max 2)
+		failsOver bool // whether the next provider is tried
+	}{
+		{"auth never retried", &FailoverError{Reason: FailoverAuth, Status: 401}, 1, true},
+		{"short rate limit retried", &FailoverError{Reason: FailoverRateLimit, Status: 429, RetryAfter: time.Millisecond}, 3, true},
+		{"long rate limit fails over", &FailoverError{Reason: FailoverRateLimit, Status: 429, RetryAfter: time.Hour}, 1, true},
+		{"timeout retried", &FailoverError{Reason: FailoverTimeout}, 3, true},
+		{"context length fails over", &FailoverError{Reason: FailoverContextLength, Status: 400}, 1, true},
+		{"format fails fast", &FailoverError{Reason: FailoverFormat, Status: 400}, 1, false},
+	}
+	for _, c := range cases {
+		bad := &errorProvider{err: c.err}
+		good := &stubProvider{name: "openai"}
+		f := &FallbackProvider{
+			entries:   []ProviderEntry{{Provider: bad, Name: "anthropic"}, {Provider: good, Name: "openai"}},
+			retryMax:  2,
+			retryBase: time.Millisecond,
+			breakers:  NewBreakers(nil),
+		}
+		_, err := f.Chat(context.Background(), nil, nil, "", nil)
+		if bad.calls != c.calls {
+			t.Errorf("%s: %d calls, want %d", c.name, bad.calls, c.calls)
+		}
+		if triedNext := len(good.models) > 0; triedNext != c.failsOver {
+			t.Errorf("%s: next provider tried = %v (err %v)", c.name, triedNext, err)
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	cases := map[string]FailoverReason{
+		"API error (status 401): invalid key": FailoverAuth,
+		"API error (status 429): slow down":   FailoverRateLimit,
+		"API error (status 400): bad":         FailoverFormat,
+		"send request: connection refused":    FailoverUnknown,
+	}
+	for msg, want := range cases {
+		if got := ClassifyError("openai", "gpt-4o", errors.New(msg)); got.Reason != want {
+			t.Errorf("%q: reason %s, want %s", msg, got.Reason, want)
+		}
+	}
+	if fe := ClassifyError("openai", "", fmt.Errorf("wrapped: %w", context.DeadlineExceeded)); fe.Reason != FailoverTimeout {
+		t.Errorf("deadline: reason %s", fe.Reason)
+	}
+}
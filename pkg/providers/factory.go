@@ -1,12 +1,15 @@
 package providers
 
 import (
+	"net"
+	"net/url"
 	"os"
 	"strings"
 
 	"github.com/sypherexx/sypher-mini/pkg/config"
 	"github.com/sypherexx/sypher-mini/pkg/providers/anthropic"
 	"github.com/sypherexx/sypher-mini/pkg/providers/gemini"
+	"github.com/sypherexx/sypher-mini/pkg/providers/local"
 	"github.com/sypherexx/sypher-mini/pkg/providers/openai_compat"
 )
 
@@ -36,28 +39,20 @@ func NewProviderWithFallbacks(cfg *config.Config) []ProviderEntry {
 }
 
 func listProviders(cfg *config.Config) []ProviderEntry {
-	strategy := RoutingStrategy(strings.ToLower(cfg.Providers.RoutingStrategy))
-	if strategy == "" {
-		strategy = RoutingCheapFirst
-	}
-
 	var entries []ProviderEntry
 
-	// cheap_first: Cerebras -> OpenAI -> Anthropic (Anthropic/Gemini need separate impl)
-	if strategy == RoutingCheapFirst || strategy == RoutingFastFirst {
-		if key := getAPIKey("CEREBRAS_API_KEY", cfg.Providers.Cerebras.APIKey); key != "" {
-			base := cfg.Providers.Cerebras.APIBase
-			if base == "" {
-				base = "https://api.cerebras.ai/v1"
-			}
+	if key := getAPIKey("CEREBRAS_API_KEY", cfg.ResolveSecret(cfg.Providers.Cerebras.APIKey)); key != "" {
+		base := cfg.Providers.Cerebras.APIBase
+		if base == "" {
+			base = "https://api.cerebras.ai/v1"
+		}
 		entries = append(entries, ProviderEntry{
 			Provider: openai_compat.New("cerebras", key, base, "llama-3.1-70b"),
 			Name:     "cerebras",
 		})
-		}
 	}
 
-	if key := getAPIKey("OPENAI_API_KEY", cfg.Providers.OpenAI.APIKey); key != "" {
+	if key := getAPIKey("OPENAI_API_KEY", cfg.ResolveSecret(cfg.Providers.OpenAI.APIKey)); key != "" {
 		base := cfg.Providers.OpenAI.APIBase
 		if base == "" {
 			base = "https://api.openai.com/v1"
@@ -68,21 +63,65 @@ func listProviders(cfg *config.Config) []ProviderEntry {
 		})
 	}
 
-	if key := getAPIKey("ANTHROPIC_API_KEY", cfg.Providers.Anthropic.APIKey); key != "" {
+	if key := getAPIKey("ANTHROPIC_API_KEY", cfg.ResolveSecret(cfg.Providers.Anthropic.APIKey)); key != "" {
 		entries = append(entries, ProviderEntry{
 			Provider: anthropic.New(key, "claude-3-5-sonnet-20241022"),
 			Name:     "anthropic",
 		})
 	}
 
-	if key := getAPIKey("GEMINI_API_KEY", cfg.Providers.Gemini.APIKey); key != "" {
+	if key := getAPIKey("GEMINI_API_KEY", cfg.ResolveSecret(cfg.Providers.Gemini.APIKey)); key != "" {
 		entries = append(entries, ProviderEntry{
 			Provider: gemini.New(key, "gemini-1.5-flash"),
 			Name:     "gemini",
 		})
 	}
 
-	return entries
+	for _, lc := range []struct {
+		name string
+		cfg  config.LocalProviderConfig
+	}{
+		{local.BackendOllama, cfg.Providers.Ollama},
+		{local.BackendLlamaCpp, cfg.Providers.LlamaCpp},
+	} {
+		if lc.cfg.Enabled {
+			entries = append(entries, ProviderEntry{
+				Provider: local.New(lc.name, lc.cfg.APIBase, cfg.ResolveSecret(lc.cfg.APIKey), lc.cfg.Model, lc.cfg.Tools),
+				Name:     lc.name,
+				Local:    lc.cfg.Trusted || IsLocal(lc.cfg.APIBase),
+			})
+		}
+	}
+
+	return NewCatalog(cfg).Order(strategyFromConfig(cfg), entries, nil)
+}
+
+// IsLocal reports whether a model server at apiBase runs on this machine, i.e.
+// its host is localhost or a loopback address. An empty apiBase is the
+// backend's localhost default. Local providers stay available in safe mode.
+func IsLocal(apiBase string) bool {
+	if apiBase == "" {
+		return true
+	}
+	u, err := url.Parse(apiBase)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// strategyFromConfig returns the configured routing strategy (default cheap_first).
+func strategyFromConfig(cfg *config.Config) RoutingStrategy {
+	strategy := RoutingStrategy(strings.ToLower(cfg.Providers.RoutingStrategy))
+	if strategy == "" {
+		strategy = RoutingCheapFirst
+	}
+	return strategy
 }
 
 func getAPIKey(envKey, configKey string) string {
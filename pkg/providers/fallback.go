@@ -2,25 +2,30 @@ package providers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
-	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/sypherexx/sypher-mini/pkg/config"
 )
 
-// retryAfterRegex parses "retry in X.XXXs" or "retry in Xs" from API error bodies.
-var retryAfterRegex = regexp.MustCompile(`[Rr]etry in (\d+(?:\.\d+)?)s`)
-
-// FallbackProvider tries providers in order with retries.
+// FallbackProvider tries providers in strategy order with retries. The order is
+// recomputed per request from the catalog and observed latency and error rates.
 type FallbackProvider struct {
 	entries   []ProviderEntry
 	retryMax  int
 	retryBase time.Duration
+	strategy  RoutingStrategy
+	catalog   *Catalog
+	stats     *ProviderStats
+	breakers  *Breakers
 }
 
+// ErrCircuitOpen is returned for a provider whose circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
 // NewFallbackProvider creates a provider that falls back on failure.
 func NewFallbackProvider(cfg *config.Config) *FallbackProvider {
 	retryMax := cfg.Task.RetryMax
@@ -31,83 +36,159 @@ func NewFallbackProvider(cfg *config.Config) *FallbackProvider {
 		entries:   NewProviderWithFallbacks(cfg),
 		retryMax:  retryMax,
 		retryBase: time.Second,
+		strategy:  strategyFromConfig(cfg),
+		catalog:   NewCatalog(cfg),
+		stats:     NewProviderStats(),
+		breakers:  NewBreakers(cfg),
 	}
 }
 
-// Entries returns the configured provider entries.
+// Entries returns the provider entries in current strategy order.
 func (f *FallbackProvider) Entries() []ProviderEntry {
-	return f.entries
+	if f.catalog == nil {
+		return f.entries
+	}
+	return f.catalog.Order(f.strategy, f.entries, f.stats)
 }
 
-// is429 returns true if the error indicates a rate limit (429).
-func is429(err error) bool {
-	if err == nil {
-		return false
+// LocalOnly returns a provider over the local model servers only (see IsLocal).
+func (f *FallbackProvider) LocalOnly() *FallbackProvider {
+	out := *f
+	out.entries = nil
+	for _, e := range f.entries {
+		if e.Local {
+			out.entries = append(out.entries, e)
+		}
 	}
-	s := err.Error()
-	return strings.Contains(s, "429") || strings.Contains(s, "RESOURCE_EXHAUSTED") || strings.Contains(s, "quota")
+	return &out
 }
 
-// parseRetryAfter extracts suggested wait time in seconds from error body.
-func parseRetryAfter(err error) time.Duration {
-	if err == nil {
-		return 0
-	}
-	m := retryAfterRegex.FindStringSubmatch(err.Error())
-	if len(m) < 2 {
-		return 0
-	}
-	sec, _ := strconv.ParseFloat(m[1], 64)
-	if sec <= 0 {
-		return 0
-	}
-	d := time.Duration(sec * float64(time.Second))
-	if d < time.Second {
-		d = time.Second
-	}
-	if d > 90*time.Second {
-		d = 90 * time.Second
-	}
-	return d
+// Breakers returns the per-provider circuit breakers.
+func (f *FallbackProvider) Breakers() *Breakers {
+	return f.breakers
+}
+
+// Stats returns the observed per-provider latency and error rates.
+func (f *FallbackProvider) Stats() *ProviderStats {
+	return f.stats
 }
 
 // Chat tries each provider with retries.
 func (f *FallbackProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	return f.chat(ctx, messages, tools, model, options, nil)
+}
+
+// ChatStream is Chat with streaming. Providers without streaming support return
+// their whole response as one delta. Once output has been emitted, a failure is
+// returned as-is instead of retrying, so callers never see duplicated text.
+func (f *FallbackProvider) ChatStream(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}, onEvent func(StreamEvent)) (*LLMResponse, error) {
+	return f.chat(ctx, messages, tools, model, options, newEmitGuard(onEvent))
+}
+
+func (f *FallbackProvider) chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}, guard *emitGuard) (*LLMResponse, error) {
 	var lastErr error
-	for _, e := range f.entries {
+	for _, e := range f.Entries() {
 		if e.Provider == nil {
 			continue
 		}
-		maxAttempts := f.retryMax + 1
-		for attempt := 0; attempt < maxAttempts; attempt++ {
-			if attempt > 0 {
-				backoff := f.retryBase * time.Duration(1<<uint(attempt-1))
-				if backoff > 30*time.Second {
-					backoff = 30 * time.Second
-				}
-				if is429(lastErr) {
-					if parsed := parseRetryAfter(lastErr); parsed > 0 {
-						backoff = parsed
-					} else {
-						backoff = 60 * time.Second
-					}
-					if attempt >= 2 {
-						break
-					}
+		resp, err := f.chatEntry(ctx, e, messages, tools, model, options, guard)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if guard.Emitted() || failFast(err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// Entry returns the configured provider entry with the given name.
+func (f *FallbackProvider) Entry(name string) (ProviderEntry, bool) {
+	for _, e := range f.entries {
+		if e.Provider != nil && strings.EqualFold(e.Name, name) {
+			return e, true
+		}
+	}
+	return ProviderEntry{}, false
+}
+
+// chatEntry calls a single provider. A nil guard means no streaming. The
+// provider's circuit breaker is checked first, and images are dropped for
+// models the catalog does not mark as vision-capable. Failures are classified
+// and retried with backoff only when decide says so; the returned error is a
+// *FailoverError unless the breaker was open or ctx ended.
+func (f *FallbackProvider) chatEntry(ctx context.Context, e ProviderEntry, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}, guard *emitGuard) (*LLMResponse, error) {
+	var cb *CircuitBreaker
+	if f.breakers != nil {
+		cb = f.breakers.Get(e.Name)
+		if !cb.Allow() {
+			return nil, fmt.Errorf("provider %s: %w", e.Name, ErrCircuitOpen)
+		}
+	}
+	if HasImages(messages) && (f.catalog == nil || !f.catalog.SupportsVision(e.Name, model)) {
+		messages = WithoutImages(messages)
+	}
+	var lastErr error
+	var lastFE *FailoverError
+	maxAttempts := f.retryMax + 1
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			backoff := f.retryBase * time.Duration(1<<uint(attempt-1))
+			if backoff > maxRetryWait {
+				backoff = maxRetryWait
+			}
+			if lastFE.RetryAfter > 0 {
+				backoff = lastFE.RetryAfter
+			}
+			log.Printf("LLM %s waiting %v before retry", e.Name, backoff)
+			select {
+			case <-ctx.Done():
+				if cb != nil {
+					cb.Abort()
 				}
-				log.Printf("LLM %s rate limited, waiting %v before retry", e.Name, backoff)
-				select {
-				case <-ctx.Done():
-					return nil, ctx.Err()
-				case <-time.After(backoff):
+				return nil, ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+		start := time.Now()
+		resp, err := guard.call(ctx, e.Provider, messages, tools, model, options)
+		if f.stats != nil && ctx.Err() == nil {
+			f.stats.Record(e.Name, time.Since(start), err)
+		}
+		if err == nil {
+			if cb != nil {
+				cb.Success()
+			}
+			if resp.Provider == "" {
+				resp.Provider = e.Name
+			}
+			if resp.Model == "" {
+				resp.Model = model
+				if resp.Model == "" {
+					resp.Model = e.Provider.GetDefaultModel()
 				}
 			}
-			resp, err := e.Provider.Chat(ctx, messages, tools, model, options)
-			if err == nil {
-				return resp, nil
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			if cb != nil {
+				cb.Abort()
 			}
-			lastErr = err
-			log.Printf("LLM %s attempt %d failed: %v", e.Name, attempt+1, err)
+			return nil, ctx.Err()
+		}
+		lastFE = ClassifyError(e.Name, model, err)
+		lastErr = lastFE
+		log.Printf("LLM %s attempt %d failed (%s): %v", e.Name, attempt+1, lastFE.Reason, err)
+		if cb != nil && cb.Failure(lastFE) {
+			log.Printf("LLM %s circuit breaker open", e.Name)
+			break
+		}
+		if decide(lastFE) != actionRetry || guard.Emitted() {
+			break
 		}
 	}
 	return nil, lastErr
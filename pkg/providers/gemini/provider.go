@@ -43,13 +43,46 @@ func normalizeModel(model string) string {
 	return model
 }
 
+// resolveModel strips a provider prefix and applies the default model.
+func (p *Provider) resolveModel(model string) string {
+	if model = normalizeModel(model); model == "" {
+		return p.defaultModel
+	}
+	return model
+}
+
 // Chat sends a request to Gemini generateContent API.
 func (p *Provider) Chat(ctx context.Context, messages []types.Message, tools []types.ToolDefinition, model string, options map[string]interface{}) (*types.LLMResponse, error) {
+	req, err := p.newRequest(ctx, messages, model, options, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, types.NewRequestError("gemini", p.resolveModel(model), err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, types.NewHTTPError("gemini", p.resolveModel(model), resp, body)
+	}
+
+	return parseResponse(body)
+}
+
+// newRequest builds a generateContent (or streamGenerateContent with SSE) request.
+func (p *Provider) newRequest(ctx context.Context, messages []types.Message, model string, options map[string]interface{}, stream bool) (*http.Request, error) {
 	if p.apiKey == "" {
-		return nil, fmt.Errorf("gemini: API key not configured")
+		return nil, types.NewAuthError("gemini", p.resolveModel(model), fmt.Errorf("gemini: API key not configured"))
 	}
 
-	model = normalizeModel(model)
+	model = p.resolveModel(model)
 	maxTokens := 2048
 	if mt, ok := asInt(options["max_tokens"]); ok && mt > 0 {
 		maxTokens = mt
@@ -60,7 +93,11 @@ func (p *Provider) Chat(ctx context.Context, messages []types.Message, tools []t
 	var systemInstruction string
 	for _, m := range messages {
 		if m.Role == "system" {
-			systemInstruction = m.Content
+			// Multiple system messages (prompt + running summary) are joined
+			if systemInstruction != "" {
+				systemInstruction += "\n\n"
+			}
+			systemInstruction += m.Content
 			continue
 		}
 		if m.Role == "tool" {
@@ -72,7 +109,7 @@ func (p *Provider) Chat(ctx context.Context, messages []types.Message, tools []t
 		}
 		contents = append(contents, map[string]interface{}{
 			"role": role,
-			"parts": []map[string]interface{}{{"text": m.Content}},
+			"parts": contentParts(m),
 		})
 	}
 
@@ -94,29 +131,35 @@ func (p *Provider) Chat(ctx context.Context, messages []types.Message, tools []t
 	}
 
 	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s", baseURL, model, p.apiKey)
+	if stream {
+		url = fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse&key=%s", baseURL, model, p.apiKey)
+	}
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
 
-	resp, err := p.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+// contentParts converts a message to text and inlineData parts. Unreadable
+// images are noted in the text.
+func contentParts(m types.Message) []map[string]interface{} {
+	text := m.Content
+	var images []map[string]interface{}
+	for _, img := range m.Images {
+		data, err := img.Base64()
+		if err != nil {
+			text += fmt.Sprintf("\n[image unavailable: %v]", err)
+			continue
+		}
+		images = append(images, map[string]interface{}{
+			"inlineData": map[string]string{"mimeType": img.MimeType, "data": data},
+		})
 	}
-
-	return parseResponse(body)
+	parts := []map[string]interface{}{{"text": text}}
+	return append(parts, images...)
 }
 
 func parseResponse(body []byte) (*types.LLMResponse, error) {
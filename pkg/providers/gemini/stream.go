@@ -0,0 +1,62 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sypherexx/sypher-mini/pkg/providers/types"
+)
+
+// ChatStream sends a streamGenerateContent request (SSE) and calls onEvent for each text delta.
+func (p *Provider) ChatStream(ctx context.Context, messages []types.Message, tools []types.ToolDefinition, model string, options map[string]interface{}, onEvent func(types.StreamEvent)) (*types.LLMResponse, error) {
+	req, err := p.newRequest(ctx, messages, model, options, true)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, types.NewRequestError("gemini", p.resolveModel(model), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, types.NewHTTPError("gemini", p.resolveModel(model), resp, body)
+	}
+	return parseStream(resp.Body, onEvent)
+}
+
+// parseStream reads SSE chunks; each chunk is a partial generateContent response.
+func parseStream(r io.Reader, onEvent func(types.StreamEvent)) (*types.LLMResponse, error) {
+	var acc types.StreamAccumulator
+	var parseErr error
+	err := types.ReadSSE(r, func(data string) bool {
+		chunk, err := parseResponse([]byte(data))
+		if err != nil {
+			parseErr = err
+			return false
+		}
+		if chunk.Content != "" {
+			ev := types.StreamEvent{Delta: chunk.Content}
+			acc.Add(ev)
+			onEvent(ev)
+		}
+		if chunk.FinishReason != "" {
+			acc.FinishReason = chunk.FinishReason
+		}
+		if chunk.Usage != nil {
+			acc.Usage = chunk.Usage
+		}
+		return true
+	})
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read stream: %w", err)
+	}
+	return acc.Response(), nil
+}
@@ -0,0 +1,174 @@
+package local
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/sypherexx/sypher-mini/pkg/providers/types"
+)
+
+// ollamaMessage is a chat message in Ollama /api/chat format. Tool call
+// arguments are JSON objects, not strings, and calls carry no IDs. Images are
+// base64 strings.
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Images    []string         `json:"images,omitempty"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	} `json:"function"`
+}
+
+// ollamaResponse is a /api/chat response, or one NDJSON line of a stream.
+type ollamaResponse struct {
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+// toOllamaMessages converts messages to Ollama format. Tool results are
+// labelled with the name of the call they answer.
+func toOllamaMessages(messages []types.Message) []ollamaMessage {
+	names := make(map[string]string)
+	out := make([]ollamaMessage, 0, len(messages))
+	for _, m := range messages {
+		om := ollamaMessage{Role: m.Role, Content: m.Content}
+		for _, img := range m.Images {
+			data, err := img.Base64()
+			if err != nil {
+				om.Content += fmt.Sprintf("\n[image unavailable: %v]", err)
+				continue
+			}
+			om.Images = append(om.Images, data)
+		}
+		for _, tc := range m.ToolCalls {
+			names[tc.ID] = tc.Name
+			var call ollamaToolCall
+			call.Function.Name = tc.Name
+			call.Function.Arguments = tc.Arguments
+			if call.Function.Arguments == nil {
+				call.Function.Arguments = map[string]interface{}{}
+			}
+			om.ToolCalls = append(om.ToolCalls, call)
+		}
+		if m.Role == "tool" {
+			om.ToolName = names[m.ToolCallID]
+		}
+		out = append(out, om)
+	}
+	return out
+}
+
+// ollamaChat calls /api/chat. With onEvent set the response is streamed as NDJSON.
+func (p *Provider) ollamaChat(ctx context.Context, messages []types.Message, tools []types.ToolDefinition, model string, options map[string]interface{}, onEvent func(types.StreamEvent)) (*types.LLMResponse, error) {
+	requestBody := map[string]interface{}{
+		"model":    model,
+		"messages": toOllamaMessages(messages),
+		"stream":   onEvent != nil,
+	}
+	if len(tools) > 0 {
+		requestBody["tools"] = tools
+	}
+	opts := map[string]interface{}{}
+	if v, ok := options["max_tokens"]; ok {
+		opts["num_predict"] = v
+	}
+	if v, ok := options["temperature"]; ok {
+		opts["temperature"] = v
+	}
+	if len(opts) > 0 {
+		requestBody["options"] = opts
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", p.apiBase+"/api/chat", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, types.NewRequestError(p.backend, model, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, types.NewHTTPError(p.backend, model, resp, body)
+	}
+
+	var acc types.StreamAccumulator
+	calls := 0
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var chunk ollamaResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return nil, fmt.Errorf("unmarshal response: %w", err)
+		}
+		if chunk.Error != "" {
+			return nil, fmt.Errorf("API error: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			ev := types.StreamEvent{Delta: chunk.Message.Content}
+			acc.Add(ev)
+			if onEvent != nil {
+				onEvent(ev)
+			}
+		}
+		for _, tc := range chunk.Message.ToolCalls {
+			args, _ := json.Marshal(tc.Function.Arguments)
+			ev := types.StreamEvent{ToolCall: &types.ToolCallDelta{
+				Index:     calls,
+				ID:        "call_" + uuid.New().String(),
+				Name:      tc.Function.Name,
+				Arguments: string(args),
+			}}
+			calls++
+			acc.Add(ev)
+			if onEvent != nil {
+				onEvent(ev)
+			}
+		}
+		if chunk.Done {
+			acc.FinishReason = chunk.DoneReason
+			acc.Usage = &types.UsageInfo{
+				PromptTokens:     chunk.PromptEvalCount,
+				CompletionTokens: chunk.EvalCount,
+				TotalTokens:      chunk.PromptEvalCount + chunk.EvalCount,
+			}
+			break
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	out := acc.Response()
+	if len(out.ToolCalls) > 0 {
+		out.FinishReason = "tool_calls"
+	}
+	return out, nil
+}
@@ -0,0 +1,109 @@
+package local
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sypherexx/sypher-mini/pkg/providers/types"
+)
+
+// promptToolMessages rewrites messages for a model without native tool calling:
+// the tools are described in the system prompt, earlier tool calls become JSON
+// in assistant text, and tool results become user messages.
+func promptToolMessages(messages []types.Message, tools []types.ToolDefinition) []types.Message {
+	out := make([]types.Message, 0, len(messages)+1)
+	prompt := toolPrompt(tools)
+	if len(messages) == 0 || messages[0].Role != "system" {
+		out = append(out, types.Message{Role: "system", Content: prompt})
+	}
+	names := make(map[string]string)
+	for i, m := range messages {
+		switch {
+		case i == 0 && m.Role == "system":
+			out = append(out, types.Message{Role: "system", Content: m.Content + "\n\n" + prompt})
+		case m.Role == "assistant" && len(m.ToolCalls) > 0:
+			parts := []string{}
+			if strings.TrimSpace(m.Content) != "" {
+				parts = append(parts, m.Content)
+			}
+			for _, tc := range m.ToolCalls {
+				names[tc.ID] = tc.Name
+				parts = append(parts, formatPromptToolCall(tc))
+			}
+			out = append(out, types.Message{Role: "assistant", Content: strings.Join(parts, "\n")})
+		case m.Role == "tool":
+			out = append(out, types.Message{Role: "user", Content: fmt.Sprintf("Tool result (%s):\n%s", names[m.ToolCallID], m.Content)})
+		default:
+			out = append(out, types.Message{Role: m.Role, Content: m.Content, Images: m.Images})
+		}
+	}
+	return out
+}
+
+func toolPrompt(tools []types.ToolDefinition) string {
+	var b strings.Builder
+	b.WriteString("You can call tools. To call a tool, reply with only a JSON object of this form and no other text:\n")
+	b.WriteString(`{"tool_call": {"name": "<tool name>", "arguments": {<arguments>}}}`)
+	b.WriteString("\nThe tool result will be sent back to you. When you do not need a tool, reply normally.\n\nAvailable tools:\n")
+	for _, t := range tools {
+		params, _ := json.Marshal(t.Function.Parameters)
+		fmt.Fprintf(&b, "- %s: %s\n  parameters: %s\n", t.Function.Name, t.Function.Description, params)
+	}
+	return b.String()
+}
+
+func formatPromptToolCall(tc types.ToolCall) string {
+	args := tc.Arguments
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"tool_call": map[string]interface{}{"name": tc.Name, "arguments": args},
+	})
+	return string(data)
+}
+
+// parsePromptToolCalls extracts {"tool_call": {...}} objects from a reply,
+// including ones wrapped in code fences. It returns the calls and the
+// remaining text.
+func parsePromptToolCalls(content string) ([]types.ToolCall, string) {
+	var calls []types.ToolCall
+	rest := content
+	for {
+		idx := strings.Index(rest, `"tool_call"`)
+		if idx < 0 {
+			break
+		}
+		start := strings.LastIndex(rest[:idx], "{")
+		if start < 0 {
+			break
+		}
+		dec := json.NewDecoder(strings.NewReader(rest[start:]))
+		var v struct {
+			ToolCall *struct {
+				Name      string                 `json:"name"`
+				Arguments map[string]interface{} `json:"arguments"`
+			} `json:"tool_call"`
+		}
+		if err := dec.Decode(&v); err != nil || v.ToolCall == nil || v.ToolCall.Name == "" {
+			break
+		}
+		args := v.ToolCall.Arguments
+		if args == nil {
+			args = map[string]interface{}{}
+		}
+		calls = append(calls, types.ToolCall{
+			ID:        "call_" + uuid.New().String(),
+			Name:      v.ToolCall.Name,
+			Arguments: args,
+		})
+		rest = rest[:start] + rest[start+int(dec.InputOffset()):]
+	}
+	if len(calls) == 0 {
+		return nil, content
+	}
+	rest = strings.NewReplacer("```json", "", "```", "").Replace(rest)
+	return calls, strings.TrimSpace(rest)
+}
@@ -0,0 +1,173 @@
+package local
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sypherexx/sypher-mini/pkg/providers/openai_compat"
+	"github.com/sypherexx/sypher-mini/pkg/providers/types"
+)
+
+// Backends.
+const (
+	BackendOllama   = "ollama"   // Ollama native /api/chat
+	BackendLlamaCpp = "llamacpp" // llama.cpp server (OpenAI-compatible /v1/chat/completions)
+)
+
+// Tool calling modes (providers.<backend>.tools).
+const (
+	ToolsNative = "native" // send tool definitions to the server
+	ToolsPrompt = "prompt" // describe tools in the system prompt and parse JSON replies
+	ToolsAuto   = "auto"   // native, switching to prompt for models that reject tools
+)
+
+// Provider talks to a local model server. It needs no API key.
+type Provider struct {
+	backend      string
+	apiBase      string
+	defaultModel string
+	toolMode     string
+	httpClient   *http.Client
+	compat       *openai_compat.Provider // llamacpp only
+
+	mu         sync.Mutex
+	promptOnly map[string]bool // auto mode: models that rejected native tools
+}
+
+// New creates a local provider for backend ("ollama" or "llamacpp"). Empty
+// apiBase and defaultModel use the backend defaults; apiKey is only sent to
+// llama.cpp servers started with --api-key.
+func New(backend, apiBase, apiKey, defaultModel, toolMode string) *Provider {
+	backend = strings.ToLower(backend)
+	apiBase = strings.TrimRight(apiBase, "/")
+	if apiBase == "" {
+		if backend == BackendLlamaCpp {
+			apiBase = "http://localhost:8080"
+		} else {
+			apiBase = "http://localhost:11434"
+		}
+	}
+	if defaultModel == "" {
+		if backend == BackendLlamaCpp {
+			defaultModel = "default"
+		} else {
+			defaultModel = "llama3.1"
+		}
+	}
+	switch toolMode {
+	case ToolsNative, ToolsPrompt:
+	default:
+		toolMode = ToolsAuto
+	}
+	p := &Provider{
+		backend:      backend,
+		apiBase:      apiBase,
+		defaultModel: defaultModel,
+		toolMode:     toolMode,
+		// Local models can be slow on CPU
+		httpClient: &http.Client{Timeout: 300 * time.Second},
+		promptOnly: make(map[string]bool),
+	}
+	if backend == BackendLlamaCpp {
+		if apiKey == "" {
+			apiKey = "no-key"
+		}
+		base := apiBase
+		if !strings.HasSuffix(base, "/v1") {
+			base += "/v1"
+		}
+		p.compat = openai_compat.New(BackendLlamaCpp, apiKey, base, defaultModel)
+	}
+	return p
+}
+
+// Chat sends a chat request to the local server.
+func (p *Provider) Chat(ctx context.Context, messages []types.Message, tools []types.ToolDefinition, model string, options map[string]interface{}) (*types.LLMResponse, error) {
+	return p.chat(ctx, messages, tools, model, options, nil)
+}
+
+// ChatStream is Chat with streaming. In prompt tool mode the reply is only
+// emitted once it is known not to be a tool call.
+func (p *Provider) ChatStream(ctx context.Context, messages []types.Message, tools []types.ToolDefinition, model string, options map[string]interface{}, onEvent func(types.StreamEvent)) (*types.LLMResponse, error) {
+	return p.chat(ctx, messages, tools, model, options, onEvent)
+}
+
+// GetDefaultModel returns the default model.
+func (p *Provider) GetDefaultModel() string {
+	return p.defaultModel
+}
+
+func (p *Provider) chat(ctx context.Context, messages []types.Message, tools []types.ToolDefinition, model string, options map[string]interface{}, onEvent func(types.StreamEvent)) (*types.LLMResponse, error) {
+	model = p.normalizeModel(model)
+	if len(tools) > 0 && p.usePromptTools(model) {
+		return p.chatPromptTools(ctx, messages, tools, model, options, onEvent)
+	}
+	resp, err := p.chatNative(ctx, messages, tools, model, options, onEvent)
+	if err != nil && len(tools) > 0 && p.toolMode == ToolsAuto && isToolsUnsupported(err) {
+		p.mu.Lock()
+		p.promptOnly[model] = true
+		p.mu.Unlock()
+		return p.chatPromptTools(ctx, messages, tools, model, options, onEvent)
+	}
+	return resp, err
+}
+
+// chatNative sends the request in the backend's own format.
+func (p *Provider) chatNative(ctx context.Context, messages []types.Message, tools []types.ToolDefinition, model string, options map[string]interface{}, onEvent func(types.StreamEvent)) (*types.LLMResponse, error) {
+	if p.compat != nil {
+		if onEvent != nil {
+			return p.compat.ChatStream(ctx, messages, tools, model, options, onEvent)
+		}
+		return p.compat.Chat(ctx, messages, tools, model, options)
+	}
+	return p.ollamaChat(ctx, messages, tools, model, options, onEvent)
+}
+
+// chatPromptTools runs a request with tools described in the prompt instead of
+// sent natively, and turns a JSON tool call in the reply into ToolCalls.
+func (p *Provider) chatPromptTools(ctx context.Context, messages []types.Message, tools []types.ToolDefinition, model string, options map[string]interface{}, onEvent func(types.StreamEvent)) (*types.LLMResponse, error) {
+	resp, err := p.chatNative(ctx, promptToolMessages(messages, tools), nil, model, options, nil)
+	if err != nil {
+		return nil, err
+	}
+	if calls, rest := parsePromptToolCalls(resp.Content); len(calls) > 0 {
+		resp.ToolCalls = calls
+		resp.Content = rest
+		resp.FinishReason = "tool_calls"
+	} else if onEvent != nil && resp.Content != "" {
+		onEvent(types.StreamEvent{Delta: resp.Content})
+	}
+	return resp, nil
+}
+
+func (p *Provider) usePromptTools(model string) bool {
+	switch p.toolMode {
+	case ToolsPrompt:
+		return true
+	case ToolsAuto:
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.promptOnly[model]
+	}
+	return false
+}
+
+// isToolsUnsupported reports whether the server rejected native tool calling
+// (Ollama: "does not support tools"; llama.cpp without --jinja).
+func isToolsUnsupported(err error) bool {
+	s := err.Error()
+	return strings.Contains(s, "does not support tools") || strings.Contains(s, "--jinja")
+}
+
+func (p *Provider) normalizeModel(model string) string {
+	if model == "" {
+		return p.defaultModel
+	}
+	if idx := strings.Index(model, "/"); idx > 0 && strings.EqualFold(model[:idx], p.backend) {
+		return model[idx+1:]
+	}
+	return model
+}
@@ -0,0 +1,152 @@
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sypherexx/sypher-mini/pkg/providers/types"
+)
+
+var execTool = []types.ToolDefinition{{
+	Type: "function",
+	Function: types.ToolFunctionDefinition{
+		Name:        "exec",
+		Description: "Run a shell command",
+		Parameters:  map[string]interface{}{"type": "object"},
+	},
+}}
+
+func TestOllamaChat_NativeTools(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		var req map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req["model"] != "qwen2.5" || req["tools"] == nil {
+			t.Errorf("unexpected request: %v", req)
+		}
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"exec","arguments":{"command":"ls"}}}]},"done":true,"done_reason":"stop","prompt_eval_count":10,"eval_count":5}`)
+	}))
+	defer srv.Close()
+
+	p := New(BackendOllama, srv.URL, "", "", ToolsNative)
+	resp, err := p.Chat(context.Background(), []types.Message{{Role: "user", Content: "list"}}, execTool, "ollama/qwen2.5", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Name != "exec" || resp.ToolCalls[0].Arguments["command"] != "ls" {
+		t.Errorf("tool call not parsed: %+v", resp.ToolCalls)
+	}
+	if resp.ToolCalls[0].ID == "" {
+		t.Error("expected generated tool call ID")
+	}
+	if resp.Usage == nil || resp.Usage.TotalTokens != 15 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+}
+
+func TestOllamaChatStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hel"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"lo"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true,"done_reason":"stop"}`)
+	}))
+	defer srv.Close()
+
+	p := New(BackendOllama, srv.URL, "", "", "")
+	var deltas []string
+	resp, err := p.ChatStream(context.Background(), []types.Message{{Role: "user", Content: "hi"}}, nil, "", nil, func(ev types.StreamEvent) {
+		deltas = append(deltas, ev.Delta)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deltas) != 2 || resp.Content != "Hello" || resp.FinishReason != "stop" {
+		t.Errorf("deltas %v, response %+v", deltas, resp)
+	}
+}
+
+func TestChat_AutoFallsBackToPromptTools(t *testing.T) {
+	var requests []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		requests = append(requests, req)
+		if req["tools"] != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"registry.ollama.ai/library/gemma:2b does not support tools"}`)
+			return
+		}
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"Sure.\n`+"```json"+`\n{\"tool_call\": {\"name\": \"exec\", \"arguments\": {\"command\": \"ls\"}}}\n`+"```"+`"},"done":true}`)
+	}))
+	defer srv.Close()
+
+	p := New(BackendOllama, srv.URL, "", "gemma:2b", ToolsAuto)
+	msgs := []types.Message{{Role: "system", Content: "You are helpful."}, {Role: "user", Content: "list files"}}
+	resp, err := p.Chat(context.Background(), msgs, execTool, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Arguments["command"] != "ls" || resp.Content != "Sure." {
+		t.Errorf("prompt tool call not parsed: %+v", resp)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected native attempt then prompt retry, got %d requests", len(requests))
+	}
+	system := requests[1]["messages"].([]interface{})[0].(map[string]interface{})["content"].(string)
+	if !strings.Contains(system, "You are helpful.") || !strings.Contains(system, "- exec: Run a shell command") {
+		t.Errorf("tools not described in system prompt: %q", system)
+	}
+
+	// The model is remembered; no second native attempt
+	requests = nil
+	if _, err := p.Chat(context.Background(), msgs, execTool, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 {
+		t.Errorf("expected prompt mode directly, got %d requests", len(requests))
+	}
+}
+
+func TestPromptToolMessages_History(t *testing.T) {
+	msgs := []types.Message{
+		{Role: "user", Content: "list"},
+		{Role: "assistant", ToolCalls: []types.ToolCall{{ID: "c1", Name: "exec", Arguments: map[string]interface{}{"command": "ls"}}}},
+		{Role: "tool", ToolCallID: "c1", Content: "a.txt"},
+	}
+	out := promptToolMessages(msgs, execTool)
+	if len(out) != 4 || out[0].Role != "system" {
+		t.Fatalf("expected system prompt prepended, got %+v", out)
+	}
+	if calls, _ := parsePromptToolCalls(out[2].Content); len(calls) != 1 || calls[0].Name != "exec" {
+		t.Errorf("assistant tool call not round-tripped: %q", out[2].Content)
+	}
+	if out[3].Role != "user" || !strings.Contains(out[3].Content, "Tool result (exec)") {
+		t.Errorf("tool result not converted: %+v", out[3])
+	}
+}
+
+func TestLlamaCpp_OpenAICompatible(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"hi"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+
+	p := New(BackendLlamaCpp, srv.URL, "", "", "")
+	resp, err := p.Chat(context.Background(), []types.Message{{Role: "user", Content: "hi"}}, nil, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "hi" {
+		t.Errorf("content = %q", resp.Content)
+	}
+}
@@ -51,15 +51,43 @@ func New(name, apiKey, apiBase, defaultModel string) *Provider {
 
 // Chat sends a chat completion request.
 func (p *Provider) Chat(ctx context.Context, messages []types.Message, tools []types.ToolDefinition, model string, options map[string]interface{}) (*types.LLMResponse, error) {
+	req, err := p.newRequest(ctx, messages, tools, model, options, false)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, types.NewRequestError(p.name, p.normalizeModel(model), err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, types.NewHTTPError(p.name, p.normalizeModel(model), resp, body)
+	}
+
+	return parseResponse(body)
+}
+
+// newRequest builds a chat completions request.
+func (p *Provider) newRequest(ctx context.Context, messages []types.Message, tools []types.ToolDefinition, model string, options map[string]interface{}, stream bool) (*http.Request, error) {
 	if p.apiKey == "" {
-		return nil, fmt.Errorf("%s: API key not configured", p.name)
+		return nil, types.NewAuthError(p.name, p.normalizeModel(model), fmt.Errorf("%s: API key not configured", p.name))
 	}
 
 	model = p.normalizeModel(model)
 
 	requestBody := map[string]interface{}{
 		"model":    model,
-		"messages": messages,
+		"messages": toAPIMessages(messages),
+	}
+	if stream {
+		requestBody["stream"] = true
 	}
 
 	if len(tools) > 0 {
@@ -86,28 +114,80 @@ func (p *Provider) Chat(ctx context.Context, messages []types.Message, tools []t
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	return req, nil
+}
 
-	resp, err := p.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("send request: %w", err)
-	}
-	defer resp.Body.Close()
+// apiMessage is a chat message in OpenAI wire format. Content is a string, or
+// a list of content parts when the message has images.
+type apiMessage struct {
+	Role       string        `json:"role"`
+	Content    interface{}   `json:"content"`
+	ToolCalls  []apiToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string        `json:"tool_call_id,omitempty"`
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
-	}
+type apiToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
+// toAPIMessages converts messages to OpenAI format: tool calls carry a function
+// object with JSON-encoded arguments.
+func toAPIMessages(messages []types.Message) []apiMessage {
+	out := make([]apiMessage, 0, len(messages))
+	for _, m := range messages {
+		am := apiMessage{Role: m.Role, Content: m.Content, ToolCallID: m.ToolCallID}
+		if len(m.Images) > 0 {
+			am.Content = contentParts(m)
+		}
+		for _, tc := range m.ToolCalls {
+			var call apiToolCall
+			call.ID = tc.ID
+			call.Type = "function"
+			call.Function.Name = tc.Name
+			args, err := json.Marshal(tc.Arguments)
+			if err != nil || tc.Arguments == nil {
+				args = []byte("{}")
+			}
+			call.Function.Arguments = string(args)
+			am.ToolCalls = append(am.ToolCalls, call)
+		}
+		out = append(out, am)
 	}
+	return out
+}
 
-	return parseResponse(body)
+// contentParts converts a message with images to text and image_url parts.
+// Images are sent inline as data URLs; unreadable ones are noted in the text.
+func contentParts(m types.Message) []map[string]interface{} {
+	text := m.Content
+	var images []map[string]interface{}
+	for _, img := range m.Images {
+		url, err := img.DataURL()
+		if err != nil {
+			text += fmt.Sprintf("\n[image unavailable: %v]", err)
+			continue
+		}
+		images = append(images, map[string]interface{}{
+			"type":      "image_url",
+			"image_url": map[string]string{"url": url},
+		})
+	}
+	parts := []map[string]interface{}{{"type": "text", "text": text}}
+	return append(parts, images...)
 }
 
 func (p *Provider) normalizeModel(model string) string {
-	// Strip provider prefix if present (e.g. "cerebras/llama-3.1-70b" -> "llama-3.1-70b")
-	if idx := strings.Index(model, "/"); idx > 0 {
+	if model == "" {
+		return p.defaultModel
+	}
+	// Strip own provider prefix (e.g. "cerebras/llama-3.1-70b" -> "llama-3.1-70b").
+	// Other slashes are part of the model ID (e.g. "meta-llama/Llama-3.3-70B").
+	if idx := strings.Index(model, "/"); idx > 0 && strings.EqualFold(model[:idx], p.name) {
 		return model[idx+1:]
 	}
 	return model
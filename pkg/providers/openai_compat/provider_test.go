@@ -0,0 +1,87 @@
+package openai_compat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sypherexx/sypher-mini/pkg/providers/types"
+)
+
+func TestChat_TypedErrors(t *testing.T) {
+	cases := []struct {
+		status int
+		header string
+		body   string
+		reason types.FailoverReason
+		retry  time.Duration
+	}{
+		{http.StatusUnauthorized, "", `{"error":{"message":"bad key"}}`, types.FailoverAuth, 0},
+		{http.StatusTooManyRequests, "7", `{"error":{"message":"slow down"}}`, types.FailoverRateLimit, 7 * time.Second},
+		{http.StatusBadRequest, "", `{"error":{"code":"context_length_exceeded"}}`, types.FailoverContextLength, 0},
+		{http.StatusBadRequest, "", `{"error":{"message":"invalid tools"}}`, types.FailoverFormat, 0},
+		{http.StatusGatewayTimeout, "", `upstream timeout`, types.FailoverTimeout, 0},
+		{http.StatusInternalServerError, "", `oops`, types.FailoverUnknown, 0},
+	}
+	for _, c := range cases {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if c.header != "" {
+				w.Header().Set("Retry-After", c.header)
+			}
+			w.WriteHeader(c.status)
+			fmt.Fprint(w, c.body)
+		}))
+		p := New("openai", "key", srv.URL, "gpt-4o-mini")
+		_, err := p.Chat(context.Background(), []types.Message{{Role: "user", Content: "hi"}}, nil, "openai/gpt-4o", nil)
+		srv.Close()
+
+		var fe *types.FailoverError
+		if !errors.As(err, &fe) {
+			t.Fatalf("status %d: expected FailoverError, got %v", c.status, err)
+		}
+		if fe.Reason != c.reason || fe.Status != c.status || fe.Provider != "openai" || fe.Model != "gpt-4o" || fe.RetryAfter != c.retry {
+			t.Errorf("status %d: got %+v", c.status, fe)
+		}
+	}
+}
+
+func TestChat_MissingKeyIsAuthError(t *testing.T) {
+	_, err := New("cerebras", "", "http://127.0.0.1:0", "llama-3.1-70b").Chat(context.Background(), nil, nil, "", nil)
+	var fe *types.FailoverError
+	if !errors.As(err, &fe) || fe.Reason != types.FailoverAuth {
+		t.Errorf("expected auth FailoverError, got %v", err)
+	}
+}
+
+func TestChat_ImageParts(t *testing.T) {
+	var got struct {
+		Messages []struct {
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"a cat"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+
+	messages := []types.Message{
+		{Role: "system", Content: "be brief"},
+		{Role: "user", Content: "what is this?", Images: []types.ImagePart{{MimeType: "image/png", Data: "aGk="}}},
+	}
+	if _, err := New("openai", "key", srv.URL, "gpt-4o").Chat(context.Background(), messages, nil, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if string(got.Messages[0].Content) != `"be brief"` {
+		t.Errorf("text message content = %s", got.Messages[0].Content)
+	}
+	want := `[{"text":"what is this?","type":"text"},{"image_url":{"url":"data:image/png;base64,aGk="},"type":"image_url"}]`
+	if string(got.Messages[1].Content) != want {
+		t.Errorf("image message content = %s", got.Messages[1].Content)
+	}
+}
@@ -0,0 +1,92 @@
+package openai_compat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/sypherexx/sypher-mini/pkg/providers/types"
+)
+
+// streamChunk is one SSE chunk of a streamed chat completion.
+type streamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *types.UsageInfo `json:"usage"`
+}
+
+// ChatStream sends a streaming chat completion request (SSE) and calls onEvent
+// for each text delta and tool call fragment.
+func (p *Provider) ChatStream(ctx context.Context, messages []types.Message, tools []types.ToolDefinition, model string, options map[string]interface{}, onEvent func(types.StreamEvent)) (*types.LLMResponse, error) {
+	req, err := p.newRequest(ctx, messages, tools, model, options, true)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, types.NewRequestError(p.name, p.normalizeModel(model), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, types.NewHTTPError(p.name, p.normalizeModel(model), resp, body)
+	}
+
+	var acc types.StreamAccumulator
+	var parseErr error
+	err = types.ReadSSE(resp.Body, func(data string) bool {
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			parseErr = fmt.Errorf("unmarshal stream chunk: %w", err)
+			return false
+		}
+		if chunk.Usage != nil {
+			acc.Usage = chunk.Usage
+		}
+		for _, c := range chunk.Choices {
+			if c.FinishReason != "" {
+				acc.FinishReason = c.FinishReason
+			}
+			if c.Delta.Content != "" {
+				ev := types.StreamEvent{Delta: c.Delta.Content}
+				acc.Add(ev)
+				onEvent(ev)
+			}
+			for _, tc := range c.Delta.ToolCalls {
+				ev := types.StreamEvent{ToolCall: &types.ToolCallDelta{
+					Index:     tc.Index,
+					ID:        tc.ID,
+					Name:      tc.Function.Name,
+					Arguments: tc.Function.Arguments,
+				}}
+				acc.Add(ev)
+				onEvent(ev)
+			}
+		}
+		return true
+	})
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read stream: %w", err)
+	}
+	return acc.Response(), nil
+}
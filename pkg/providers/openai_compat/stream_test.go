@@ -0,0 +1,47 @@
+package openai_compat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sypherexx/sypher-mini/pkg/providers/types"
+)
+
+func TestChatStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range []string{
+			`{"choices":[{"delta":{"content":"Hel"}}]}`,
+			`{"choices":[{"delta":{"content":"lo"}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"c1","function":{"name":"exec","arguments":"{\"comm"}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"and\":\"ls\"}"}}]},"finish_reason":"tool_calls"}]}`,
+			`[DONE]`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+	}))
+	defer srv.Close()
+
+	p := New("openai", "key", srv.URL, "gpt-4o-mini")
+	var deltas []string
+	resp, err := p.ChatStream(context.Background(), []types.Message{{Role: "user", Content: "hi"}}, nil, "", nil, func(ev types.StreamEvent) {
+		if ev.Delta != "" {
+			deltas = append(deltas, ev.Delta)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deltas) != 2 || resp.Content != "Hello" {
+		t.Errorf("unexpected deltas %v / content %q", deltas, resp.Content)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Name != "exec" || resp.ToolCalls[0].Arguments["command"] != "ls" {
+		t.Errorf("tool call not assembled: %+v", resp.ToolCalls)
+	}
+	if resp.FinishReason != "tool_calls" {
+		t.Errorf("finish reason = %q", resp.FinishReason)
+	}
+}
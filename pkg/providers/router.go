@@ -0,0 +1,136 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// knownProviders are the provider names accepted as a model prefix.
+var knownProviders = []string{"cerebras", "openai", "anthropic", "gemini", "ollama", "llamacpp"}
+
+// ModelRef is a parsed "provider/model" reference. Provider is empty when the
+// model has no known provider prefix.
+type ModelRef struct {
+	Provider string
+	Model    string
+}
+
+// String returns the reference in provider/model form.
+func (r ModelRef) String() string {
+	if r.Provider == "" {
+		return r.Model
+	}
+	return r.Provider + "/" + r.Model
+}
+
+// ParseModelRef splits "cerebras/llama-3.1-70b" into provider and model. A
+// prefix that is not a known provider is kept as part of the model ID.
+func ParseModelRef(s string) ModelRef {
+	s = strings.TrimSpace(s)
+	if idx := strings.Index(s, "/"); idx > 0 {
+		prefix := strings.ToLower(s[:idx])
+		for _, p := range knownProviders {
+			if prefix == p {
+				return ModelRef{Provider: p, Model: s[idx+1:]}
+			}
+		}
+	}
+	return ModelRef{Model: s}
+}
+
+// ChainProvider is implemented by providers that route an ordered model chain
+// (primary first, then fallbacks) themselves. onEvent may be nil; when set,
+// output is streamed to it.
+type ChainProvider interface {
+	ChatChain(ctx context.Context, messages []Message, tools []ToolDefinition, models []string, options map[string]interface{}, onEvent func(StreamEvent)) (*LLMResponse, error)
+}
+
+// Router sends each request to the provider named by the model prefix.
+// Unprefixed models use the routing strategy order of the fallback provider.
+type Router struct {
+	fallback *FallbackProvider
+}
+
+// NewRouter creates a model-aware router over the configured providers.
+func NewRouter(fallback *FallbackProvider) *Router {
+	return &Router{fallback: fallback}
+}
+
+// Entries returns the configured provider entries in strategy order.
+func (r *Router) Entries() []ProviderEntry {
+	return r.fallback.Entries()
+}
+
+// Chat routes a single model reference.
+func (r *Router) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	return r.ChatChain(ctx, messages, tools, []string{model}, options, nil)
+}
+
+// ChatStream routes a single model reference and streams the output.
+func (r *Router) ChatStream(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}, onEvent func(StreamEvent)) (*LLMResponse, error) {
+	return r.ChatChain(ctx, messages, tools, []string{model}, options, onEvent)
+}
+
+// ChatChain tries each model reference in order. A prefixed reference goes only
+// to its provider; references to unconfigured providers are skipped. When every
+// reference is skipped, the strategy order is used with each provider's default model.
+// After streamed output has been emitted, a failure ends the chain.
+func (r *Router) ChatChain(ctx context.Context, messages []Message, tools []ToolDefinition, models []string, options map[string]interface{}, onEvent func(StreamEvent)) (*LLMResponse, error) {
+	guard := newEmitGuard(onEvent)
+	var lastErr error
+	tried := false
+	for _, m := range models {
+		ref := ParseModelRef(m)
+		var resp *LLMResponse
+		var err error
+		if ref.Provider == "" {
+			resp, err = r.fallback.chat(ctx, messages, tools, ref.Model, options, guard)
+		} else {
+			e, ok := r.fallback.Entry(ref.Provider)
+			if !ok {
+				lastErr = fmt.Errorf("provider %s not configured for model %s", ref.Provider, ref)
+				continue
+			}
+			resp, err = r.fallback.chatEntry(ctx, e, messages, tools, ref.Model, options, guard)
+		}
+		tried = true
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if guard.Emitted() {
+			return nil, err
+		}
+		lastErr = err
+		log.Printf("LLM model %s failed: %v", ref, err)
+	}
+	if !tried {
+		if len(models) > 0 {
+			log.Printf("LLM models %v not configured, using %s", models, strategyLabel(r.fallback))
+		}
+		return r.fallback.chat(ctx, messages, tools, "", options, guard)
+	}
+	return nil, lastErr
+}
+
+// GetDefaultModel returns the first provider's default model in provider/model form.
+func (r *Router) GetDefaultModel() string {
+	for _, e := range r.fallback.Entries() {
+		if e.Provider != nil {
+			return e.Name + "/" + e.Provider.GetDefaultModel()
+		}
+	}
+	return r.fallback.GetDefaultModel()
+}
+
+func strategyLabel(f *FallbackProvider) string {
+	names := make([]string, 0, len(f.entries))
+	for _, e := range f.entries {
+		names = append(names, e.Name)
+	}
+	return "provider defaults (" + strings.Join(names, ", ") + ")"
+}
@@ -0,0 +1,111 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+)
+
+// stubProvider records the models it was asked for and fails when fail is set.
+type stubProvider struct {
+	name   string
+	fail   bool
+	models []string
+	last   []Message
+}
+
+func (p *stubProvider) Chat(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	p.models = append(p.models, model)
+	p.last = messages
+	if p.fail {
+		return nil, fmt.Errorf("%s down", p.name)
+	}
+	return &LLMResponse{Content: p.name + ":" + model}, nil
+}
+
+func (p *stubProvider) GetDefaultModel() string { return p.name + "-default" }
+
+func TestParseModelRef(t *testing.T) {
+	cases := map[string]ModelRef{
+		"cerebras/llama-3.1-70b":   {Provider: "cerebras", Model: "llama-3.1-70b"},
+		"gpt-4o":                   {Model: "gpt-4o"},
+		"meta-llama/Llama-3.3-70B": {Model: "meta-llama/Llama-3.3-70B"},
+	}
+	for in, want := range cases {
+		if got := ParseModelRef(in); got != want {
+			t.Errorf("ParseModelRef(%q) = %+v, want %+v", in, got, want)
+		}
+	}
+}
+
+func TestRouter_ChatChain(t *testing.T) {
+	cerebras := &stubProvider{name: "cerebras", fail: true}
+	openai := &stubProvider{name: "openai"}
+	r := NewRouter(&FallbackProvider{entries: []ProviderEntry{
+		{Provider: cerebras, Name: "cerebras"},
+		{Provider: openai, Name: "openai"},
+	}})
+
+	resp, err := r.ChatChain(context.Background(), nil, nil, []string{"anthropic/claude", "cerebras/llama-3.1-70b", "openai/gpt-4o"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "openai:gpt-4o" {
+		t.Errorf("expected openai fallback, got %q", resp.Content)
+	}
+	if len(cerebras.models) != 1 || cerebras.models[0] != "llama-3.1-70b" {
+		t.Errorf("cerebras should get its own model only, got %v", cerebras.models)
+	}
+	if len(openai.models) != 1 || openai.models[0] != "gpt-4o" {
+		t.Errorf("openai should get gpt-4o only, got %v", openai.models)
+	}
+}
+
+func TestRouter_UnconfiguredUsesStrategyDefaults(t *testing.T) {
+	openai := &stubProvider{name: "openai"}
+	r := NewRouter(&FallbackProvider{entries: []ProviderEntry{{Provider: openai, Name: "openai"}}})
+
+	resp, err := r.Chat(context.Background(), nil, nil, "cerebras/llama-3.1-70b", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "openai:" {
+		t.Errorf("expected openai with its default model, got %q", resp.Content)
+	}
+}
+
+func TestFallbackProvider_LocalOnly(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Providers.OpenAI.APIKey = "key"
+	cfg.Providers.Ollama.Enabled = true
+	fb := NewFallbackProvider(cfg).LocalOnly()
+	entries := fb.Entries()
+	if len(entries) != 1 || entries[0].Name != "ollama" {
+		t.Errorf("expected only ollama, got %v", names(entries))
+	}
+
+	cfg.Providers.Ollama.APIBase = "http://gpu.lan:11434"
+	cfg.Providers.LlamaCpp = config.LocalProviderConfig{Enabled: true, APIBase: "http://10.0.0.5:8080", Trusted: true}
+	entries = NewFallbackProvider(cfg).LocalOnly().Entries()
+	if len(entries) != 1 || entries[0].Name != "llamacpp" {
+		t.Errorf("remote server should only stay when trusted, got %v", names(entries))
+	}
+	for base, want := range map[string]bool{
+		"":                        true,
+		"http://localhost:11434":  true,
+		"http://127.0.0.2:8080":   true,
+		"http://[::1]:8080":       true,
+		"http://192.168.1.9:8080": false,
+		"https://ollama.example":  false,
+		"localhost:11434":         false,
+	} {
+		if got := IsLocal(base); got != want {
+			t.Errorf("IsLocal(%q) = %v, want %v", base, got, want)
+		}
+	}
+	if ref := ParseModelRef("ollama/qwen2.5:7b"); ref.Provider != "ollama" || ref.Model != "qwen2.5:7b" {
+		t.Errorf("ParseModelRef = %+v", ref)
+	}
+}
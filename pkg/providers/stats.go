@@ -0,0 +1,104 @@
+package providers
+
+import (
+	"sync"
+	"time"
+)
+
+// statsAlpha is the EWMA weight of the newest sample.
+const statsAlpha = 0.3
+
+// minStatsSamples is the number of samples before observed latency overrides the catalog tier.
+const minStatsSamples = 3
+
+// ProviderStats tracks observed latency and error rate per provider.
+type ProviderStats struct {
+	mu    sync.RWMutex
+	stats map[string]*providerStat
+}
+
+type providerStat struct {
+	latency   float64 // EWMA seconds, successful calls only
+	errorRate float64 // EWMA of 0/1
+	samples   int
+}
+
+// StatSnapshot is a read-only view of one provider's stats.
+type StatSnapshot struct {
+	LatencySec float64 `json:"latency_sec"`
+	ErrorRate  float64 `json:"error_rate"`
+	Samples    int     `json:"samples"`
+}
+
+// NewProviderStats creates an empty stats tracker.
+func NewProviderStats() *ProviderStats {
+	return &ProviderStats{stats: make(map[string]*providerStat)}
+}
+
+// Record adds one call outcome for the provider.
+func (s *ProviderStats) Record(name string, latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.stats[name]
+	if !ok {
+		st = &providerStat{}
+		s.stats[name] = st
+	}
+	failed := 0.0
+	if err != nil {
+		failed = 1
+	}
+	if st.samples == 0 {
+		st.errorRate = failed
+	} else {
+		st.errorRate = statsAlpha*failed + (1-statsAlpha)*st.errorRate
+	}
+	if err == nil {
+		sec := latency.Seconds()
+		if st.latency == 0 {
+			st.latency = sec
+		} else {
+			st.latency = statsAlpha*sec + (1-statsAlpha)*st.latency
+		}
+	}
+	st.samples++
+}
+
+// ErrorRate returns the smoothed error rate (0..1) for the provider.
+func (s *ProviderStats) ErrorRate(name string) float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if st, ok := s.stats[name]; ok && st.samples >= minStatsSamples {
+		return st.errorRate
+	}
+	return 0
+}
+
+// LatencyTier maps observed latency to a tier, or returns def without enough samples.
+func (s *ProviderStats) LatencyTier(name string, def Tier) Tier {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	st, ok := s.stats[name]
+	if !ok || st.samples < minStatsSamples || st.latency == 0 {
+		return def
+	}
+	switch {
+	case st.latency < 2:
+		return 1
+	case st.latency < 6:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Snapshot returns stats for all providers.
+func (s *ProviderStats) Snapshot() map[string]StatSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]StatSnapshot, len(s.stats))
+	for name, st := range s.stats {
+		out[name] = StatSnapshot{LatencySec: st.latency, ErrorRate: st.errorRate, Samples: st.samples}
+	}
+	return out
+}
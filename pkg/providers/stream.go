@@ -0,0 +1,45 @@
+package providers
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// emitGuard forwards stream events and remembers whether any text was emitted.
+// A nil guard makes blocking Chat calls.
+type emitGuard struct {
+	onEvent func(StreamEvent)
+	emitted atomic.Bool
+}
+
+func newEmitGuard(onEvent func(StreamEvent)) *emitGuard {
+	if onEvent == nil {
+		return nil
+	}
+	return &emitGuard{onEvent: onEvent}
+}
+
+// Emitted reports whether output has reached the caller.
+func (g *emitGuard) Emitted() bool {
+	return g != nil && g.emitted.Load()
+}
+
+func (g *emitGuard) emit(ev StreamEvent) {
+	g.emitted.Store(true)
+	g.onEvent(ev)
+}
+
+// call runs one request, streaming when both the guard and the provider allow it.
+func (g *emitGuard) call(ctx context.Context, p LLMProvider, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}) (*LLMResponse, error) {
+	if g == nil {
+		return p.Chat(ctx, messages, tools, model, options)
+	}
+	if sp, ok := p.(StreamingProvider); ok {
+		return sp.ChatStream(ctx, messages, tools, model, options, g.emit)
+	}
+	resp, err := p.Chat(ctx, messages, tools, model, options)
+	if err == nil && resp.Content != "" {
+		g.emit(StreamEvent{Delta: resp.Content})
+	}
+	return resp, err
+}
@@ -1,52 +1,49 @@
 package providers
 
 import (
-	"fmt"
-
 	"github.com/sypherexx/sypher-mini/pkg/providers/types"
 )
 
 // Re-export types for backward compatibility.
 type (
 	Message             = types.Message
+	ImagePart           = types.ImagePart
 	ToolCall            = types.ToolCall
 	LLMResponse         = types.LLMResponse
 	ToolDefinition      = types.ToolDefinition
 	ToolFunctionDefinition = types.ToolFunctionDefinition
 	UsageInfo           = types.UsageInfo
 	LLMProvider         = types.LLMProvider
+	StreamEvent         = types.StreamEvent
+	ToolCallDelta       = types.ToolCallDelta
+	StreamingProvider   = types.StreamingProvider
+	FailoverReason      = types.FailoverReason
+	FailoverError       = types.FailoverError
 )
 
 // ProviderEntry holds a provider and its priority.
 type ProviderEntry struct {
 	Provider LLMProvider
 	Name     string
+	Local    bool // model server on this machine (see IsLocal); kept in safe mode
 }
 
-// FailoverReason classifies why an LLM request failed.
-type FailoverReason string
-
+// FailoverReason values, re-exported from types.
 const (
-	FailoverAuth      FailoverReason = "auth"
-	FailoverRateLimit FailoverReason = "rate_limit"
-	FailoverTimeout   FailoverReason = "timeout"
-	FailoverFormat    FailoverReason = "format"
-	FailoverUnknown   FailoverReason = "unknown"
+	FailoverAuth          = types.FailoverAuth
+	FailoverRateLimit     = types.FailoverRateLimit
+	FailoverTimeout       = types.FailoverTimeout
+	FailoverFormat        = types.FailoverFormat
+	FailoverContextLength = types.FailoverContextLength
+	FailoverUnknown       = types.FailoverUnknown
 )
 
-// FailoverError wraps an LLM provider error.
-type FailoverError struct {
-	Reason   FailoverReason
-	Provider string
-	Model    string
-	Status   int
-	Wrapped  error
-}
-
-func (e *FailoverError) Error() string {
-	return fmt.Sprintf("failover(%s): provider=%s model=%s: %v", e.Reason, e.Provider, e.Model, e.Wrapped)
+// HasImages reports whether any message carries images.
+func HasImages(messages []Message) bool {
+	return types.HasImages(messages)
 }
 
-func (e *FailoverError) Unwrap() error {
-	return e.Wrapped
+// WithoutImages returns messages with images replaced by a text note.
+func WithoutImages(messages []Message) []Message {
+	return types.WithoutImages(messages)
 }
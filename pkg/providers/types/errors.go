@@ -0,0 +1,147 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FailoverReason classifies why an LLM request failed.
+type FailoverReason string
+
+const (
+	FailoverAuth          FailoverReason = "auth"
+	FailoverRateLimit     FailoverReason = "rate_limit"
+	FailoverTimeout       FailoverReason = "timeout"
+	FailoverFormat        FailoverReason = "format"
+	FailoverContextLength FailoverReason = "context_length"
+	FailoverUnknown       FailoverReason = "unknown"
+)
+
+// FailoverError wraps an LLM provider error.
+type FailoverError struct {
+	Reason     FailoverReason
+	Provider   string
+	Model      string
+	Status     int           // HTTP status, 0 if no response
+	RetryAfter time.Duration // server-suggested wait, if any
+	Wrapped    error
+}
+
+func (e *FailoverError) Error() string {
+	return fmt.Sprintf("failover(%s): provider=%s model=%s: %v", e.Reason, e.Provider, e.Model, e.Wrapped)
+}
+
+func (e *FailoverError) Unwrap() error {
+	return e.Wrapped
+}
+
+// NewHTTPError classifies a non-2xx API response. Retry-After is taken from
+// the header, or from a "retry in Xs" hint in the body.
+func NewHTTPError(provider, model string, resp *http.Response, body []byte) *FailoverError {
+	retryAfter := ParseRetryAfterHeader(resp.Header.Get("Retry-After"))
+	if retryAfter == 0 {
+		retryAfter = ParseRetryAfterBody(string(body))
+	}
+	return &FailoverError{
+		Reason:     ReasonForStatus(resp.StatusCode, string(body)),
+		Provider:   provider,
+		Model:      model,
+		Status:     resp.StatusCode,
+		RetryAfter: retryAfter,
+		Wrapped:    fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body)),
+	}
+}
+
+// NewRequestError classifies an error sending a request (no response).
+func NewRequestError(provider, model string, err error) *FailoverError {
+	reason := FailoverUnknown
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		reason = FailoverTimeout
+	}
+	return &FailoverError{Reason: reason, Provider: provider, Model: model, Wrapped: fmt.Errorf("send request: %w", err)}
+}
+
+// NewAuthError reports a missing or rejected credential.
+func NewAuthError(provider, model string, err error) *FailoverError {
+	return &FailoverError{Reason: FailoverAuth, Provider: provider, Model: model, Wrapped: err}
+}
+
+// contextLengthMarkers are body fragments providers use for oversized prompts.
+var contextLengthMarkers = []string{
+	"context_length_exceeded",
+	"maximum context length",
+	"context window",
+	"prompt is too long",
+	"too many tokens",
+	"input token count",
+}
+
+// ReasonForStatus maps an HTTP status and error body to a FailoverReason.
+func ReasonForStatus(status int, body string) FailoverReason {
+	lower := strings.ToLower(body)
+	if status == http.StatusRequestEntityTooLarge {
+		return FailoverContextLength
+	}
+	if status == http.StatusBadRequest {
+		for _, m := range contextLengthMarkers {
+			if strings.Contains(lower, m) {
+				return FailoverContextLength
+			}
+		}
+	}
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return FailoverAuth
+	case status == http.StatusTooManyRequests || strings.Contains(body, "RESOURCE_EXHAUSTED"):
+		return FailoverRateLimit
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return FailoverTimeout
+	case status == http.StatusBadRequest || status == http.StatusNotFound || status == http.StatusUnprocessableEntity:
+		return FailoverFormat
+	}
+	return FailoverUnknown
+}
+
+// ParseRetryAfterHeader parses a Retry-After header (seconds or HTTP date).
+func ParseRetryAfterHeader(v string) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	if sec, err := strconv.ParseFloat(v, 64); err == nil {
+		if sec <= 0 {
+			return 0
+		}
+		return time.Duration(sec * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// retryInRegex matches "retry in X.XXXs" or "retry in Xs" in API error bodies.
+var retryInRegex = regexp.MustCompile(`[Rr]etry in (\d+(?:\.\d+)?)s`)
+
+// ParseRetryAfterBody extracts a "retry in Xs" hint from an error body.
+func ParseRetryAfterBody(body string) time.Duration {
+	m := retryInRegex.FindStringSubmatch(body)
+	if len(m) < 2 {
+		return 0
+	}
+	sec, _ := strconv.ParseFloat(m[1], 64)
+	if sec <= 0 {
+		return 0
+	}
+	return time.Duration(sec * float64(time.Second))
+}
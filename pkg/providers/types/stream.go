@@ -0,0 +1,118 @@
+package types
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+)
+
+// StreamEvent is one incremental piece of a streamed response.
+type StreamEvent struct {
+	Delta    string         `json:"delta,omitempty"`     // text delta
+	ToolCall *ToolCallDelta `json:"tool_call,omitempty"` // tool call fragment
+}
+
+// ToolCallDelta is an incremental tool call fragment. Fragments with the same
+// Index belong to one call; ID and Name arrive once, Arguments is a JSON fragment.
+type ToolCallDelta struct {
+	Index     int    `json:"index"`
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// StreamingProvider is implemented by providers that can stream responses.
+// ChatStream calls onEvent for each delta and returns the complete response.
+type StreamingProvider interface {
+	ChatStream(ctx context.Context, messages []Message, tools []ToolDefinition, model string, options map[string]interface{}, onEvent func(StreamEvent)) (*LLMResponse, error)
+}
+
+// StreamAccumulator builds an LLMResponse from stream events.
+type StreamAccumulator struct {
+	content      strings.Builder
+	calls        map[int]*accCall
+	FinishReason string
+	Usage        *UsageInfo
+}
+
+type accCall struct {
+	id, name string
+	args     strings.Builder
+}
+
+// Add applies one event.
+func (a *StreamAccumulator) Add(ev StreamEvent) {
+	a.content.WriteString(ev.Delta)
+	if tc := ev.ToolCall; tc != nil {
+		if a.calls == nil {
+			a.calls = make(map[int]*accCall)
+		}
+		c, ok := a.calls[tc.Index]
+		if !ok {
+			c = &accCall{}
+			a.calls[tc.Index] = c
+		}
+		if tc.ID != "" {
+			c.id = tc.ID
+		}
+		if tc.Name != "" {
+			c.name = tc.Name
+		}
+		c.args.WriteString(tc.Arguments)
+	}
+}
+
+// Response returns the accumulated response.
+func (a *StreamAccumulator) Response() *LLMResponse {
+	idx := make([]int, 0, len(a.calls))
+	for i := range a.calls {
+		idx = append(idx, i)
+	}
+	sort.Ints(idx)
+	var calls []ToolCall
+	for _, i := range idx {
+		c := a.calls[i]
+		args := make(map[string]interface{})
+		if s := strings.TrimSpace(c.args.String()); s != "" {
+			_ = json.Unmarshal([]byte(s), &args)
+		}
+		calls = append(calls, ToolCall{ID: c.id, Name: c.name, Arguments: args})
+	}
+	finish := a.FinishReason
+	if finish == "" {
+		finish = "stop"
+	}
+	return &LLMResponse{
+		Content:      a.content.String(),
+		ToolCalls:    calls,
+		FinishReason: finish,
+		Usage:        a.Usage,
+	}
+}
+
+// ReadSSE calls onData with the payload of each "data:" line of a server-sent
+// event stream until EOF, a "[DONE]" payload, or onData returns false.
+func ReadSSE(r io.Reader, onData func(data string) bool) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "" {
+			continue
+		}
+		if data == "[DONE]" {
+			return nil
+		}
+		if !onData(data) {
+			return nil
+		}
+	}
+	return sc.Err()
+}
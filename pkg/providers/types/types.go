@@ -1,13 +1,79 @@
 package types
 
-import "context"
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"strconv"
+)
 
 // Message represents a chat message.
 type Message struct {
-	Role       string     `json:"role"`
-	Content    string     `json:"content"`
-	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
-	ToolCallID string     `json:"tool_call_id,omitempty"`
+	Role       string      `json:"role"`
+	Content    string      `json:"content"`
+	Images     []ImagePart `json:"images,omitempty"`
+	ToolCalls  []ToolCall  `json:"tool_calls,omitempty"`
+	ToolCallID string      `json:"tool_call_id,omitempty"`
+}
+
+// ImagePart is an image attached to a user message. Images are stored by path
+// and read when a request is built, so sessions stay small.
+type ImagePart struct {
+	MimeType string `json:"mime_type"`
+	Path     string `json:"path,omitempty"`
+	Data     string `json:"data,omitempty"` // base64; used instead of Path when set
+}
+
+// Base64 returns the image data base64-encoded.
+func (p ImagePart) Base64() (string, error) {
+	if p.Data != "" {
+		return p.Data, nil
+	}
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// DataURL returns the image as a data: URL.
+func (p ImagePart) DataURL() (string, error) {
+	data, err := p.Base64()
+	if err != nil {
+		return "", err
+	}
+	return "data:" + p.MimeType + ";base64," + data, nil
+}
+
+// HasImages reports whether any message carries images.
+func HasImages(messages []Message) bool {
+	for _, m := range messages {
+		if len(m.Images) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// WithoutImages returns messages with images replaced by a text note, for
+// models without vision support.
+func WithoutImages(messages []Message) []Message {
+	out := make([]Message, len(messages))
+	for i, m := range messages {
+		if len(m.Images) > 0 {
+			m.Content += "\n[" + imageCount(len(m.Images)) + " omitted: the model cannot view images]"
+			m.Images = nil
+		}
+		out[i] = m
+	}
+	return out
+}
+
+func imageCount(n int) string {
+	if n == 1 {
+		return "1 image"
+	}
+	return strconv.Itoa(n) + " images"
 }
 
 // ToolCall represents a tool invocation from the LLM.
@@ -24,6 +90,8 @@ type LLMResponse struct {
 	ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
 	FinishReason string     `json:"finish_reason"`
 	Usage        *UsageInfo `json:"usage,omitempty"`
+	Provider     string     `json:"provider,omitempty"` // provider that served the response
+	Model        string     `json:"model,omitempty"`    // model that served the response
 }
 
 // UsageInfo holds token usage.
@@ -0,0 +1,261 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	kdfPBKDF2  = "pbkdf2-sha256"
+	kdfKeyFile = "key_file"
+	// pbkdf2Iterations follows the OWASP recommendation for PBKDF2-HMAC-SHA256.
+	pbkdf2Iterations = 600000
+)
+
+// FileStore keeps secrets in a JSON map encrypted with AES-256-GCM. The key
+// is derived from Passphrase with PBKDF2-HMAC-SHA256, or, without a
+// passphrase, read from KeyFile (32 random bytes, created on first write).
+type FileStore struct {
+	Path       string
+	KeyFile    string
+	Passphrase string
+	mu         sync.Mutex
+}
+
+// fileEnvelope is the on-disk format.
+type fileEnvelope struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations,omitempty"`
+	Salt       []byte `json:"salt,omitempty"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Get returns the secret name.
+func (s *FileStore) Get(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	v, ok := m[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return v, nil
+}
+
+// Set stores the secret name.
+func (s *FileStore) Set(name, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.load()
+	if err != nil {
+		return err
+	}
+	m[name] = value
+	return s.save(m)
+}
+
+// Delete removes the secret name.
+func (s *FileStore) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := m[name]; !ok {
+		return ErrNotFound
+	}
+	delete(m, name)
+	return s.save(m)
+}
+
+// List returns the stored names.
+func (s *FileStore) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// load decrypts the file; a missing file is an empty store.
+func (s *FileStore) load() (map[string]string, error) {
+	data, err := os.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return make(map[string]string), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var env fileEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("parse secrets file: %w", err)
+	}
+	if env.Version != 1 {
+		return nil, fmt.Errorf("secrets file version %d not supported", env.Version)
+	}
+	var key []byte
+	switch env.KDF {
+	case kdfPBKDF2:
+		if s.Passphrase == "" {
+			return nil, fmt.Errorf("%s is passphrase-protected; set %s", s.Path, PassphraseEnv)
+		}
+		key = pbkdf2SHA256([]byte(s.Passphrase), env.Salt, env.Iterations, 32)
+	case kdfKeyFile:
+		if key, err = s.readKey(false); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("secrets file: unknown kdf %q", env.KDF)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, env.Nonce, env.Ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("cannot decrypt secrets file: wrong passphrase or key file")
+	}
+	m := make(map[string]string)
+	if err := json.Unmarshal(plain, &m); err != nil {
+		return nil, fmt.Errorf("parse secrets: %w", err)
+	}
+	return m, nil
+}
+
+// save encrypts m with a fresh salt and nonce and replaces the file.
+func (s *FileStore) save(m map[string]string) error {
+	plain, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	env := fileEnvelope{Version: 1}
+	var key []byte
+	if s.Passphrase != "" {
+		env.KDF, env.Iterations = kdfPBKDF2, pbkdf2Iterations
+		env.Salt = make([]byte, 16)
+		if _, err := rand.Read(env.Salt); err != nil {
+			return err
+		}
+		key = pbkdf2SHA256([]byte(s.Passphrase), env.Salt, env.Iterations, 32)
+	} else {
+		env.KDF = kdfKeyFile
+		if key, err = s.readKey(true); err != nil {
+			return err
+		}
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	env.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return err
+	}
+	env.Ciphertext = gcm.Seal(nil, env.Nonce, plain, nil)
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.Path, data)
+}
+
+// readKey reads the key file, creating it when create is set and it does
+// not exist.
+func (s *FileStore) readKey(create bool) ([]byte, error) {
+	key, err := os.ReadFile(s.KeyFile)
+	if os.IsNotExist(err) && create {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		return key, writeFileAtomic(s.KeyFile, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read key file: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key file %s: want 32 bytes, got %d", s.KeyFile, len(key))
+	}
+	return key, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// writeFileAtomic writes data with mode 0600 through a temporary file.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".secrets-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	defer os.Remove(tmp)
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// pbkdf2SHA256 is PBKDF2 (RFC 8018) with HMAC-SHA256.
+func pbkdf2SHA256(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var out []byte
+	buf := make([]byte, 4)
+	for block := uint32(1); len(out) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(buf, block)
+		prf.Write(buf)
+		u := prf.Sum(nil)
+		t := append([]byte(nil), u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		out = append(out, t...)
+	}
+	return out[:keyLen]
+}
@@ -0,0 +1,70 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPBKDF2SHA256(t *testing.T) {
+	// RFC 7914, section 11
+	want, _ := hex.DecodeString("55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783")
+	if got := pbkdf2SHA256([]byte("passwd"), []byte("salt"), 1, 64); !bytes.Equal(got, want) {
+		t.Errorf("pbkdf2 = %x", got)
+	}
+}
+
+func TestFileStore_Passphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	s := &FileStore{Path: path, Passphrase: "correct horse"}
+	if err := s.Set("github", "ghp_secret_value"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("ghp_secret_value")) || bytes.Contains(data, []byte("github")) {
+		t.Error("secrets file should not contain plaintext")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("secrets file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	if v, err := (&FileStore{Path: path, Passphrase: "correct horse"}).Get("github"); err != nil || v != "ghp_secret_value" {
+		t.Errorf("Get = %q, %v", v, err)
+	}
+	if _, err := (&FileStore{Path: path, Passphrase: "wrong"}).Get("github"); err == nil {
+		t.Error("wrong passphrase should fail")
+	}
+	if _, err := (&FileStore{Path: path}).Get("github"); err == nil {
+		t.Error("missing passphrase should fail")
+	}
+}
+
+func TestFileStore_KeyFile(t *testing.T) {
+	dir := t.TempDir()
+	s := &FileStore{Path: filepath.Join(dir, "secrets.enc"), KeyFile: filepath.Join(dir, "secrets.key")}
+	if _, err := s.Get("x"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("empty store: expected ErrNotFound, got %v", err)
+	}
+	if err := s.Set("x", "value"); err != nil {
+		t.Fatal(err)
+	}
+	if key, err := os.ReadFile(s.KeyFile); err != nil || len(key) != 32 {
+		t.Fatalf("key file not created: %v", err)
+	}
+	if v, err := s.Get("x"); err != nil || v != "value" {
+		t.Errorf("Get = %q, %v", v, err)
+	}
+	if err := os.WriteFile(s.KeyFile, bytes.Repeat([]byte{1}, 32), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("x"); err == nil {
+		t.Error("different key should fail to decrypt")
+	}
+}
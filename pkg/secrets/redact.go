@@ -0,0 +1,135 @@
+package secrets
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Placeholder replaces redacted values.
+const Placeholder = "[REDACTED]"
+
+// minRedactLen: shorter values are too likely to appear in ordinary output.
+const minRedactLen = 6
+
+// sensitiveNames mark environment variables that hold credentials.
+var sensitiveNames = []string{"KEY", "TOKEN", "SECRET", "PASSWORD", "PASSWD", "CREDENTIAL"}
+
+// Redactor replaces known secret values in text. It is safe for concurrent
+// use; values can be added while it is in use.
+type Redactor struct {
+	mu     sync.RWMutex
+	seen   map[string]bool
+	values []string // longest first, so a value containing another is replaced whole
+}
+
+// NewRedactor creates a redactor for values.
+func NewRedactor(values ...string) *Redactor {
+	r := &Redactor{seen: make(map[string]bool)}
+	r.Add(values...)
+	return r
+}
+
+// Add registers more secret values. Empty and very short values are ignored.
+func (r *Redactor) Add(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changed := false
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if len(v) < minRedactLen || r.seen[v] {
+			continue
+		}
+		r.seen[v] = true
+		r.values = append(r.values, v)
+		changed = true
+	}
+	if changed {
+		sort.Slice(r.values, func(i, j int) bool { return len(r.values[i]) > len(r.values[j]) })
+	}
+}
+
+// Redact returns s with every known value replaced by Placeholder. A nil
+// Redactor returns s unchanged.
+func (r *Redactor) Redact(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, v := range r.values {
+		if strings.Contains(s, v) {
+			s = strings.ReplaceAll(s, v, Placeholder)
+		}
+	}
+	return s
+}
+
+// Stream redacts text that arrives in chunks, such as live command output.
+// Write holds back the end of a chunk while it could be the start of a
+// secret, so a value split across two chunks is still replaced. A Stream is
+// not safe for concurrent use.
+type Stream struct {
+	r       *Redactor
+	pending string
+}
+
+// Stream returns a chunk redactor. A nil Redactor passes chunks through.
+func (r *Redactor) Stream() *Stream {
+	return &Stream{r: r}
+}
+
+// Write returns the redacted part of the text so far that can be emitted.
+func (s *Stream) Write(chunk string) string {
+	if s.r == nil {
+		return chunk
+	}
+	text := s.pending + chunk
+	keep := s.r.partialSuffix(text)
+	s.pending = text[len(text)-keep:]
+	return s.r.Redact(text[:len(text)-keep])
+}
+
+// Flush returns the redacted text still held back.
+func (s *Stream) Flush() string {
+	out := s.r.Redact(s.pending)
+	s.pending = ""
+	return out
+}
+
+// partialSuffix returns the length of the longest suffix of text that is a
+// proper prefix of a known value.
+func (r *Redactor) partialSuffix(text string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	longest := 0
+	for _, v := range r.values {
+		for n := min(len(v)-1, len(text)); n > longest; n-- {
+			if strings.HasSuffix(text, v[:n]) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
+
+// EnvValues returns the values of variables in environ ("NAME=value")
+// whose names look like credentials (API_KEY, GITHUB_TOKEN, DB_PASSWORD, ...).
+func EnvValues(environ []string) []string {
+	var out []string
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || value == "" {
+			continue
+		}
+		upper := strings.ToUpper(name)
+		for _, s := range sensitiveNames {
+			if strings.Contains(upper, s) {
+				out = append(out, value)
+				break
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,45 @@
+package secrets
+
+import "testing"
+
+func TestRedactor(t *testing.T) {
+	r := NewRedactor("sk-test-1234567890", "", "abc")
+	got := r.Redact("key=sk-test-1234567890 short=abc")
+	if got != "key="+Placeholder+" short=abc" {
+		t.Errorf("unexpected redaction: %q", got)
+	}
+	r.Add("sk-test-1234567890-extended")
+	if got := r.Redact("sk-test-1234567890-extended"); got != Placeholder {
+		t.Errorf("longer value should be replaced whole, got %q", got)
+	}
+	var nilR *Redactor
+	if nilR.Redact("x") != "x" {
+		t.Error("nil redactor should return input")
+	}
+}
+
+func TestStream(t *testing.T) {
+	r := NewRedactor("sk-test-1234567890")
+	s := r.Stream()
+	var got string
+	for _, chunk := range []string{"token: sk-te", "st-12345", "67890\nnext sk", "-other\n", "tail sk-test"} {
+		got += s.Write(chunk)
+	}
+	if got != "token: "+Placeholder+"\nnext sk-other\ntail " {
+		t.Errorf("streamed output = %q", got)
+	}
+	if rest := s.Flush(); rest != "sk-test" {
+		t.Errorf("Flush = %q", rest)
+	}
+	var nilR *Redactor
+	if out := nilR.Stream().Write("abc"); out != "abc" {
+		t.Errorf("nil redactor stream = %q", out)
+	}
+}
+
+func TestEnvValues(t *testing.T) {
+	got := EnvValues([]string{"OPENAI_API_KEY=sk-1", "PATH=/usr/bin", "GITHUB_TOKEN=ghp_x", "DB_PASSWORD=", "HOME=/root"})
+	if len(got) != 2 || got[0] != "sk-1" || got[1] != "ghp_x" {
+		t.Errorf("EnvValues = %v", got)
+	}
+}
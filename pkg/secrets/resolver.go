@@ -0,0 +1,176 @@
+// Package secrets resolves named secrets from the environment, the OS
+// keychain (Secret Service) or an encrypted file, and redacts known secret
+// values from text.
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Backend is the secrets backend type.
+type Backend string
+
+const (
+	BackendEnv           Backend = "env"
+	BackendKeychain      Backend = "keychain"       // OS keychain: Secret Service on Linux, env elsewhere
+	BackendSecretService Backend = "secret_service" // Linux Secret Service over D-Bus
+	BackendFile          Backend = "file"           // AES-GCM encrypted file
+)
+
+// RefPrefix starts a reference to a named secret in a config value,
+// e.g. "secret://openai".
+const RefPrefix = "secret://"
+
+// PassphraseEnv holds the passphrase of the file backend. Without it the
+// file is encrypted with a random key kept in the key file.
+const PassphraseEnv = "SYPHER_SECRETS_PASSPHRASE"
+
+var (
+	// ErrNotFound is returned when a secret does not exist.
+	ErrNotFound = errors.New("secret not found")
+	// ErrReadOnly is returned when writing to the env backend.
+	ErrReadOnly = errors.New("the env backend is read-only; set the environment variable instead")
+)
+
+// Store is a backend that holds named secrets.
+type Store interface {
+	Get(name string) (string, error) // ErrNotFound when missing
+	Set(name, value string) error
+	Delete(name string) error // ErrNotFound when missing
+	List() ([]string, error)
+}
+
+// Options configures the backends. Empty fields use the defaults.
+type Options struct {
+	File       string // file backend: default ~/.sypher-mini/secrets.enc
+	KeyFile    string // file backend without passphrase: default ~/.sypher-mini/secrets.key
+	Passphrase string // file backend: default $SYPHER_SECRETS_PASSPHRASE
+	SecretTool string // secret_service backend: default secret-tool
+}
+
+// Resolver resolves secrets from env or a store. Values read from the store
+// are cached.
+type Resolver struct {
+	backend Backend
+	store   Store // nil = env
+	mu      sync.Mutex
+	cache   map[string]string
+}
+
+// NewResolver creates a secrets resolver with default options.
+func NewResolver(backend Backend) *Resolver {
+	return New(backend, Options{})
+}
+
+// New creates a secrets resolver. Unknown backends use env.
+func New(backend Backend, opts Options) *Resolver {
+	r := &Resolver{backend: backend, cache: make(map[string]string)}
+	switch backend {
+	case BackendKeychain:
+		if runtime.GOOS == "linux" {
+			r.store = &SecretService{Path: opts.SecretTool}
+		}
+	case BackendSecretService:
+		r.store = &SecretService{Path: opts.SecretTool}
+	case BackendFile:
+		home, _ := os.UserHomeDir()
+		if opts.File == "" {
+			opts.File = filepath.Join(home, ".sypher-mini", "secrets.enc")
+		}
+		if opts.KeyFile == "" {
+			opts.KeyFile = filepath.Join(home, ".sypher-mini", "secrets.key")
+		}
+		if opts.Passphrase == "" {
+			opts.Passphrase = os.Getenv(PassphraseEnv)
+		}
+		r.store = &FileStore{Path: opts.File, KeyFile: opts.KeyFile, Passphrase: opts.Passphrase}
+	default:
+		r.backend = BackendEnv
+	}
+	return r
+}
+
+// Backend returns the backend in use.
+func (r *Resolver) Backend() Backend { return r.backend }
+
+// Get returns the secret for key, or "" if it is not set. Store backends
+// fall back to the environment variable of that name.
+func (r *Resolver) Get(key string) string {
+	if v, err := r.Lookup(key); err == nil {
+		return v
+	}
+	if r.store != nil {
+		return os.Getenv(key)
+	}
+	return ""
+}
+
+// Lookup returns the secret for key from the backend only, without the env
+// fallback of Get.
+func (r *Resolver) Lookup(key string) (string, error) {
+	if r.store == nil {
+		if v, ok := os.LookupEnv(key); ok {
+			return v, nil
+		}
+		return "", ErrNotFound
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if v, ok := r.cache[key]; ok {
+		return v, nil
+	}
+	v, err := r.store.Get(key)
+	if err != nil {
+		return "", err
+	}
+	r.cache[key] = v
+	return v, nil
+}
+
+// Set stores a secret.
+func (r *Resolver) Set(key, value string) error {
+	if r.store == nil {
+		return ErrReadOnly
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cache, key)
+	return r.store.Set(key, value)
+}
+
+// Delete removes a secret.
+func (r *Resolver) Delete(key string) error {
+	if r.store == nil {
+		return ErrReadOnly
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cache, key)
+	return r.store.Delete(key)
+}
+
+// List returns the names of the stored secrets, sorted. The env backend
+// has no list of its own and returns none.
+func (r *Resolver) List() ([]string, error) {
+	if r.store == nil {
+		return nil, nil
+	}
+	names, err := r.store.List()
+	sort.Strings(names)
+	return names, err
+}
+
+// ParseRef returns the secret name of a "secret://name" reference.
+func ParseRef(v string) (string, bool) {
+	name, ok := strings.CutPrefix(v, RefPrefix)
+	if !ok || name == "" {
+		return "", false
+	}
+	return name, true
+}
@@ -0,0 +1,71 @@
+package secrets
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolver_Env(t *testing.T) {
+	t.Setenv("SYPHER_TEST_SECRET", "from-env")
+	r := NewResolver(BackendEnv)
+	if got := r.Get("SYPHER_TEST_SECRET"); got != "from-env" {
+		t.Errorf("Get = %q", got)
+	}
+	if _, err := r.Lookup("SYPHER_TEST_UNSET"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := r.Set("x", "y"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("env backend should be read-only, got %v", err)
+	}
+	if NewResolver("bogus").Backend() != BackendEnv {
+		t.Error("unknown backend should fall back to env")
+	}
+}
+
+func TestResolver_File(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(PassphraseEnv, "")
+	t.Setenv("SYPHER_TEST_FALLBACK", "env-value")
+	opts := Options{File: filepath.Join(dir, "secrets.enc"), KeyFile: filepath.Join(dir, "secrets.key")}
+	r := New(BackendFile, opts)
+
+	if err := r.Set("openai", "sk-file-123"); err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Get("openai"); got != "sk-file-123" {
+		t.Errorf("Get = %q", got)
+	}
+	if got := r.Get("SYPHER_TEST_FALLBACK"); got != "env-value" {
+		t.Errorf("missing secret should fall back to env, got %q", got)
+	}
+	if _, err := r.Lookup("SYPHER_TEST_FALLBACK"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Lookup should not fall back to env, got %v", err)
+	}
+
+	// A fresh resolver reads the file, not the cache
+	names, err := New(BackendFile, opts).List()
+	if err != nil || len(names) != 1 || names[0] != "openai" {
+		t.Errorf("List = %v, %v", names, err)
+	}
+	if err := r.Delete("openai"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Lookup("openai"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleted secret should be gone, got %v", err)
+	}
+	if err := r.Delete("openai"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestParseRef(t *testing.T) {
+	if name, ok := ParseRef("secret://openai"); !ok || name != "openai" {
+		t.Errorf("ParseRef = %q, %v", name, ok)
+	}
+	for _, v := range []string{"sk-plain", "secret://", ""} {
+		if _, ok := ParseRef(v); ok {
+			t.Errorf("%q is not a reference", v)
+		}
+	}
+}
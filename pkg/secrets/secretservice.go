@@ -0,0 +1,102 @@
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// serviceAttr tags the Secret Service items that belong to Sypher-mini.
+const serviceAttr = "sypher-mini"
+
+// SecretService stores secrets in the Linux Secret Service (GNOME Keyring,
+// KWallet, KeePassXC) over D-Bus, through libsecret's secret-tool. Items
+// carry the attributes service=sypher-mini and name=<name>.
+type SecretService struct {
+	Path string // secret-tool binary; default "secret-tool"
+}
+
+func (s *SecretService) tool(args ...string) *exec.Cmd {
+	path := s.Path
+	if path == "" {
+		path = "secret-tool"
+	}
+	return exec.Command(path, args...)
+}
+
+// Get returns the secret name.
+func (s *SecretService) Get(name string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := s.tool("lookup", "service", serviceAttr, "name", name)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && stdout.Len() == 0 && strings.TrimSpace(stderr.String()) == "" {
+			return "", ErrNotFound
+		}
+		return "", s.wrap("lookup", err, stderr.String())
+	}
+	return strings.TrimSuffix(stdout.String(), "\n"), nil
+}
+
+// Set stores the secret name, replacing an existing one.
+func (s *SecretService) Set(name, value string) error {
+	var stderr bytes.Buffer
+	cmd := s.tool("store", "--label", serviceAttr+": "+name, "service", serviceAttr, "name", name)
+	cmd.Stdin = strings.NewReader(value)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return s.wrap("store", err, stderr.String())
+	}
+	return nil
+}
+
+// Delete removes the secret name.
+func (s *SecretService) Delete(name string) error {
+	if _, err := s.Get(name); err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	cmd := s.tool("clear", "service", serviceAttr, "name", name)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return s.wrap("clear", err, stderr.String())
+	}
+	return nil
+}
+
+// List returns the names of all Sypher-mini items.
+func (s *SecretService) List() ([]string, error) {
+	var out bytes.Buffer
+	cmd := s.tool("search", "--all", "service", serviceAttr)
+	// Depending on the version, secret-tool prints attributes on stdout or stderr
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && out.Len() == 0 {
+			return nil, nil
+		}
+		return nil, s.wrap("search", err, out.String())
+	}
+	var names []string
+	sc := bufio.NewScanner(&out)
+	for sc.Scan() {
+		if name, ok := strings.CutPrefix(strings.TrimSpace(sc.Text()), "attribute.name = "); ok {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func (s *SecretService) wrap(op string, err error, stderr string) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("secret service: secret-tool not found (install libsecret-tools): %w", err)
+	}
+	if msg := strings.TrimSpace(stderr); msg != "" {
+		return fmt.Errorf("secret service: %s: %s", op, msg)
+	}
+	return fmt.Errorf("secret service: %s: %w", op, err)
+}
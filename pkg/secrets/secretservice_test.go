@@ -0,0 +1,74 @@
+package secrets
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// fakeSecretTool mimics secret-tool with one file per item.
+const fakeSecretTool = `#!/bin/sh
+dir="$SYPHER_FAKE_KEYRING"
+op=$1; shift
+case $op in
+store) cat > "$dir/$6" ;;
+lookup) [ -f "$dir/$4" ] || exit 1; cat "$dir/$4" ;;
+clear) rm -f "$dir/$4" ;;
+search)
+	found=1
+	for f in "$dir"/*; do
+		[ -f "$f" ] || continue
+		found=0
+		echo "[/org/freedesktop/secrets/collection/login/$(basename "$f")]"
+		echo "attribute.name = $(basename "$f")"
+		echo "attribute.service = sypher-mini"
+	done
+	exit $found ;;
+esac
+`
+
+func TestSecretService(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake secret-tool is a shell script")
+	}
+	dir := t.TempDir()
+	keyring := filepath.Join(dir, "keyring")
+	if err := os.Mkdir(keyring, 0700); err != nil {
+		t.Fatal(err)
+	}
+	tool := filepath.Join(dir, "secret-tool")
+	if err := os.WriteFile(tool, []byte(fakeSecretTool), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SYPHER_FAKE_KEYRING", keyring)
+	s := &SecretService{Path: tool}
+
+	if names, err := s.List(); err != nil || len(names) != 0 {
+		t.Errorf("empty keyring: List = %v, %v", names, err)
+	}
+	if _, err := s.Get("openai"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := s.Set("openai", "sk-keyring"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := s.Get("openai"); err != nil || v != "sk-keyring" {
+		t.Errorf("Get = %q, %v", v, err)
+	}
+	if names, err := s.List(); err != nil || len(names) != 1 || names[0] != "openai" {
+		t.Errorf("List = %v, %v", names, err)
+	}
+	if err := s.Delete("openai"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete("openai"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	missing := &SecretService{Path: filepath.Join(dir, "nonexistent")}
+	if _, err := missing.Get("x"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("missing secret-tool should be an error, got %v", err)
+	}
+}
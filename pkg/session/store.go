@@ -0,0 +1,174 @@
+package session
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sypherexx/sypher-mini/pkg/providers/types"
+)
+
+// DefaultMaxMessages is the per-session history cap when none is configured.
+const DefaultMaxMessages = 50
+
+// Session is the persisted conversation history for a session key.
+type Session struct {
+	Key       string          `json:"key"`
+	Messages  []types.Message `json:"messages"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// Info summarizes a stored session.
+type Info struct {
+	Key       string
+	Messages  int
+	UpdatedAt time.Time
+}
+
+// Store persists per-session conversation history as JSON files.
+type Store struct {
+	dir         string
+	maxMessages int
+	mu          sync.Mutex
+}
+
+// NewStore creates a session store rooted at dir (typically {workspace}/sessions).
+func NewStore(dir string, maxMessages int) *Store {
+	if maxMessages <= 0 {
+		maxMessages = DefaultMaxMessages
+	}
+	return &Store{dir: dir, maxMessages: maxMessages}
+}
+
+// Dir returns the store directory.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Load returns the stored history for key, or nil if none exists.
+func (s *Store) Load(key string) ([]types.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.read(s.path(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return sess.Messages, nil
+}
+
+// Save replaces the history for key, keeping at most maxMessages recent messages.
+func (s *Store) Save(key string, messages []types.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(Session{
+		Key:       key,
+		Messages:  trimHistory(messages, s.maxMessages),
+		UpdatedAt: time.Now(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := s.path(key)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Get returns the full stored session for key.
+func (s *Store) Get(key string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(s.path(key))
+}
+
+// List returns all stored sessions, most recently updated first.
+func (s *Store) List() ([]Info, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []Info
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		sess, err := s.read(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		out = append(out, Info{Key: sess.Key, Messages: len(sess.Messages), UpdatedAt: sess.UpdatedAt})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UpdatedAt.After(out[j].UpdatedAt) })
+	return out, nil
+}
+
+// Clear removes the stored history for key.
+func (s *Store) Clear(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := os.Remove(s.path(key))
+	if os.IsNotExist(err) {
+		return fmt.Errorf("session not found: %s", key)
+	}
+	return err
+}
+
+func (s *Store) read(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sess Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, fmt.Errorf("parse session %s: %w", filepath.Base(path), err)
+	}
+	return &sess, nil
+}
+
+// path names the file of key by its SHA-256, so distinct keys never share a
+// file and any key is a valid file name; the key itself is stored inside.
+func (s *Store) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// trimHistory keeps the last max messages. The kept window always starts at a
+// user message so an assistant tool call is never separated from its results.
+// When the last max messages hold no user message (one long tool-calling
+// turn), the window is widened back to the last user message instead.
+func trimHistory(messages []types.Message, max int) []types.Message {
+	if len(messages) <= max {
+		return messages
+	}
+	cut := len(messages) - max
+	for start := cut; start < len(messages); start++ {
+		if messages[start].Role == "user" {
+			return messages[start:]
+		}
+	}
+	for start := cut - 1; start >= 0; start-- {
+		if messages[start].Role == "user" {
+			return messages[start:]
+		}
+	}
+	return messages[cut:]
+}
@@ -0,0 +1,100 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/sypherexx/sypher-mini/pkg/providers/types"
+)
+
+func TestStore_SaveLoad(t *testing.T) {
+	s := NewStore(t.TempDir(), 10)
+	key := "agent:main:whatsapp:+123"
+
+	msgs, err := s.Load(key)
+	if err != nil || msgs != nil {
+		t.Fatalf("expected empty history, got %v, %v", msgs, err)
+	}
+
+	in := []types.Message{
+		{Role: "user", Content: "list files"},
+		{Role: "assistant", ToolCalls: []types.ToolCall{{ID: "c1", Name: "exec", Arguments: map[string]interface{}{"command": "ls"}}}},
+		{Role: "tool", Content: "a.txt", ToolCallID: "c1"},
+		{Role: "assistant", Content: "a.txt"},
+	}
+	if err := s.Save(key, in); err != nil {
+		t.Fatal(err)
+	}
+	out, err := s.Load(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(out))
+	}
+	if out[1].ToolCalls[0].Name != "exec" || out[2].ToolCallID != "c1" {
+		t.Errorf("tool call not preserved: %+v", out[1:3])
+	}
+
+	list, err := s.List()
+	if err != nil || len(list) != 1 || list[0].Key != key {
+		t.Errorf("List() = %v, %v", list, err)
+	}
+
+	if err := s.Clear(key); err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := s.Load(key); out != nil {
+		t.Error("Clear did not remove session")
+	}
+}
+
+func TestStore_DistinctKeys(t *testing.T) {
+	s := NewStore(t.TempDir(), 10)
+	keys := []string{"agent:main:cli:a_b", "agent:main:cli:a b", "agent:main:cli:a@b", "agent:main:cli:a/b", "agent_main_cli_a_b"}
+	for _, key := range keys {
+		if err := s.Save(key, []types.Message{{Role: "user", Content: key}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, key := range keys {
+		out, err := s.Load(key)
+		if err != nil || len(out) != 1 || out[0].Content != key {
+			t.Errorf("Load(%q) = %v, %v", key, out, err)
+		}
+	}
+	if list, _ := s.List(); len(list) != len(keys) {
+		t.Errorf("expected %d sessions, got %d", len(keys), len(list))
+	}
+}
+
+func TestTrimHistory_StartsAtUser(t *testing.T) {
+	msgs := []types.Message{
+		{Role: "user", Content: "1"},
+		{Role: "assistant", ToolCalls: []types.ToolCall{{ID: "c1"}}},
+		{Role: "tool", ToolCallID: "c1"},
+		{Role: "assistant", Content: "done"},
+		{Role: "user", Content: "2"},
+		{Role: "assistant", Content: "ok"},
+	}
+	got := trimHistory(msgs, 4)
+	if len(got) != 2 || got[0].Content != "2" {
+		t.Errorf("expected window starting at second user message, got %+v", got)
+	}
+}
+
+func TestTrimHistory_LongToolTurn(t *testing.T) {
+	msgs := []types.Message{
+		{Role: "user", Content: "1"},
+		{Role: "assistant", Content: "ok"},
+		{Role: "user", Content: "2"},
+		{Role: "assistant", ToolCalls: []types.ToolCall{{ID: "c1"}}},
+		{Role: "tool", ToolCallID: "c1"},
+		{Role: "assistant", ToolCalls: []types.ToolCall{{ID: "c2"}}},
+		{Role: "tool", ToolCallID: "c2"},
+		{Role: "assistant", Content: "done"},
+	}
+	got := trimHistory(msgs, 3)
+	if len(got) != 6 || got[0].Content != "2" {
+		t.Errorf("expected window widened to the last user message, got %+v", got)
+	}
+}
@@ -0,0 +1,586 @@
+// Package shell parses POSIX shell command lines into simple commands so
+// exec policy can be checked per executable instead of on the raw string.
+//
+// It understands quoting, parameter expansion, command and process
+// substitution, pipelines, lists, redirections, ( ) and { } groups and the
+// if/while/until/for keywords. Syntax it cannot analyse reliably (here-
+// documents, case, function definitions) is rejected with an error rather
+// than guessed at.
+package shell
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDepth bounds nesting of groups and substitutions.
+const maxDepth = 32
+
+// Word is a shell word after quote removal.
+type Word struct {
+	Lit     string // value without quotes; expansions are kept verbatim (e.g. "$HOME")
+	Dynamic bool   // contains an expansion, substitution, glob or brace list, so the runtime value is unknown
+	Quoted  bool   // some part was quoted or escaped
+}
+
+// Redirect is an I/O redirection such as "2>&1" or "> out.txt".
+type Redirect struct {
+	Op     string // operator including any fd prefix: ">", ">>", "2>", "<", "&>", "<<<", ...
+	Target Word
+}
+
+// Command is a simple command.
+type Command struct {
+	Assigns      []string // leading NAME=value words
+	Args         []Word   // Args[0] is the executable
+	Redirects    []Redirect
+	Piped        bool // stdin comes from a pipe
+	Substitution bool // a word or redirect target contains $( ), ` ` or <( )
+}
+
+// Script is a parsed command line.
+type Script struct {
+	Commands  []*Command // every simple command, including those in groups and substitutions
+	Subshells int        // ( ) groups, command substitutions and process substitutions
+}
+
+// Parse parses src.
+func Parse(src string) (*Script, error) {
+	p := &parser{src: []rune(src), out: &Script{}}
+	if err := p.list(0, false); err != nil {
+		return nil, err
+	}
+	return p.out, nil
+}
+
+type parser struct {
+	src   []rune
+	pos   int
+	depth int
+	out   *Script
+}
+
+var (
+	// skipWords are keywords that only structure the commands around them.
+	skipWords = map[string]bool{
+		"!": true, "{": true, "}": true, "if": true, "then": true, "else": true, "elif": true,
+		"fi": true, "while": true, "until": true, "do": true, "done": true,
+	}
+	unsupportedWords = map[string]bool{"case": true, "esac": true, "function": true, "coproc": true}
+)
+
+func (p *parser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *parser) peek() rune { return p.at(0) }
+
+func (p *parser) at(off int) rune {
+	if p.pos+off >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos+off]
+}
+
+func (p *parser) hasPrefix(s string) bool {
+	for i, r := range []rune(s) {
+		if p.at(i) != r {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("shell: "+format+" (at offset %d)", append(args, p.pos)...)
+}
+
+// list parses commands until closer (0 = end of input). piped marks the
+// first command as reading from a pipe, for groups on the right of "|".
+func (p *parser) list(closer rune, piped bool) error {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxDepth {
+		return p.errorf("nesting too deep")
+	}
+	cur := &Command{}
+	discard := false // inside "for NAME in words"
+	flush := func() {
+		if len(cur.Args)+len(cur.Assigns)+len(cur.Redirects) > 0 {
+			cur.Piped = piped
+			p.out.Commands = append(p.out.Commands, cur)
+		}
+		cur = &Command{}
+		discard = false
+		piped = false
+	}
+	empty := func() bool { return len(cur.Args) == 0 && len(cur.Assigns) == 0 && !discard }
+
+	for {
+		p.skipBlanks()
+		if p.eof() {
+			if closer != 0 {
+				return p.errorf("missing %q", closer)
+			}
+			flush()
+			return nil
+		}
+		c := p.peek()
+		switch {
+		case c == '#':
+			for !p.eof() && p.peek() != '\n' {
+				p.pos++
+			}
+		case closer != 0 && c == closer:
+			p.pos++
+			flush()
+			return nil
+		case c == ')':
+			return p.errorf("unexpected )")
+		case c == ';' || c == '\n':
+			if p.hasPrefix(";;") {
+				return p.errorf("case is not supported")
+			}
+			p.pos++
+			flush()
+		case p.hasPrefix("&&") || p.hasPrefix("||"):
+			p.pos += 2
+			flush()
+		case p.hasPrefix("&>"):
+			if err := p.redirect("", cur); err != nil {
+				return err
+			}
+		case c == '&':
+			p.pos++
+			flush()
+		case c == '|':
+			p.pos++
+			if p.peek() == '&' {
+				p.pos++
+			}
+			flush()
+			piped = true
+		case c == '(':
+			if p.hasPrefix("((") {
+				// Arithmetic command: no commands inside unless substituted
+				if err := p.arithmetic(&strings.Builder{}, &Word{}); err != nil {
+					return err
+				}
+				continue
+			}
+			if !empty() {
+				if len(cur.Args) == 1 {
+					return p.errorf("function definitions are not supported")
+				}
+				return p.errorf("unexpected (")
+			}
+			p.pos++
+			p.out.Subshells++
+			wasPiped := piped
+			piped = false
+			if err := p.list(')', wasPiped); err != nil {
+				return err
+			}
+		case (c == '<' || c == '>') && p.at(1) != '(':
+			if err := p.redirect("", cur); err != nil {
+				return err
+			}
+		default:
+			if fd := p.fdPrefix(); fd != "" {
+				p.pos += len(fd)
+				if err := p.redirect(fd, cur); err != nil {
+					return err
+				}
+				continue
+			}
+			w, subst, err := p.word()
+			if err != nil {
+				return err
+			}
+			if subst {
+				cur.Substitution = true
+			}
+			switch {
+			case discard:
+			case empty() && !w.Quoted && !w.Dynamic && skipWords[w.Lit]:
+			case empty() && !w.Quoted && !w.Dynamic && unsupportedWords[w.Lit]:
+				return p.errorf("%s is not supported", w.Lit)
+			case empty() && !w.Quoted && (w.Lit == "for" || w.Lit == "select"):
+				discard = true
+			case len(cur.Args) == 0 && isAssignment(w):
+				cur.Assigns = append(cur.Assigns, w.Lit)
+			default:
+				cur.Args = append(cur.Args, w)
+			}
+		}
+	}
+}
+
+// fdPrefix returns the digits of an "N>" or "N<" redirection at the cursor.
+func (p *parser) fdPrefix() string {
+	i := 0
+	for p.at(i) >= '0' && p.at(i) <= '9' {
+		i++
+	}
+	if i == 0 || (p.at(i) != '<' && p.at(i) != '>') || p.at(i+1) == '(' {
+		return ""
+	}
+	return string(p.src[p.pos : p.pos+i])
+}
+
+// redirectOps are tried longest first.
+var redirectOps = []string{"&>>", "<<<", "<<-", "&>", ">>", ">&", "<&", "<>", ">|", "<<", ">", "<"}
+
+func (p *parser) redirect(fd string, cur *Command) error {
+	op := ""
+	for _, o := range redirectOps {
+		if p.hasPrefix(o) {
+			op = o
+			break
+		}
+	}
+	if op == "<<" || op == "<<-" {
+		return p.errorf("here-documents are not supported")
+	}
+	p.pos += len([]rune(op))
+	p.skipBlanks()
+	if p.eof() || (strings.ContainsRune(" \t\n;&|()<>", p.peek()) && p.at(1) != '(') {
+		return p.errorf("missing target for %s", op)
+	}
+	w, subst, err := p.word()
+	if err != nil {
+		return err
+	}
+	if subst {
+		cur.Substitution = true
+	}
+	cur.Redirects = append(cur.Redirects, Redirect{Op: fd + op, Target: w})
+	return nil
+}
+
+func (p *parser) skipBlanks() {
+	for !p.eof() {
+		switch {
+		case p.peek() == ' ' || p.peek() == '\t':
+			p.pos++
+		case p.hasPrefix("\\\n"):
+			p.pos += 2
+		default:
+			return
+		}
+	}
+}
+
+// word reads one word. subst reports a command or process substitution in it.
+// Unquoted glob characters and brace lists ({a,b}, {1..3}) make it dynamic.
+func (p *parser) word() (w Word, subst bool, err error) {
+	var b strings.Builder
+	braceOpen, braceList := false, false
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case strings.ContainsRune(" \t\n;&|()", c):
+			w.Lit = b.String()
+			return w, subst, nil
+		case c == '<' || c == '>':
+			if p.at(1) != '(' {
+				w.Lit = b.String()
+				return w, subst, nil
+			}
+			// Process substitution <( ) or >( )
+			b.WriteString(string(c) + "(...)")
+			p.pos += 2
+			p.out.Subshells++
+			w.Dynamic, subst = true, true
+			if err := p.list(')', false); err != nil {
+				return w, subst, err
+			}
+		case c == '\\':
+			w.Quoted = true
+			p.pos++
+			if p.eof() {
+				break
+			}
+			if p.peek() != '\n' {
+				b.WriteRune(p.peek())
+			}
+			p.pos++
+		case c == '\'':
+			w.Quoted = true
+			p.pos++
+			end := p.pos
+			for end < len(p.src) && p.src[end] != '\'' {
+				end++
+			}
+			if end >= len(p.src) {
+				return w, subst, p.errorf("unterminated single quote")
+			}
+			b.WriteString(string(p.src[p.pos:end]))
+			p.pos = end + 1
+		case c == '"':
+			w.Quoted = true
+			p.pos++
+			if s, err := p.doubleQuoted(&b, &w); err != nil {
+				return w, subst, err
+			} else if s {
+				subst = true
+			}
+		case c == '$':
+			s, err := p.dollar(&b, &w)
+			if err != nil {
+				return w, subst, err
+			}
+			subst = subst || s
+		case c == '`':
+			if err := p.backtick(&b, &w); err != nil {
+				return w, subst, err
+			}
+			subst = true
+		default:
+			switch {
+			case c == '*' || c == '?' || (c == '[' && p.closesBracket()):
+				w.Dynamic = true
+			case c == '{':
+				braceOpen = true
+			case braceOpen && (c == ',' || (c == '.' && p.at(1) == '.')):
+				braceList = true
+			case c == '}' && braceList:
+				w.Dynamic = true
+			}
+			b.WriteRune(c)
+			p.pos++
+		}
+	}
+	w.Lit = b.String()
+	return w, subst, nil
+}
+
+// closesBracket reports whether the "[" at the cursor has a "]" later in the
+// same word, making it a glob bracket expression rather than a literal.
+func (p *parser) closesBracket() bool {
+	for i := p.pos + 1; i < len(p.src); i++ {
+		switch p.src[i] {
+		case ']':
+			return true
+		case ' ', '\t', '\n', ';', '&', '|', '(', ')', '<', '>':
+			return false
+		}
+	}
+	return false
+}
+
+func (p *parser) doubleQuoted(b *strings.Builder, w *Word) (subst bool, err error) {
+	for {
+		if p.eof() {
+			return subst, p.errorf("unterminated double quote")
+		}
+		switch c := p.peek(); c {
+		case '"':
+			p.pos++
+			return subst, nil
+		case '\\':
+			next := p.at(1)
+			switch next {
+			case '$', '`', '"', '\\':
+				b.WriteRune(next)
+				p.pos += 2
+			case '\n':
+				p.pos += 2
+			default:
+				b.WriteRune(c)
+				p.pos++
+			}
+		case '$':
+			s, err := p.dollar(b, w)
+			if err != nil {
+				return subst, err
+			}
+			subst = subst || s
+		case '`':
+			if err := p.backtick(b, w); err != nil {
+				return subst, err
+			}
+			subst = true
+		default:
+			b.WriteRune(c)
+			p.pos++
+		}
+	}
+}
+
+// dollar handles "$" at the cursor: parameter expansion, command
+// substitution, arithmetic, or $'...' strings. Expansions are written to b
+// verbatim and make the word dynamic.
+func (p *parser) dollar(b *strings.Builder, w *Word) (subst bool, err error) {
+	next := p.at(1)
+	switch {
+	case p.hasPrefix("$(("):
+		p.pos++
+		w.Dynamic = true
+		b.WriteRune('$')
+		return false, p.arithmetic(b, w)
+	case next == '(':
+		p.pos += 2
+		p.out.Subshells++
+		w.Dynamic = true
+		b.WriteString("$(...)")
+		return true, p.list(')', false)
+	case next == '{':
+		start := p.pos
+		p.pos += 2
+		w.Dynamic = true
+		depth := 1
+		for depth > 0 {
+			if p.eof() {
+				return subst, p.errorf("unterminated ${")
+			}
+			switch c := p.peek(); {
+			case c == '}':
+				depth--
+				p.pos++
+			case c == '\\':
+				p.pos += 2
+			case c == '\'':
+				p.pos++
+				for !p.eof() && p.peek() != '\'' {
+					p.pos++
+				}
+				p.pos++
+			case c == '$':
+				var inner strings.Builder
+				s, err := p.dollar(&inner, w)
+				if err != nil {
+					return subst, err
+				}
+				subst = subst || s
+			case c == '`':
+				if err := p.backtick(&strings.Builder{}, w); err != nil {
+					return subst, err
+				}
+				subst = true
+			case c == '{':
+				depth++
+				p.pos++
+			default:
+				p.pos++
+			}
+		}
+		b.WriteString(string(p.src[start:min(p.pos, len(p.src))]))
+		return subst, nil
+	case next == '\'':
+		// $'...' may encode any character with escapes; treat it as unknown
+		start := p.pos
+		p.pos += 2
+		for !p.eof() && p.peek() != '\'' {
+			if p.peek() == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		if p.eof() {
+			return false, p.errorf("unterminated $'")
+		}
+		p.pos++
+		w.Dynamic, w.Quoted = true, true
+		b.WriteString(string(p.src[start:p.pos]))
+		return false, nil
+	case next == '"':
+		p.pos++
+		return false, nil
+	case isNameStart(next) || strings.ContainsRune("0123456789@*#?$!-", next):
+		start := p.pos
+		p.pos += 2
+		if isNameStart(next) {
+			for isNameStart(p.peek()) || (p.peek() >= '0' && p.peek() <= '9') {
+				p.pos++
+			}
+		}
+		w.Dynamic = true
+		b.WriteString(string(p.src[start:p.pos]))
+		return false, nil
+	default:
+		b.WriteRune('$')
+		p.pos++
+		return false, nil
+	}
+}
+
+// arithmetic skips "((...))", parsing any substitutions inside.
+func (p *parser) arithmetic(b *strings.Builder, w *Word) error {
+	start := p.pos
+	depth := 0
+	for {
+		if p.eof() {
+			return p.errorf("unterminated ((")
+		}
+		switch p.peek() {
+		case '(':
+			depth++
+			p.pos++
+		case ')':
+			depth--
+			p.pos++
+			if depth == 0 {
+				b.WriteString(string(p.src[start:p.pos]))
+				return nil
+			}
+		case '$':
+			if _, err := p.dollar(&strings.Builder{}, w); err != nil {
+				return err
+			}
+		case '`':
+			if err := p.backtick(&strings.Builder{}, w); err != nil {
+				return err
+			}
+		default:
+			p.pos++
+		}
+	}
+}
+
+// backtick parses a `...` command substitution.
+func (p *parser) backtick(b *strings.Builder, w *Word) error {
+	p.pos++
+	var inner strings.Builder
+	for {
+		if p.eof() {
+			return p.errorf("unterminated `")
+		}
+		c := p.peek()
+		if c == '`' {
+			p.pos++
+			break
+		}
+		if c == '\\' && strings.ContainsRune("`$\\", p.at(1)) {
+			inner.WriteRune(p.at(1))
+			p.pos += 2
+			continue
+		}
+		inner.WriteRune(c)
+		p.pos++
+	}
+	sub := &parser{src: []rune(inner.String()), depth: p.depth, out: p.out}
+	if err := sub.list(0, false); err != nil {
+		return err
+	}
+	p.out.Subshells++
+	w.Dynamic = true
+	b.WriteString("`...`")
+	return nil
+}
+
+func isNameStart(r rune) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// isAssignment reports whether w is NAME=value with an unquoted name.
+func isAssignment(w Word) bool {
+	i := strings.IndexByte(w.Lit, '=')
+	if i <= 0 {
+		return false
+	}
+	for j, r := range w.Lit[:i] {
+		if !isNameStart(r) && (j == 0 || r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
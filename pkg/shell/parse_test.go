@@ -0,0 +1,140 @@
+package shell
+
+import (
+	"strings"
+	"testing"
+)
+
+// names returns the executables of the script's commands joined by spaces.
+func names(s *Script) string {
+	var out []string
+	for _, c := range s.Commands {
+		if len(c.Args) > 0 {
+			out = append(out, c.Args[0].Lit)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func TestParse_Commands(t *testing.T) {
+	tests := []struct {
+		src   string
+		names string
+	}{
+		{"echo hi", "echo"},
+		{"cd src && make || echo failed; ls", "cd make echo ls"},
+		{"cat a.txt | grep x | sort &", "cat grep sort"},
+		{"( cd sub; make ) && { go test ./...; }", "cd make go"},
+		{"if test -f go.mod; then go build; else echo none; fi", "test go echo"},
+		{"for f in *.go; do gofmt -l $f; done", "gofmt"},
+		{"while read l; do echo $l; done < list.txt", "read echo"},
+		{"echo $(date) `whoami`", "date whoami echo"},
+		{"diff <(sort a) <(sort b)", "sort sort diff"},
+		{"FOO=1 BAR=\"x y\" env", "env"},
+		{"echo ${HOME:-$(pwd)}", "pwd echo"},
+		{"echo $((1 + 2))", "echo"},
+		{"# just a comment", ""},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("%q: %v", tt.src, err)
+			continue
+		}
+		if got := names(s); got != tt.names {
+			t.Errorf("%q: commands %q, want %q", tt.src, got, tt.names)
+		}
+	}
+}
+
+func TestParse_Words(t *testing.T) {
+	s, err := Parse(`'r''m' -r\ -f "a b" "$HOME/x" /bin/rm`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := s.Commands[0].Args
+	want := []string{"rm", "-r -f", "a b", "$HOME/x", "/bin/rm"}
+	if len(args) != len(want) {
+		t.Fatalf("got %d args: %+v", len(args), args)
+	}
+	for i, w := range want {
+		if args[i].Lit != w {
+			t.Errorf("arg %d = %q, want %q", i, args[i].Lit, w)
+		}
+	}
+	if !args[0].Quoted || args[0].Dynamic || !args[3].Dynamic {
+		t.Errorf("quoted/dynamic flags wrong: %+v", args)
+	}
+}
+
+func TestParse_GlobsAndBraces(t *testing.T) {
+	for src, dynamic := range map[string]bool{
+		"/bin/r?":       true,
+		"r[m]":          true,
+		"*.go":          true,
+		"{rm,-rf,x}":    true,
+		"file{1..3}":    true,
+		"'*.go'":        false,
+		"\\*":           false,
+		"\"{a,b}\"":     false,
+		"[":             false,
+		"{}":            false,
+		"a{b}":          false,
+		"x=[1]":         true,
+		"git@host:repo": false,
+	} {
+		s, err := Parse("echo " + src)
+		if err != nil {
+			t.Errorf("%q: %v", src, err)
+			continue
+		}
+		if got := s.Commands[0].Args[1].Dynamic; got != dynamic {
+			t.Errorf("%q: Dynamic = %v, want %v", src, got, dynamic)
+		}
+	}
+}
+
+func TestParse_RedirectsAndPipes(t *testing.T) {
+	s, err := Parse("make 2>&1 >build.log | tee out &>> all.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	make, tee := s.Commands[0], s.Commands[1]
+	if len(make.Redirects) != 2 || make.Redirects[0].Op != "2>&" || make.Redirects[0].Target.Lit != "1" ||
+		make.Redirects[1].Op != ">" || make.Redirects[1].Target.Lit != "build.log" {
+		t.Errorf("make redirects: %+v", make.Redirects)
+	}
+	if make.Piped || !tee.Piped {
+		t.Error("only tee reads from the pipe")
+	}
+	if len(tee.Args) != 2 || len(tee.Redirects) != 1 || tee.Redirects[0].Op != "&>>" {
+		t.Errorf("tee: %+v", tee)
+	}
+
+	s, _ = Parse("curl -s x | (sh)")
+	if !s.Commands[1].Piped || s.Subshells != 1 {
+		t.Errorf("group after pipe should read the pipe: %+v", s.Commands[1])
+	}
+	s, _ = Parse("echo $(date)")
+	if !s.Commands[1].Substitution || s.Commands[0].Substitution {
+		t.Error("substitution should be marked on the command using it")
+	}
+}
+
+func TestParse_Unsupported(t *testing.T) {
+	for _, src := range []string{
+		"cat <<EOF\nhi\nEOF",
+		":(){ :|:& };:",
+		"case $x in a) echo;; esac",
+		"function f { rm -rf /; }",
+		"echo 'unterminated",
+		"echo \"unterminated",
+		"echo $(date",
+		"echo )",
+		"echo >",
+	} {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("%q should not parse", src)
+		}
+	}
+}
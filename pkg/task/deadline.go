@@ -0,0 +1,113 @@
+package task
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// deadline is a task context whose timeout can be paused, so time spent
+// waiting on the user (approval prompts) does not count against the task.
+// It reports context.DeadlineExceeded, to it and its children, once the
+// timeout has run out.
+type deadline struct {
+	parent context.Context
+	done   chan struct{}
+
+	mu        sync.Mutex
+	err       error
+	timer     *time.Timer
+	remaining time.Duration
+	started   time.Time
+	paused    int
+}
+
+func newDeadline(parent context.Context, timeout time.Duration) *deadline {
+	d := &deadline{parent: parent, done: make(chan struct{}), remaining: timeout, started: time.Now()}
+	d.mu.Lock()
+	d.timer = time.AfterFunc(timeout, d.expire)
+	d.mu.Unlock()
+	go func() {
+		select {
+		case <-parent.Done():
+			d.finish(parent.Err())
+		case <-d.done:
+		}
+	}()
+	return d
+}
+
+// Deadline reports when the timeout runs out; there is none while paused.
+func (d *deadline) Deadline() (time.Time, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.paused > 0 || d.err != nil {
+		return d.parent.Deadline()
+	}
+	at := d.started.Add(d.remaining)
+	if parent, ok := d.parent.Deadline(); ok && parent.Before(at) {
+		return parent, true
+	}
+	return at, true
+}
+
+func (d *deadline) Done() <-chan struct{} { return d.done }
+
+func (d *deadline) Err() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
+
+func (d *deadline) Value(key any) any { return d.parent.Value(key) }
+
+func (d *deadline) expire() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.paused == 0 {
+		d.finishLocked(context.DeadlineExceeded)
+	}
+}
+
+func (d *deadline) finish(err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.finishLocked(err)
+}
+
+func (d *deadline) finishLocked(err error) {
+	if d.err != nil {
+		return
+	}
+	d.err = err
+	d.timer.Stop()
+	close(d.done)
+}
+
+// pause stops the clock until the returned resume func is called.
+func (d *deadline) pause() (resume func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.paused == 0 && d.err == nil {
+		d.timer.Stop()
+		d.remaining -= time.Since(d.started)
+	}
+	d.paused++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			d.mu.Lock()
+			defer d.mu.Unlock()
+			d.paused--
+			if d.paused == 0 && d.err == nil {
+				d.started = time.Now()
+				d.timer = time.AfterFunc(max(d.remaining, 0), d.expire)
+			}
+		})
+	}
+}
+
+// stop releases the timer and watcher once the task is done.
+func (d *deadline) stop() {
+	d.finish(context.Canceled)
+}
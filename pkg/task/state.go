@@ -16,10 +16,12 @@ const (
 	StateAuthorized State = "authorized"
 	StateExecuting  State = "executing"
 	StateMonitoring State = "monitoring"
-	StateCompleted  State = "completed"
-	StateFailed     State = "failed"
-	StateKilled     State = "killed"
-	StateTimeout    State = "timeout"
+	// StateAwaitingApproval: a tool call is paused until the user approves or denies it
+	StateAwaitingApproval State = "awaiting_approval"
+	StateCompleted        State = "completed"
+	StateFailed           State = "failed"
+	StateKilled           State = "killed"
+	StateTimeout          State = "timeout"
 )
 
 // Task represents a single task with lifecycle state.
@@ -31,7 +33,9 @@ type Task struct {
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
 	Cancelled  bool
+	Media      []string // workspace paths of the message's attachments
 	mu         sync.RWMutex
+	deadline   *deadline
 }
 
 // New creates a new task in pending state.
@@ -62,6 +66,20 @@ func (t *Task) SetCancelled(c bool) {
 	t.Cancelled = c
 }
 
+// SetMedia records the attachments the task works on.
+func (t *Task) SetMedia(paths []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Media = paths
+}
+
+// GetMedia returns the task's attachment paths.
+func (t *Task) GetMedia() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.Media
+}
+
 // IsCancelled returns whether the task is cancelled.
 func (t *Task) IsCancelled() bool {
 	t.mu.RLock()
@@ -84,6 +102,7 @@ func (t *Task) IsTerminal() bool {
 
 // RunWithTimeout runs fn with a timeout. Transitions to StateExecuting before running,
 // StateTimeout on timeout, or leaves as-is on success (caller transitions to StateCompleted).
+// The timeout does not run while the task is paused with PauseDeadline.
 func (t *Task) RunWithTimeout(ctx context.Context, timeout time.Duration, fn func(context.Context) error) error {
 	if timeout <= 0 {
 		t.Transition(StateExecuting)
@@ -91,23 +110,43 @@ func (t *Task) RunWithTimeout(ctx context.Context, timeout time.Duration, fn fun
 	}
 
 	t.Transition(StateExecuting)
-	ctx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
+	d := newDeadline(ctx, timeout)
+	defer d.stop()
+	t.mu.Lock()
+	t.deadline = d
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.deadline = nil
+		t.mu.Unlock()
+	}()
 
 	done := make(chan error, 1)
 	go func() {
-		done <- fn(ctx)
+		done <- fn(d)
 	}()
 
 	select {
 	case err := <-done:
-		if ctx.Err() == context.DeadlineExceeded {
+		if d.Err() == context.DeadlineExceeded {
 			t.Transition(StateTimeout)
 			return context.DeadlineExceeded
 		}
 		return err
-	case <-ctx.Done():
+	case <-d.Done():
 		t.Transition(StateTimeout)
-		return ctx.Err()
+		return d.Err()
+	}
+}
+
+// PauseDeadline stops the task's timeout clock, e.g. while it waits for the
+// user, until resume is called. It is a no-op outside RunWithTimeout.
+func (t *Task) PauseDeadline() (resume func()) {
+	t.mu.RLock()
+	d := t.deadline
+	t.mu.RUnlock()
+	if d == nil {
+		return func() {}
 	}
+	return d.pause()
 }
@@ -30,6 +30,7 @@ func TestTask_IsTerminal(t *testing.T) {
 	}{
 		{StatePending, false},
 		{StateExecuting, false},
+		{StateAwaitingApproval, false},
 		{StateCompleted, true},
 		{StateFailed, true},
 		{StateKilled, true},
@@ -81,3 +82,25 @@ func TestTask_RunWithTimeout_Timeout(t *testing.T) {
 		t.Errorf("expected state timeout, got %s", task.GetState())
 	}
 }
+
+func TestTask_PauseDeadline(t *testing.T) {
+	task := New("a", "s")
+	err := task.RunWithTimeout(context.Background(), 100*time.Millisecond, func(ctx context.Context) error {
+		resume := task.PauseDeadline()
+		time.Sleep(200 * time.Millisecond)
+		resume()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		child, cancel := context.WithCancel(ctx)
+		defer cancel()
+		<-child.Done()
+		if child.Err() != context.DeadlineExceeded {
+			t.Errorf("child context err = %v, want DeadlineExceeded", child.Err())
+		}
+		return nil
+	})
+	if err != context.DeadlineExceeded || task.GetState() != StateTimeout {
+		t.Errorf("expected the paused task to time out later, got %v in %s", err, task.GetState())
+	}
+}
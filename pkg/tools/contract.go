@@ -20,6 +20,13 @@ type Response struct {
 	Retriable  bool   `json:"retriable,omitempty"`
 }
 
+// ParallelSafe is implemented by tools without side effects. When ParallelSafe
+// returns true, the agent loop may run the tool concurrently with other
+// parallel-safe calls from the same LLM turn.
+type ParallelSafe interface {
+	ParallelSafe() bool
+}
+
 // ErrorCodes for tool responses.
 const (
 	CodeSafetyBlocked  = "SAFETY_BLOCKED"
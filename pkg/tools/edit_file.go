@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sypherexx/sypher-mini/pkg/audit"
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/policy"
+)
+
+// EditFileTool changes an existing file by exact string replacement or by
+// applying a unified diff.
+type EditFileTool struct {
+	fileAccess
+}
+
+// NewEditFileTool creates an edit_file tool.
+func NewEditFileTool(cfg *config.Config, policyEval *policy.Evaluator, auditLogger *audit.Logger, safeMode bool) *EditFileTool {
+	return &EditFileTool{fileAccess: newFileAccess(cfg, policyEval, auditLogger, safeMode)}
+}
+
+// Name returns the tool name.
+func (t *EditFileTool) Name() string { return "edit_file" }
+
+// Description returns the tool description for the LLM.
+func (t *EditFileTool) Description() string {
+	return "Edit a file. Either replace old_string with new_string (old_string must match exactly and be unique unless replace_all is set), " +
+		"or apply a unified diff (@@ hunks with ' ', '-' and '+' lines) via the diff argument."
+}
+
+// Schema returns the JSON schema of the tool arguments.
+func (t *EditFileTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path":        map[string]interface{}{"type": "string", "description": "File path (relative to the workspace or absolute)"},
+			"old_string":  map[string]interface{}{"type": "string", "description": "Exact text to replace, including whitespace"},
+			"new_string":  map[string]interface{}{"type": "string", "description": "Replacement text"},
+			"replace_all": map[string]interface{}{"type": "boolean", "description": "Replace every occurrence of old_string (default false)"},
+			"diff":        map[string]interface{}{"type": "string", "description": "Unified diff for this file (alternative to old_string/new_string)"},
+		},
+		"required": []interface{}{"path"},
+	}
+}
+
+// Capabilities returns the capabilities this tool provides.
+func (t *EditFileTool) Capabilities() []string {
+	return []string{"code_generation", "file_edit"}
+}
+
+// Execute applies the edit.
+func (t *EditFileTool) Execute(ctx context.Context, req Request) Response {
+	if t.safeMode {
+		return safeModeError(req, "edit_file")
+	}
+	p, _ := req.Args["path"].(string)
+	if p == "" {
+		return ErrorResponse(req.ToolCallID,
+			"Missing 'path' argument",
+			"Path is required.",
+			CodePermissionDenied, false)
+	}
+	oldStr, _ := req.Args["old_string"].(string)
+	newStr, hasNew := req.Args["new_string"].(string)
+	replaceAll, _ := req.Args["replace_all"].(bool)
+	diff, _ := req.Args["diff"].(string)
+	if (diff == "") == (oldStr == "") {
+		return ErrorResponse(req.ToolCallID,
+			"Provide either old_string/new_string or diff",
+			"Invalid edit arguments.",
+			CodePermissionDenied, false)
+	}
+	if oldStr != "" && !hasNew {
+		return ErrorResponse(req.ToolCallID,
+			"Missing 'new_string' argument",
+			"Invalid edit arguments.",
+			CodePermissionDenied, false)
+	}
+
+	path, denied := t.resolve(req, p, accessWrite)
+	if denied != nil {
+		return *denied
+	}
+	data, err := readTextFile(path)
+	if err != nil {
+		return fileError(req, fmt.Errorf("read %s: %w", p, err), "Could not read file.")
+	}
+	content := string(data)
+
+	var updated, summary string
+	if diff != "" {
+		hunks, err := parseHunks(diff)
+		if err != nil {
+			return fileError(req, fmt.Errorf("invalid diff: %w", err), "Could not apply diff.")
+		}
+		if updated, err = applyHunks(content, hunks); err != nil {
+			return fileError(req, err, "Could not apply diff.")
+		}
+		summary = fmt.Sprintf("Applied %d hunk(s) to %s", len(hunks), p)
+	} else {
+		n := strings.Count(content, oldStr)
+		switch {
+		case n == 0:
+			return fileError(req, fmt.Errorf("old_string not found in %s", p), "Text to replace not found.")
+		case n > 1 && !replaceAll:
+			return fileError(req,
+				fmt.Errorf("old_string occurs %d times in %s; add surrounding context to make it unique or set replace_all", n, p),
+				"Text to replace is not unique.")
+		}
+		if replaceAll {
+			updated = strings.ReplaceAll(content, oldStr, newStr)
+		} else {
+			updated = strings.Replace(content, oldStr, newStr, 1)
+			n = 1
+		}
+		summary = fmt.Sprintf("Replaced %d occurrence(s) in %s", n, p)
+	}
+
+	if err := writeFileAtomic(path, []byte(updated)); err != nil {
+		return fileError(req, fmt.Errorf("write %s: %w", p, err), "Could not write file.")
+	}
+	auditRef := t.audit(req, "edit_file", path, fmt.Sprintf("%s; bytes %d -> %d", summary, len(content), len(updated)))
+	return SuccessResponse(req.ToolCallID, summary, summary, auditRef)
+}
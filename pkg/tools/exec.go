@@ -7,69 +7,27 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
-	"runtime"
 	"strings"
 	"time"
 
 	"github.com/sypherexx/sypher-mini/pkg/audit"
 	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/executor"
 	"github.com/sypherexx/sypher-mini/pkg/process"
+	"github.com/sypherexx/sypher-mini/pkg/shell"
 )
 
-var defaultDenyPatterns = []*regexp.Regexp{
-	regexp.MustCompile(`\brm\s+-[rf]{1,2}\b`),
-	regexp.MustCompile(`\bdel\s+/[fq]\b`),
-	regexp.MustCompile(`\brmdir\s+/s\b`),
-	regexp.MustCompile(`\b(format|mkfs|diskpart)\b\s`),
-	regexp.MustCompile(`\bdd\s+if=`),
-	regexp.MustCompile(`>\s*/dev/sd[a-z]\b`),
-	regexp.MustCompile(`\b(shutdown|reboot|poweroff)\b`),
-	regexp.MustCompile(`:\(\)\s*\{.*\};\s*:`),
-	regexp.MustCompile(`\$\([^)]+\)`),
-	regexp.MustCompile(`\$\{[^}]+\}`),
-	regexp.MustCompile("`[^`]+`"),
-	regexp.MustCompile(`\|\s*sh\b`),
-	regexp.MustCompile(`\|\s*bash\b`),
-	regexp.MustCompile(`;\s*rm\s+-[rf]`),
-	regexp.MustCompile(`&&\s*rm\s+-[rf]`),
-	regexp.MustCompile(`\|\|\s*rm\s+-[rf]`),
-	regexp.MustCompile(`>\s*/dev/null\s*>&?\s*\d?`),
-	regexp.MustCompile(`<<\s*EOF`),
-	regexp.MustCompile(`\$\(\s*cat\s+`),
-	regexp.MustCompile(`\$\(\s*curl\s+`),
-	regexp.MustCompile(`\$\(\s*wget\s+`),
-	regexp.MustCompile(`\$\(\s*which\s+`),
-	regexp.MustCompile(`\bsudo\b`),
-	regexp.MustCompile(`\bchmod\s+[0-7]{3,4}\b`),
-	regexp.MustCompile(`\bchown\b`),
-	regexp.MustCompile(`\bpkill\b`),
-	regexp.MustCompile(`\bkillall\b`),
-	regexp.MustCompile(`\bkill\s+-[9]\b`),
-	regexp.MustCompile(`\bcurl\b.*\|\s*(sh|bash)`),
-	regexp.MustCompile(`\bwget\b.*\|\s*(sh|bash)`),
-	regexp.MustCompile(`\bnpm\s+install\s+-g\b`),
-	regexp.MustCompile(`\bpip\s+install\s+--user\b`),
-	regexp.MustCompile(`\bapt\s+(install|remove|purge)\b`),
-	regexp.MustCompile(`\byum\s+(install|remove)\b`),
-	regexp.MustCompile(`\bdnf\s+(install|remove)\b`),
-	regexp.MustCompile(`\bdocker\s+run\b`),
-	regexp.MustCompile(`\bdocker\s+exec\b`),
-	regexp.MustCompile(`\bgit\s+push\b`),
-	regexp.MustCompile(`\bgit\s+force\b`),
-	regexp.MustCompile(`\bssh\b.*@`),
-	regexp.MustCompile(`\beval\b`),
-	regexp.MustCompile(`\bsource\s+.*\.sh\b`),
-}
-
 // ExecTool executes shell commands with safety checks.
 type ExecTool struct {
+	cfg                 *config.Config
 	workingDir          string
 	timeout             time.Duration
-	denyPatterns        []*regexp.Regexp
+	guard               *commandGuard
+	env                 *CommandEnv
 	restrictToWorkspace bool
 	auditLogger         *audit.Logger
 	procTracker         *process.Tracker
+	jobs                *JobManager
 	authorizedTerms     []string
 	safeMode            bool
 }
@@ -79,16 +37,6 @@ func NewExecTool(cfg *config.Config, auditLogger *audit.Logger, procTracker *pro
 	if cfg == nil {
 		cfg = config.DefaultConfig()
 	}
-	denyPatterns := make([]*regexp.Regexp, 0, len(defaultDenyPatterns)+10)
-	if len(cfg.Tools.Exec.CustomDenyPatterns) > 0 {
-		for _, p := range cfg.Tools.Exec.CustomDenyPatterns {
-			if re, err := regexp.Compile(p); err == nil {
-				denyPatterns = append(denyPatterns, re)
-			}
-		}
-	}
-	denyPatterns = append(denyPatterns, defaultDenyPatterns...)
-
 	timeout := 60 * time.Second
 	if cfg != nil && cfg.Tools.Exec.TimeoutSec > 0 {
 		timeout = time.Duration(cfg.Tools.Exec.TimeoutSec) * time.Second
@@ -104,17 +52,54 @@ func NewExecTool(cfg *config.Config, auditLogger *audit.Logger, procTracker *pro
 	}
 
 	return &ExecTool{
+		cfg:                 cfg,
 		workingDir:          workspace,
 		timeout:             timeout,
-		denyPatterns:        denyPatterns,
+		guard:               newCommandGuard(cfg),
+		env:                 NewCommandEnv(cfg, nil, nil),
 		restrictToWorkspace: cfg.Agents.Defaults.RestrictToWorkspace,
 		auditLogger:         auditLogger,
 		procTracker:         procTracker,
+		jobs:                NewJobManager(procTracker, auditLogger),
 		authorizedTerms:     terms,
 		safeMode:            safeMode,
 	}
 }
 
+// SetCommandEnv replaces the builder of the commands' environment, e.g. to
+// share a redactor with the agent loop.
+func (t *ExecTool) SetCommandEnv(env *CommandEnv) { t.env = env }
+
+// Jobs returns the manager of the tool's background jobs.
+func (t *ExecTool) Jobs() *JobManager { return t.jobs }
+
+// Name returns the tool name.
+func (t *ExecTool) Name() string { return "exec" }
+
+// Description returns the tool description for the LLM.
+func (t *ExecTool) Description() string {
+	return "Execute a shell command and return its output. Use with caution. Commands run in the workspace. " +
+		"Set background to true for long-running commands (dev servers, watchers): it returns a job ID at once; use job_status, job_output and job_stop."
+}
+
+// Schema returns the JSON schema of the tool arguments.
+func (t *ExecTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"command":     map[string]interface{}{"type": "string", "description": "The shell command to run"},
+			"working_dir": map[string]interface{}{"type": "string", "description": "Working directory (optional)"},
+			"background":  map[string]interface{}{"type": "boolean", "description": "Run in the background and return a job ID (stopped when the task ends)"},
+		},
+		"required": []interface{}{"command"},
+	}
+}
+
+// Capabilities returns the capabilities this tool provides.
+func (t *ExecTool) Capabilities() []string {
+	return []string{"code_generation", "log_analysis", "deploy_service"}
+}
+
 // Execute runs a command and returns a tool response.
 func (t *ExecTool) Execute(ctx context.Context, req Request) Response {
 	if t.safeMode {
@@ -132,30 +117,32 @@ func (t *ExecTool) Execute(ctx context.Context, req Request) Response {
 			CodePermissionDenied, false)
 	}
 
+	workspace := agentWorkspace(t.cfg, req.AgentID, t.workingDir)
 	workingDir, _ := req.Args["working_dir"].(string)
 	if workingDir == "" {
-		workingDir = t.workingDir
+		workingDir = workspace
 	}
 	workingDir = config.ExpandPath(workingDir)
 
-	// Deny pattern check
-	for _, re := range t.denyPatterns {
-		if re.MatchString(cmdStr) {
-			return ErrorResponse(req.ToolCallID,
-				"Command blocked by safety guard (dangerous pattern detected)",
-				"Command was blocked for safety.",
-				CodeSafetyBlocked, false)
-		}
+	// Per-agent allowlist (agents.list[].allowed_commands)
+	if a := t.cfg.AgentByID(req.AgentID); a != nil && a.AllowedCommands != nil && !commandAllowed(a.AllowedCommands, cmdStr) {
+		return ErrorResponse(req.ToolCallID,
+			"Command not in allowed_commands for agent "+req.AgentID,
+			"Command is not allowed for this agent.",
+			CodePermissionDenied, false)
+	}
+
+	// Structured command rules (and custom deny patterns)
+	if reason := t.guard.check(cmdStr); reason != "" {
+		return ErrorResponse(req.ToolCallID,
+			"Command blocked by safety guard: "+reason,
+			"Command was blocked for safety.",
+			CodeSafetyBlocked, false)
 	}
 
 	// Workspace restriction
 	if t.restrictToWorkspace {
-		abs, err := filepath.Abs(workingDir)
-		if err != nil {
-			abs = workingDir
-		}
-		wsAbs, _ := filepath.Abs(t.workingDir)
-		if !strings.HasPrefix(abs, wsAbs) {
+		if !inWorkspace(workingDir, workspace) {
 			return ErrorResponse(req.ToolCallID,
 				"Working directory outside workspace",
 				"Command blocked: working directory outside allowed workspace.",
@@ -163,17 +150,23 @@ func (t *ExecTool) Execute(ctx context.Context, req Request) Response {
 		}
 	}
 
+	if background, _ := req.Args["background"].(bool); background {
+		return t.startJob(req, cmdStr, workingDir, workspace)
+	}
+
 	// Build command with timeout
 	runCtx, cancel := context.WithTimeout(ctx, t.timeout)
 	defer cancel()
 
-	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		cmd = exec.CommandContext(runCtx, "cmd", "/c", cmdStr)
-	} else {
-		cmd = exec.CommandContext(runCtx, "sh", "-c", cmdStr)
+	cmd, cleanup, errResp := t.command(runCtx, req, cmdStr, workingDir, workspace)
+	if errResp != nil {
+		return *errResp
 	}
-	cmd.Dir = workingDir
+	defer cleanup()
+	// Own process group: a timeout kills the shell and everything it started
+	process.SetGroup(cmd)
+	cmd.Cancel = func() error { return process.KillGroup(cmd.Process.Pid) }
+	cmd.WaitDelay = time.Second
 
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
@@ -190,10 +183,14 @@ func (t *ExecTool) Execute(ctx context.Context, req Request) Response {
 	// Record PID
 	pid := cmd.Process.Pid
 	if t.procTracker != nil {
-		t.procTracker.Record(req.TaskID, pid)
+		t.procTracker.RecordGroup(req.TaskID, pid)
 	}
 
 	err = cmd.Wait()
+	// Keep the group tracked only while something in it (e.g. "server &") survives
+	if t.procTracker != nil && !process.Alive(pid) {
+		t.procTracker.Forget(req.TaskID, pid)
+	}
 	exitCode := 0
 	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
@@ -227,3 +224,124 @@ func (t *ExecTool) Execute(ctx context.Context, req Request) Response {
 
 	return SuccessResponse(req.ToolCallID, forLLM, forUser, auditRef)
 }
+
+// startJob runs cmdStr as a background job of the task. It is not bound to
+// the exec timeout; the job runs until it exits, is stopped, or the task ends.
+func (t *ExecTool) startJob(req Request, cmdStr, workingDir, workspace string) Response {
+	cmd, cleanup, errResp := t.command(context.Background(), req, cmdStr, workingDir, workspace)
+	if errResp != nil {
+		return *errResp
+	}
+
+	job, err := t.jobs.Start(req.TaskID, req.ToolCallID, cmdStr, cmd, cleanup)
+	if err != nil {
+		cleanup()
+		return ErrorResponse(req.ToolCallID,
+			fmt.Sprintf("Failed to start: %v", err),
+			"Command failed to start.",
+			CodePermissionDenied, false)
+	}
+	return SuccessResponse(req.ToolCallID,
+		fmt.Sprintf("Started background job %s (pid %d). Use job_output to read its output and job_stop to stop it.", job.ID, job.PID),
+		fmt.Sprintf("Started %s (pid %d)", job.ID, job.PID),
+		fmt.Sprintf("audit/%s.log", req.TaskID))
+}
+
+// command builds the process for cmdStr with the agent's executor (host or
+// sandbox, see tools.exec.sandbox).
+func (t *ExecTool) command(ctx context.Context, req Request, cmdStr, workingDir, workspace string) (*exec.Cmd, func(), *Response) {
+	return executorCommand(ctx, t.cfg, t.env, req, executor.Spec{Command: cmdStr, Dir: workingDir, Workspace: workspace})
+}
+
+// executorCommand builds spec with the executor selected for the agent and
+// the environment env gives it.
+func executorCommand(ctx context.Context, cfg *config.Config, env *CommandEnv, req Request, spec executor.Spec) (*exec.Cmd, func(), *Response) {
+	environ, err := env.For(req.AgentID)
+	if err != nil {
+		resp := ErrorResponse(req.ToolCallID,
+			fmt.Sprintf("Command environment unavailable: %v", err),
+			"A secret configured for this agent is not available.",
+			CodePermissionDenied, false)
+		return nil, nil, &resp
+	}
+	spec.Env = environ
+	ex, err := executor.ForAgent(cfg, req.AgentID)
+	if err == nil {
+		var cmd *exec.Cmd
+		var cleanup func()
+		if cmd, cleanup, err = ex.Command(ctx, spec); err == nil {
+			return cmd, cleanup, nil
+		}
+	}
+	resp := ErrorResponse(req.ToolCallID,
+		fmt.Sprintf("Executor unavailable: %v", err),
+		"Command could not be run in the configured sandbox.",
+		CodePermissionDenied, false)
+	return nil, nil, &resp
+}
+
+// inWorkspace reports whether path is workspace or inside it. A sibling that
+// merely shares the prefix ("/work/ws-evil" for "/work/ws") is outside.
+func inWorkspace(path, workspace string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	wsAbs, err := filepath.Abs(workspace)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(wsAbs, abs)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// agentWorkspace returns the workspace of agentID, or fallback when none is configured.
+func agentWorkspace(cfg *config.Config, agentID, fallback string) string {
+	if ws := cfg.AgentWorkspace(agentID); ws != "" {
+		return ws
+	}
+	return fallback
+}
+
+// commandAllowed reports whether every simple command in cmd, including those
+// inside substitutions, starts with an allowed prefix of literal words. "*"
+// allows everything; an empty list allows nothing. A command line that does
+// not parse is not allowed.
+func commandAllowed(allowed []string, cmd string) bool {
+	for _, a := range allowed {
+		if a == "*" {
+			return true
+		}
+	}
+	script, err := shell.Parse(cmd)
+	if err != nil {
+		return false
+	}
+	for _, c := range script.Commands {
+		ok := false
+		for _, a := range allowed {
+			if commandHasPrefix(c, strings.Fields(a)) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// commandHasPrefix reports whether c runs the words of prefix. Leading
+// assignments and expanded words never match, since they can change what runs.
+func commandHasPrefix(c *shell.Command, prefix []string) bool {
+	if len(prefix) == 0 || len(c.Assigns) > 0 || len(c.Args) < len(prefix) {
+		return false
+	}
+	for i, w := range prefix {
+		if c.Args[i].Dynamic || c.Args[i].Lit != w {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/secrets"
+)
+
+// defaultEnvAllowlist is what exec and stream_command inherit from the server
+// environment. Everything else, provider API keys included, is dropped.
+var defaultEnvAllowlist = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "LANG", "LANGUAGE", "LC_*", "TERM", "TZ", "TMPDIR",
+	// Windows
+	"SYSTEMROOT", "SYSTEMDRIVE", "WINDIR", "COMSPEC", "PATHEXT", "TEMP", "TMP",
+	"USERPROFILE", "APPDATA", "LOCALAPPDATA", "PROGRAMDATA", "PROGRAMFILES", "PROGRAMFILES(X86)",
+	"NUMBER_OF_PROCESSORS", "PROCESSOR_ARCHITECTURE",
+}
+
+// CommandEnv builds the environment of exec and stream_command processes:
+// allowlisted server variables, tools.exec.env.set, then the agent's secrets
+// (agents.list[].secrets) read through the resolver. Injected secret values
+// are added to the redactor so they do not come back in tool output.
+type CommandEnv struct {
+	allow    []string
+	set      map[string]string
+	cfg      *config.Config
+	resolver *secrets.Resolver
+	redactor *secrets.Redactor
+}
+
+// NewCommandEnv creates a CommandEnv. A nil resolver uses the config's
+// (secrets.backend); redactor may be nil.
+func NewCommandEnv(cfg *config.Config, resolver *secrets.Resolver, redactor *secrets.Redactor) *CommandEnv {
+	if resolver == nil {
+		resolver = cfg.SecretResolver()
+	}
+	ec := cfg.Tools.Exec.Env
+	return &CommandEnv{
+		allow:    append(append([]string{}, defaultEnvAllowlist...), ec.Allow...),
+		set:      ec.Set,
+		cfg:      cfg,
+		resolver: resolver,
+		redactor: redactor,
+	}
+}
+
+// Redactor returns the redactor injected secrets are added to, or nil.
+func (e *CommandEnv) Redactor() *secrets.Redactor {
+	if e == nil {
+		return nil
+	}
+	return e.redactor
+}
+
+// For returns the environment ("NAME=value") for the agent's commands. It
+// fails when a secret configured for the agent cannot be resolved.
+func (e *CommandEnv) For(agentID string) ([]string, error) {
+	vars := make(map[string]string)
+	for _, kv := range os.Environ() {
+		name, value, ok := strings.Cut(kv, "=")
+		if ok && name != "" && envAllowed(e.allow, name) {
+			vars[name] = value
+		}
+	}
+	for name, value := range e.set {
+		vars[name] = value
+	}
+	if a := e.cfg.AgentByID(agentID); a != nil {
+		for name, secret := range a.Secrets {
+			value := e.resolver.Get(secret)
+			if value == "" {
+				return nil, fmt.Errorf("secret %q for %s is not set", secret, name)
+			}
+			vars[name] = value
+			if e.redactor != nil {
+				e.redactor.Add(value)
+			}
+		}
+	}
+
+	env := make([]string, 0, len(vars))
+	for name, value := range vars {
+		env = append(env, name+"="+value)
+	}
+	sort.Strings(env)
+	return env, nil
+}
+
+// envAllowed matches name against the allowlist; "LC_*" matches a prefix.
+// Names compare case-insensitively, as on Windows.
+func envAllowed(allow []string, name string) bool {
+	for _, a := range allow {
+		if prefix, ok := strings.CutSuffix(a, "*"); ok {
+			if len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+				return true
+			}
+		} else if strings.EqualFold(a, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/secrets"
+)
+
+func TestCommandEnv(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "sk-ant-0123456789")
+	t.Setenv("LC_ALL", "C")
+	t.Setenv("GOPATH", "/go")
+	t.Setenv("SYPHER_TEST_NPM", "npm-0123456789")
+	cfg := config.DefaultConfig()
+	cfg.Tools.Exec.Env = config.ExecEnvConfig{Allow: []string{"GO*"}, Set: map[string]string{"CI": "1"}}
+	cfg.Agents.List = []config.AgentConfig{
+		{ID: "publisher", Secrets: map[string]string{"NPM_TOKEN": "SYPHER_TEST_NPM"}},
+		{ID: "broken", Secrets: map[string]string{"X_TOKEN": "SYPHER_TEST_MISSING"}},
+	}
+	redactor := secrets.NewRedactor()
+	e := NewCommandEnv(cfg, nil, redactor)
+
+	env, err := e.For("publisher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined := "\n" + strings.Join(env, "\n") + "\n"
+	for _, want := range []string{"\nLC_ALL=C\n", "\nGOPATH=/go\n", "\nCI=1\n", "\nNPM_TOKEN=npm-0123456789\n"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("missing %q in %v", strings.TrimSpace(want), env)
+		}
+	}
+	if strings.Contains(joined, "ANTHROPIC_API_KEY") || strings.Contains(joined, "SYPHER_TEST_NPM") {
+		t.Errorf("unlisted variables should be dropped: %v", env)
+	}
+	if got := redactor.Redact("token npm-0123456789"); got != "token "+secrets.Placeholder {
+		t.Errorf("injected secret should be redacted, got %q", got)
+	}
+
+	env, _ = e.For("main")
+	if strings.Contains(strings.Join(env, "\n"), "NPM_TOKEN") {
+		t.Error("secrets are per agent")
+	}
+	if _, err := e.For("broken"); err == nil {
+		t.Error("missing secret should be an error")
+	}
+}
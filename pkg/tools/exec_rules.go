@@ -0,0 +1,464 @@
+package tools
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/shell"
+)
+
+// defaultCommandRules are the built-in per-executable rules. A rule of the
+// same name in tools.exec.rules.commands replaces the built-in one.
+var defaultCommandRules = map[string]config.CommandRule{
+	"rm":       {ForbiddenFlags: []string{"-r", "-R", "-f", "--recursive", "--force"}},
+	"del":      {ForbiddenFlags: []string{"/f", "/q"}},
+	"rmdir":    {ForbiddenFlags: []string{"/s"}},
+	"format":   {Deny: true},
+	"mkfs":     {Deny: true},
+	"diskpart": {Deny: true},
+	"dd":       {ForbiddenArgs: []string{"if=*", "of=/dev/*"}},
+	"shutdown": {Deny: true},
+	"reboot":   {Deny: true},
+	"poweroff": {Deny: true},
+	"sudo":     {Deny: true},
+	"chmod":    {ForbiddenArgs: chmodModes},
+	"chown":    {Deny: true},
+	"pkill":    {Deny: true},
+	"killall":  {Deny: true},
+	"kill":     {ForbiddenFlags: []string{"-9", "-KILL", "-SIGKILL", "-kill", "-sigkill"}, ForbiddenValues: killSignals},
+	"eval":     {Deny: true},
+	"source":   {Deny: true},
+	".":        {Deny: true},
+	"ssh":      {ForbiddenArgs: []string{"*@*"}},
+	"npm": {ValueFlags: []string{"--prefix", "--registry", "--userconfig", "--cache", "-w", "--workspace"}, Subcommands: map[string]config.CommandRule{
+		"install": {ForbiddenFlags: []string{"-g", "--global"}},
+		"i":       {ForbiddenFlags: []string{"-g", "--global"}},
+	}},
+	"pip":     {ValueFlags: pipValueFlags, Subcommands: map[string]config.CommandRule{"install": {ForbiddenFlags: []string{"--user"}}}},
+	"pip3":    {ValueFlags: pipValueFlags, Subcommands: map[string]config.CommandRule{"install": {ForbiddenFlags: []string{"--user"}}}},
+	"apt":     {ValueFlags: aptValueFlags, Subcommands: denySubcommands("install", "remove", "purge")},
+	"apt-get": {ValueFlags: aptValueFlags, Subcommands: denySubcommands("install", "remove", "purge")},
+	"yum":     {ValueFlags: dnfValueFlags, Subcommands: denySubcommands("install", "remove")},
+	"dnf":     {ValueFlags: dnfValueFlags, Subcommands: denySubcommands("install", "remove")},
+	"docker":  {ValueFlags: dockerValueFlags, Subcommands: dockerSubcommands},
+	"git":     {ValueFlags: []string{"-C", "-c", "--git-dir", "--work-tree", "--namespace", "--config-env"}, Subcommands: denySubcommands("push")},
+	"sh":      {DenyPipeInput: true},
+	"bash":    {DenyPipeInput: true},
+	"dash":    {DenyPipeInput: true},
+	"zsh":     {DenyPipeInput: true},
+	"ksh":     {DenyPipeInput: true},
+}
+
+var (
+	// chmodModes are octal modes and symbolic modes granting world write or setuid/setgid
+	chmodModes = []string{"[0-7][0-7][0-7]", "[0-7][0-7][0-7][0-7]", "+*w*", "=*w*", "*[ao][+=]*w*", "*[+=]*s*"}
+	// killSignals are the ways to name SIGKILL with -s, -n and --signal
+	killSignals       = map[string][]string{"-s": {"KILL", "SIGKILL", "9"}, "-n": {"9"}, "--signal": {"KILL", "SIGKILL", "9"}}
+	dockerSubcommands = map[string]config.CommandRule{
+		"run":       {Deny: true},
+		"exec":      {Deny: true},
+		"container": {Subcommands: denySubcommands("run", "exec", "create", "start")},
+	}
+
+	// Flags that take a separate value before the subcommand, so the value
+	// is not mistaken for it ("docker -H host run").
+	dockerValueFlags = []string{"-H", "--host", "-c", "--context", "--config", "-l", "--log-level", "--tlscacert", "--tlscert", "--tlskey"}
+	pipValueFlags    = []string{"--proxy", "--cache-dir", "--log", "--timeout", "--retries", "--exists-action", "--trusted-host", "--cert", "--client-cert", "--python"}
+	aptValueFlags    = []string{"-o", "--option", "-c", "--config-file", "-t", "--target-release"}
+	dnfValueFlags    = []string{"-c", "--config", "--installroot", "--releasever", "--setopt", "-d", "--debuglevel", "-e", "--errorlevel", "--enablerepo", "--disablerepo", "-x", "--exclude"}
+)
+
+// defaultDenyRedirectTargets keep output redirection off block devices.
+var defaultDenyRedirectTargets = []string{"/dev/sd*", "/dev/hd*", "/dev/vd*", "/dev/nvme*", "/dev/mmcblk*"}
+
+// shellNames run the argument of -c as a command line, which is checked too.
+var shellNames = map[string]bool{"sh": true, "bash": true, "dash": true, "zsh": true, "ksh": true}
+
+// wrapperNames run another command given in their arguments. true: the
+// first non-option argument is that command (env, timeout, busybox, ...), and
+// every later candidate is checked too; false: the commands follow -exec and
+// similar flags (find).
+var wrapperNames = map[string]bool{
+	"env": true, "nice": true, "nohup": true, "time": true, "timeout": true, "xargs": true,
+	"command": true, "exec": true, "builtin": true, "stdbuf": true, "ionice": true, "setsid": true,
+	"busybox": true, "toybox": true,
+	"find": false,
+}
+
+// execFlags introduce the command run by find.
+var execFlags = map[string]bool{"-exec": true, "-execdir": true, "-ok": true, "-okdir": true}
+
+const maxShellNesting = 4
+
+var durationArg = regexp.MustCompile(`^[0-9.]+[smhd]?$`)
+
+func denySubcommands(names ...string) map[string]config.CommandRule {
+	m := make(map[string]config.CommandRule, len(names))
+	for _, n := range names {
+		m[n] = config.CommandRule{Deny: true}
+	}
+	return m
+}
+
+// commandGuard decides whether exec and stream_command may run a command
+// line. The line is parsed into simple commands and each is checked against
+// the rule of its executable; custom_deny_patterns still match the raw line.
+type commandGuard struct {
+	customPatterns  []*regexp.Regexp
+	allowed         map[string]bool
+	rules           map[string]config.CommandRule
+	redirectTargets []string
+	allowRedirects  bool
+	allowSubshells  bool
+}
+
+func newCommandGuard(cfg *config.Config) *commandGuard {
+	rc := cfg.Tools.Exec.Rules
+	g := &commandGuard{
+		rules:           make(map[string]config.CommandRule, len(defaultCommandRules)+len(rc.Commands)),
+		redirectTargets: append(append([]string{}, defaultDenyRedirectTargets...), rc.DenyRedirectTargets...),
+		allowRedirects:  rc.AllowRedirects == nil || *rc.AllowRedirects,
+		allowSubshells:  rc.AllowSubshells == nil || *rc.AllowSubshells,
+	}
+	for _, p := range cfg.Tools.Exec.CustomDenyPatterns {
+		if re, err := regexp.Compile(p); err == nil {
+			g.customPatterns = append(g.customPatterns, re)
+		}
+	}
+	if len(rc.AllowedExecutables) > 0 {
+		g.allowed = make(map[string]bool, len(rc.AllowedExecutables))
+		for _, name := range rc.AllowedExecutables {
+			g.allowed[name] = true
+		}
+	}
+	for name, r := range defaultCommandRules {
+		g.rules[name] = r
+	}
+	for name, r := range rc.Commands {
+		g.rules[name] = r
+	}
+	return g
+}
+
+// check returns why cmdLine is blocked, or "" when it may run.
+func (g *commandGuard) check(cmdLine string) string {
+	for _, re := range g.customPatterns {
+		if re.MatchString(cmdLine) {
+			return "matches custom deny pattern " + re.String()
+		}
+	}
+	return g.checkScript(cmdLine, 0)
+}
+
+func (g *commandGuard) checkScript(src string, depth int) string {
+	script, err := shell.Parse(src)
+	if err != nil {
+		return "cannot analyse command: " + err.Error()
+	}
+	if !g.allowSubshells && script.Subshells > 0 {
+		return "subshells and command substitution are not allowed"
+	}
+	for _, c := range script.Commands {
+		if reason := g.checkCommand(c, depth); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+func (g *commandGuard) checkCommand(c *shell.Command, depth int) string {
+	for _, r := range c.Redirects {
+		for _, pat := range g.redirectTargets {
+			if m, _ := path.Match(pat, r.Target.Lit); m {
+				return fmt.Sprintf("redirection to %s is not allowed", r.Target.Lit)
+			}
+		}
+	}
+	if len(c.Args) == 0 {
+		if !g.allowRedirects && len(c.Redirects) > 0 {
+			return "redirections are not allowed"
+		}
+		return ""
+	}
+	return g.checkExec(c, c.Args, depth, true)
+}
+
+// checkExec checks args as a command run by c. The executable allowlist only
+// applies when strict, i.e. to the command actually run, not to every
+// candidate found in a wrapper's arguments.
+func (g *commandGuard) checkExec(c *shell.Command, args []shell.Word, depth int, strict bool) string {
+	if args[0].Dynamic {
+		return fmt.Sprintf("executable %q is an expansion; only literal command names can be checked", args[0].Lit)
+	}
+	name := executableName(args[0].Lit)
+	if strict && g.allowed != nil && !g.allowed[name] {
+		return name + " is not in allowed_executables"
+	}
+	if reason := g.applyRule(name, g.rule(name), args[1:], c); reason != "" {
+		return reason
+	}
+
+	if shellNames[name] {
+		script, ok := shellScriptArg(args[1:])
+		if !ok {
+			return ""
+		}
+		if script.Dynamic {
+			return name + " -c with an expanded script cannot be checked"
+		}
+		if depth >= maxShellNesting {
+			return "shell nesting too deep"
+		}
+		return g.checkScript(script.Lit, depth+1)
+	}
+	direct, isWrapper := wrapperNames[name]
+	if !isWrapper {
+		return ""
+	}
+	real := direct
+	for i := 1; i < len(args); i++ {
+		a := args[i]
+		if !direct && !execFlags[args[i-1].Lit] {
+			continue
+		}
+		isCmd := real && !strings.Contains(a.Lit, "=") && !durationArg.MatchString(a.Lit)
+		// A glob or expansion in the command position is refused by checkExec
+		if strings.HasPrefix(a.Lit, "-") || (a.Dynamic && !isCmd && direct) {
+			continue
+		}
+		if reason := g.checkExec(c, args[i:], depth, strict && isCmd); reason != "" {
+			return reason
+		}
+		if isCmd {
+			real = false
+		}
+	}
+	return ""
+}
+
+// rule returns the rule of the executable; "mkfs.ext4" falls back to "mkfs".
+func (g *commandGuard) rule(name string) config.CommandRule {
+	if r, ok := g.rules[name]; ok {
+		return r
+	}
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		return g.rules[name[:i]]
+	}
+	return config.CommandRule{}
+}
+
+// applyRule checks the arguments against the rule and the rules of the
+// subcommands they select.
+func (g *commandGuard) applyRule(name string, rule config.CommandRule, args []shell.Word, c *shell.Command) string {
+	allowRedirects, allowSubshells := g.allowRedirects, g.allowSubshells
+	all := args
+	for {
+		if rule.Deny {
+			return name + " is not allowed"
+		}
+		if rule.DenyPipeInput && c.Piped {
+			return "piping into " + name + " is not allowed"
+		}
+		if rule.AllowRedirects != nil {
+			allowRedirects = *rule.AllowRedirects
+		}
+		if rule.AllowSubshells != nil {
+			allowSubshells = *rule.AllowSubshells
+		}
+		if reason := checkArgs(name, rule, all); reason != "" {
+			return reason
+		}
+		if len(rule.Subcommands) == 0 {
+			break
+		}
+		sub, rest, found := subcommand(rule, args)
+		if sub != nil && sub.Dynamic {
+			return fmt.Sprintf("%s subcommand %q is an expansion and cannot be checked", name, sub.Lit)
+		}
+		if !found {
+			break
+		}
+		name, rule, args = name+" "+sub.Lit, rule.Subcommands[sub.Lit], rest
+	}
+	if !allowRedirects && len(c.Redirects) > 0 {
+		return "redirections are not allowed for " + name
+	}
+	if !allowSubshells && c.Substitution {
+		return "command substitution is not allowed in " + name + " arguments"
+	}
+	return ""
+}
+
+// checkArgs checks every argument against the rule's forbidden flags, flag
+// values and argument globs. Flags end at "--"; argument globs apply to every
+// argument. A dynamic argument that could become a flag at runtime is refused
+// when the rule restricts flags, and any dynamic argument when it restricts
+// argument values, since its expansion cannot be matched.
+func checkArgs(name string, rule config.CommandRule, args []shell.Word) string {
+	if len(rule.ForbiddenFlags) == 0 && len(rule.ForbiddenArgs) == 0 && len(rule.ForbiddenValues) == 0 {
+		return ""
+	}
+	flags := true
+	for i, a := range args {
+		if flags && a.Lit == "--" && !a.Dynamic {
+			flags = false
+			continue
+		}
+		if a.Dynamic && (len(rule.ForbiddenArgs) > 0 || (flags && mayBeFlag(a.Lit))) {
+			return fmt.Sprintf("%s argument %q is an expansion and cannot be checked", name, a.Lit)
+		}
+		if !flags {
+			if reason := forbiddenArg(name, rule, a.Lit); reason != "" {
+				return reason
+			}
+			continue
+		}
+		for _, f := range rule.ForbiddenFlags {
+			if flagMatches(a.Lit, f) {
+				return fmt.Sprintf("%s %s is not allowed", name, f)
+			}
+		}
+		for flag, pats := range rule.ForbiddenValues {
+			value, ok := flagValue(args, i, flag)
+			if !ok {
+				continue
+			}
+			if value.Dynamic {
+				return fmt.Sprintf("%s %s value %q is an expansion and cannot be checked", name, flag, value.Lit)
+			}
+			for _, pat := range pats {
+				if argMatches(strings.ToLower(pat), strings.ToLower(value.Lit)) {
+					return fmt.Sprintf("%s %s %s is not allowed", name, flag, value.Lit)
+				}
+			}
+		}
+		if reason := forbiddenArg(name, rule, a.Lit); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+func forbiddenArg(name string, rule config.CommandRule, arg string) string {
+	for _, pat := range rule.ForbiddenArgs {
+		if argMatches(pat, arg) {
+			return fmt.Sprintf("%s %s is not allowed", name, arg)
+		}
+	}
+	return ""
+}
+
+// mayBeFlag reports whether a dynamic argument could expand to something
+// starting with "-": it starts with a dash, an expansion, a glob or a brace.
+func mayBeFlag(lit string) bool {
+	return lit == "" || strings.ContainsRune("-$`*?[{<>", rune(lit[0]))
+}
+
+// flagValue returns the value given to flag by args[i]: the next argument
+// ("-s KILL"), after "=" ("--signal=KILL") or attached to a short flag
+// ("-sKILL").
+func flagValue(args []shell.Word, i int, flag string) (shell.Word, bool) {
+	a := args[i]
+	switch {
+	case a.Lit == flag:
+		if i+1 < len(args) {
+			return args[i+1], true
+		}
+	case strings.HasPrefix(a.Lit, flag+"="):
+		return shell.Word{Lit: a.Lit[len(flag)+1:], Dynamic: a.Dynamic}, true
+	case len(flag) == 2 && flag[0] == '-' && flag[1] != '-' && strings.HasPrefix(a.Lit, flag) && len(a.Lit) > 2:
+		return shell.Word{Lit: a.Lit[2:], Dynamic: a.Dynamic}, true
+	}
+	return shell.Word{}, false
+}
+
+// subcommand returns the first non-flag argument, skipping the values of
+// rule.ValueFlags, and whether the rule has a subcommand rule for it.
+func subcommand(rule config.CommandRule, args []shell.Word) (*shell.Word, []shell.Word, bool) {
+	for i := 0; i < len(args); i++ {
+		a := args[i].Lit
+		if containsString(rule.ValueFlags, a) {
+			i++
+			continue
+		}
+		if strings.HasPrefix(a, "-") {
+			continue
+		}
+		_, ok := rule.Subcommands[a]
+		return &args[i], args[i+1:], ok
+	}
+	return nil, nil, false
+}
+
+// flagMatches reports whether arg is flag: exactly, as "--flag=value", as
+// part of a short option cluster ("-r" in "-rf"), or case-insensitively for
+// Windows "/x" switches.
+func flagMatches(arg, flag string) bool {
+	if arg == flag || strings.HasPrefix(arg, flag+"=") {
+		return true
+	}
+	if strings.HasPrefix(flag, "/") {
+		return strings.EqualFold(arg, flag)
+	}
+	if len(flag) == 2 && flag[0] == '-' && flag[1] != '-' && len(arg) > 2 && arg[0] == '-' && arg[1] != '-' {
+		return strings.IndexByte(arg[1:], flag[1]) >= 0
+	}
+	return false
+}
+
+// argMatches matches a glob against an argument. Unlike path.Match, "*" also
+// matches "/", so "if=*" covers "if=/dev/zero".
+func argMatches(pattern, arg string) bool {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '[':
+			if j := strings.IndexByte(pattern[i:], ']'); j > 0 {
+				b.WriteString(pattern[i : i+j+1])
+				i += j
+				continue
+			}
+			b.WriteString(`\[`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	return err == nil && re.MatchString(arg)
+}
+
+// shellScriptArg returns the script of "sh -c script" (also "-ec", "-lc").
+func shellScriptArg(args []shell.Word) (shell.Word, bool) {
+	for i, a := range args {
+		if strings.HasPrefix(a.Lit, "-") && !strings.HasPrefix(a.Lit, "--") && strings.Contains(a.Lit, "c") && i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+	return shell.Word{}, false
+}
+
+// executableName strips the directory and a Windows .exe suffix.
+func executableName(lit string) string {
+	if i := strings.LastIndexAny(lit, `/\`); i >= 0 && i < len(lit)-1 {
+		lit = lit[i+1:]
+	}
+	return strings.TrimSuffix(lit, ".exe")
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
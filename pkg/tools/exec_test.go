@@ -2,7 +2,10 @@ package tools
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/sypherexx/sypher-mini/pkg/audit"
@@ -78,3 +81,126 @@ func TestExecTool_Success(t *testing.T) {
 		t.Errorf("unexpected error: %s", resp.ForLLM)
 	}
 }
+
+func TestExecTool_AgentAllowedCommands(t *testing.T) {
+	cfg := config.DefaultConfig()
+	auditDir := t.TempDir()
+	cfg.Audit.Dir = auditDir
+	workspace := t.TempDir()
+	cfg.Agents.List = append(cfg.Agents.List, config.AgentConfig{
+		ID:              "ops",
+		Workspace:       workspace,
+		AllowedCommands: []string{"echo", "go test"},
+	})
+	exec := NewExecTool(cfg, audit.New(auditDir), process.New(), false)
+
+	run := func(cmd string) Response {
+		return exec.Execute(context.Background(), Request{
+			ToolCallID: "tc1",
+			TaskID:     "t1",
+			AgentID:    "ops",
+			Name:       "exec",
+			Args:       map[string]interface{}{"command": cmd},
+		})
+	}
+	if resp := run("echo ok"); resp.IsError {
+		t.Errorf("echo should be allowed: %s", resp.ForLLM)
+	}
+	if resp := run("echo ok && ls"); resp.Code != CodePermissionDenied {
+		t.Errorf("ls should be rejected, got %+v", resp)
+	}
+	if resp := run("go build ./..."); resp.Code != CodePermissionDenied {
+		t.Errorf("go build should be rejected, got %+v", resp)
+	}
+	for _, cmd := range []string{
+		"echo $(rm -rf x)",
+		"echo `ls`",
+		"echo ok & ls",
+		"echo <(ls)",
+		"FOO=1 go test",
+		"$CMD test",
+		"echo 'unterminated",
+	} {
+		if resp := run(cmd); resp.Code != CodePermissionDenied {
+			t.Errorf("%q should be rejected, got %+v", cmd, resp)
+		}
+	}
+	if resp := run("echo a | echo b"); resp.Code == CodePermissionDenied {
+		t.Errorf("allowed pipeline rejected: %s", resp.ForLLM)
+	}
+}
+
+func TestExecTool_WorkingDirOutsideWorkspace(t *testing.T) {
+	cfg := config.DefaultConfig()
+	root := t.TempDir()
+	workspace := filepath.Join(root, "ws")
+	for _, dir := range []string{workspace, filepath.Join(workspace, "sub"), filepath.Join(root, "ws-evil")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg.Agents.Defaults.Workspace = workspace
+	exec := NewExecTool(cfg, nil, process.New(), false)
+
+	for dir, allowed := range map[string]bool{
+		filepath.Join(workspace, "sub"): true,
+		filepath.Join(root, "ws-evil"):  false,
+		root:                            false,
+	} {
+		resp := exec.Execute(context.Background(), Request{
+			ToolCallID: "tc1", TaskID: "t1", AgentID: "main",
+			Args: map[string]interface{}{"command": "echo hi", "working_dir": dir},
+		})
+		if denied := resp.Code == CodePermissionDenied; denied == allowed {
+			t.Errorf("working_dir %s: allowed=%v, got %+v", dir, allowed, resp)
+		}
+	}
+}
+
+func TestExecTool_SandboxUnavailable(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	cfg.Tools.Exec.Sandbox.Backend = "bwrap"
+	cfg.Tools.Exec.Sandbox.BwrapPath = "/nonexistent/bwrap"
+	exec := NewExecTool(cfg, nil, process.New(), false)
+
+	for _, bg := range []bool{false, true} {
+		resp := exec.Execute(context.Background(), Request{
+			ToolCallID: "tc1", TaskID: "t1", AgentID: "main",
+			Args: map[string]interface{}{"command": "echo hi", "background": bg},
+		})
+		if !resp.IsError || resp.Code != CodePermissionDenied {
+			t.Errorf("background=%v: command must not run on the host when the sandbox is unavailable: %+v", bg, resp)
+		}
+	}
+}
+
+func TestExecTool_Environment(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses env")
+	}
+	t.Setenv("OPENAI_API_KEY", "sk-leak-0123456789")
+	t.Setenv("SYPHER_TEST_DEPLOY", "deploy-0123456789")
+	cfg := config.DefaultConfig()
+	workspace := t.TempDir()
+	cfg.Agents.List = append(cfg.Agents.List, config.AgentConfig{
+		ID:        "ops",
+		Workspace: workspace,
+		Secrets:   map[string]string{"DEPLOY_TOKEN": "SYPHER_TEST_DEPLOY"},
+	})
+	exec := NewExecTool(cfg, nil, process.New(), false)
+
+	resp := exec.Execute(context.Background(), Request{
+		ToolCallID: "tc1", TaskID: "t1", AgentID: "ops",
+		Args: map[string]interface{}{"command": "env"},
+	})
+	if resp.IsError {
+		t.Fatalf("unexpected error: %s", resp.ForLLM)
+	}
+	if strings.Contains(resp.ForLLM, "sk-leak") || strings.Contains(resp.ForLLM, "SYPHER_TEST_DEPLOY") {
+		t.Errorf("server environment leaked into the command:\n%s", resp.ForLLM)
+	}
+	if !strings.Contains(resp.ForLLM, "DEPLOY_TOKEN=deploy-0123456789") || !strings.Contains(resp.ForLLM, "PATH=") {
+		t.Errorf("expected PATH and the injected secret:\n%s", resp.ForLLM)
+	}
+}
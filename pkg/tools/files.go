@@ -0,0 +1,194 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sypherexx/sypher-mini/pkg/audit"
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/policy"
+)
+
+// File access levels passed to policy.Evaluator.CanAccessFile.
+const (
+	accessRead  = "read"
+	accessWrite = "write"
+)
+
+// maxFileBytes is the largest file the file tools read or rewrite.
+const maxFileBytes = 10 << 20
+
+// fileAccess is shared by the file tools: it resolves paths against the
+// agent workspace, enforces restrict_to_workspace and policies.files, and
+// writes audit lines.
+type fileAccess struct {
+	cfg                 *config.Config
+	workspace           string
+	restrictToWorkspace bool
+	policyEval          *policy.Evaluator
+	auditLogger         *audit.Logger
+	safeMode            bool
+}
+
+func newFileAccess(cfg *config.Config, policyEval *policy.Evaluator, auditLogger *audit.Logger, safeMode bool) fileAccess {
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	if policyEval == nil {
+		policyEval = policy.NewEvaluator(cfg)
+	}
+	workspace := config.ExpandPath(cfg.Agents.Defaults.Workspace)
+	if workspace == "" {
+		workspace, _ = os.Getwd()
+	}
+	return fileAccess{
+		cfg:                 cfg,
+		workspace:           workspace,
+		restrictToWorkspace: cfg.Agents.Defaults.RestrictToWorkspace,
+		policyEval:          policyEval,
+		auditLogger:         auditLogger,
+		safeMode:            safeMode,
+	}
+}
+
+// resolve returns the absolute, symlink-resolved path of p for the request's
+// agent. Relative paths are relative to the agent workspace. A non-nil
+// Response means access is denied.
+func (f *fileAccess) resolve(req Request, p, access string) (string, *Response) {
+	workspace := agentWorkspace(f.cfg, req.AgentID, f.workspace)
+	p = config.ExpandPath(p)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(workspace, p)
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		abs = filepath.Clean(p)
+	}
+	// Resolve symlinks so a link inside the workspace cannot point outside it
+	abs = realPath(abs)
+
+	if f.restrictToWorkspace {
+		wsAbs, _ := filepath.Abs(workspace)
+		if !withinDir(abs, realPath(wsAbs)) {
+			resp := ErrorResponse(req.ToolCallID,
+				"Path outside workspace: "+abs,
+				"File path is outside the allowed workspace.",
+				CodePermissionDenied, false)
+			return "", &resp
+		}
+	}
+	if !f.policyEval.CanAccessFile(req.AgentID, abs, access) {
+		resp := ErrorResponse(req.ToolCallID,
+			fmt.Sprintf("Access denied by file policy (%s): %s", access, abs),
+			"File access denied by policy.",
+			CodePermissionDenied, false)
+		return "", &resp
+	}
+	return abs, nil
+}
+
+// audit records a file operation in the task's audit log.
+func (f *fileAccess) audit(req Request, op, path, summary string) string {
+	if f.auditLogger != nil {
+		_ = f.auditLogger.LogFileOp(req.TaskID, req.ToolCallID, op, path, summary)
+	}
+	return fmt.Sprintf("audit/%s.log", req.TaskID)
+}
+
+// safeModeError is returned by the tools that modify files in safe mode.
+func safeModeError(req Request, name string) Response {
+	return ErrorResponse(req.ToolCallID,
+		name+" disabled in safe mode",
+		"File changes are disabled in safe mode.",
+		CodePermissionDenied, false)
+}
+
+// fileError reports a failed file operation.
+func fileError(req Request, err error, forUser string) Response {
+	return ErrorResponse(req.ToolCallID, err.Error(), forUser, CodePermissionDenied, false)
+}
+
+// realPath resolves symlinks in the longest existing prefix of p.
+func realPath(p string) string {
+	var rest []string
+	for cur := p; ; {
+		if resolved, err := filepath.EvalSymlinks(cur); err == nil {
+			for i := len(rest) - 1; i >= 0; i-- {
+				resolved = filepath.Join(resolved, rest[i])
+			}
+			return resolved
+		}
+		parent := filepath.Dir(cur)
+		if parent == cur {
+			return p
+		}
+		rest = append(rest, filepath.Base(cur))
+		cur = parent
+	}
+}
+
+// withinDir reports whether p is dir or inside it.
+func withinDir(p, dir string) bool {
+	return p == dir || strings.HasPrefix(p, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// readTextFile reads a file for the file tools, rejecting directories,
+// oversized files and binary content.
+func readTextFile(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > maxFileBytes {
+		return nil, fmt.Errorf("%s is too large (%d bytes, max %d)", path, info.Size(), maxFileBytes)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	head := data
+	if len(head) > 8000 {
+		head = head[:8000]
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil, fmt.Errorf("%s is a binary file", path)
+	}
+	return data, nil
+}
+
+// writeFileAtomic replaces path with data via a temporary file in the same
+// directory, keeping the mode of an existing file.
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", path)
+		}
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
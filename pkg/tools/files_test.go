@@ -0,0 +1,213 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sypherexx/sypher-mini/pkg/audit"
+	"github.com/sypherexx/sypher-mini/pkg/config"
+)
+
+func fileToolsConfig(t *testing.T) (*config.Config, string) {
+	t.Helper()
+	ws := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = ws
+	cfg.Agents.Defaults.RestrictToWorkspace = true
+	return cfg, ws
+}
+
+func fileReq(name string, args map[string]interface{}) Request {
+	return Request{ToolCallID: "tc1", TaskID: "t1", AgentID: "main", Name: name, Args: args}
+}
+
+func TestReadFileTool_LineRange(t *testing.T) {
+	cfg, ws := fileToolsConfig(t)
+	if err := os.WriteFile(filepath.Join(ws, "a.txt"), []byte("one\ntwo\nthree\nfour\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewReadFileTool(cfg, nil, nil, false)
+
+	resp := tool.Execute(context.Background(), fileReq("read_file", map[string]interface{}{
+		"path": "a.txt", "start_line": float64(2), "end_line": float64(3),
+	}))
+	if resp.IsError {
+		t.Fatalf("unexpected error: %s", resp.ForLLM)
+	}
+	want := "     2\ttwo\n     3\tthree\n[lines 2-3 of 4; continue with start_line=4]"
+	if resp.ForLLM != want {
+		t.Errorf("got %q, want %q", resp.ForLLM, want)
+	}
+
+	resp = tool.Execute(context.Background(), fileReq("read_file", map[string]interface{}{"path": "a.txt"}))
+	if resp.IsError || strings.Contains(resp.ForLLM, "[lines") {
+		t.Errorf("full read should have no range note: %q", resp.ForLLM)
+	}
+}
+
+func TestFileTools_Access(t *testing.T) {
+	cfg, ws := fileToolsConfig(t)
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(ws, "link")); err != nil {
+		t.Fatal(err)
+	}
+	read := NewReadFileTool(cfg, nil, nil, false)
+
+	for _, p := range []string{filepath.Join(outside, "secret.txt"), "../" + filepath.Base(outside) + "/secret.txt", "link/secret.txt"} {
+		resp := read.Execute(context.Background(), fileReq("read_file", map[string]interface{}{"path": p}))
+		if !resp.IsError || resp.Code != CodePermissionDenied {
+			t.Errorf("%s: expected permission denied, got %+v", p, resp)
+		}
+	}
+
+	// Without restrict_to_workspace, policies.files decides
+	cfg.Agents.Defaults.RestrictToWorkspace = false
+	read = NewReadFileTool(cfg, nil, nil, false)
+	resp := read.Execute(context.Background(), fileReq("read_file", map[string]interface{}{"path": filepath.Join(outside, "secret.txt")}))
+	if !resp.IsError {
+		t.Fatal("expected policy denial without a files policy")
+	}
+	cfg.Policies.Files = []config.FilePolicy{{Path: outside + "/**", AgentIDs: []string{"main"}, Access: "read"}}
+	read = NewReadFileTool(cfg, nil, nil, false)
+	resp = read.Execute(context.Background(), fileReq("read_file", map[string]interface{}{"path": filepath.Join(outside, "secret.txt")}))
+	if resp.IsError {
+		t.Fatalf("read should be allowed by policy: %s", resp.ForLLM)
+	}
+	write := NewWriteFileTool(cfg, nil, nil, false)
+	resp = write.Execute(context.Background(), fileReq("write_file", map[string]interface{}{"path": filepath.Join(outside, "secret.txt"), "content": "y"}))
+	if !resp.IsError {
+		t.Fatal("write should be denied by a read-only policy")
+	}
+}
+
+func TestWriteAndEditFileTool(t *testing.T) {
+	cfg, ws := fileToolsConfig(t)
+	auditDir := t.TempDir()
+	logger := audit.New(auditDir)
+	write := NewWriteFileTool(cfg, nil, logger, false)
+	edit := NewEditFileTool(cfg, nil, logger, false)
+
+	resp := write.Execute(context.Background(), fileReq("write_file", map[string]interface{}{
+		"path": "src/main.go", "content": "package main\n\nfunc main() {\n\tprintln(\"hi\")\n\tprintln(\"hi\")\n}\n",
+	}))
+	if resp.IsError {
+		t.Fatalf("write: %s", resp.ForLLM)
+	}
+	path := filepath.Join(ws, "src", "main.go")
+
+	// Ambiguous replace is rejected, unique replace succeeds
+	resp = edit.Execute(context.Background(), fileReq("edit_file", map[string]interface{}{
+		"path": "src/main.go", "old_string": "println(\"hi\")", "new_string": "println(\"bye\")",
+	}))
+	if !resp.IsError || !strings.Contains(resp.ForLLM, "occurs 2 times") {
+		t.Fatalf("expected ambiguity error, got %+v", resp)
+	}
+	resp = edit.Execute(context.Background(), fileReq("edit_file", map[string]interface{}{
+		"path": "src/main.go", "old_string": "println(\"hi\")", "new_string": "println(\"bye\")", "replace_all": true,
+	}))
+	if resp.IsError {
+		t.Fatalf("replace_all: %s", resp.ForLLM)
+	}
+
+	diff := `--- a/src/main.go
++++ b/src/main.go
+@@ -3,4 +3,5 @@
+ func main() {
+-	println("bye")
++	println("hello")
+ 	println("bye")
++	println("done")
+ }
+`
+	resp = edit.Execute(context.Background(), fileReq("edit_file", map[string]interface{}{"path": "src/main.go", "diff": diff}))
+	if resp.IsError {
+		t.Fatalf("diff: %s", resp.ForLLM)
+	}
+	data, _ := os.ReadFile(path)
+	want := "package main\n\nfunc main() {\n\tprintln(\"hello\")\n\tprintln(\"bye\")\n\tprintln(\"done\")\n}\n"
+	if string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+
+	// A hunk whose context does not match leaves the file untouched
+	resp = edit.Execute(context.Background(), fileReq("edit_file", map[string]interface{}{
+		"path": "src/main.go", "diff": "@@ -1,1 +1,1 @@\n-package lib\n+package app\n",
+	}))
+	if !resp.IsError {
+		t.Fatal("expected mismatching hunk to fail")
+	}
+	if after, _ := os.ReadFile(path); string(after) != want {
+		t.Error("failed edit must not modify the file")
+	}
+
+	log, err := os.ReadFile(filepath.Join(auditDir, "t1.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, op := range []string{"| write_file |", "| edit_file |"} {
+		if !strings.Contains(string(log), op) {
+			t.Errorf("audit log missing %s: %s", op, log)
+		}
+	}
+}
+
+func TestApplyHunks_ShiftedLines(t *testing.T) {
+	content := "a\nb\nc\nd\ne\n"
+	// Stated position is stale (line 1 instead of 3); the context still locates it
+	hunks, err := parseHunks("@@ -1,2 +1,2 @@\n c\n-d\n+D\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := applyHunks(content, hunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "a\nb\nc\nD\ne\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestFileTools_SafeMode(t *testing.T) {
+	cfg, ws := fileToolsConfig(t)
+	if err := os.WriteFile(filepath.Join(ws, "a.txt"), []byte("x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if resp := NewWriteFileTool(cfg, nil, nil, true).Execute(context.Background(), fileReq("write_file", map[string]interface{}{"path": "b.txt", "content": "y"})); !resp.IsError {
+		t.Error("write_file should be disabled in safe mode")
+	}
+	if resp := NewEditFileTool(cfg, nil, nil, true).Execute(context.Background(), fileReq("edit_file", map[string]interface{}{"path": "a.txt", "old_string": "x", "new_string": "y"})); !resp.IsError {
+		t.Error("edit_file should be disabled in safe mode")
+	}
+	if resp := NewReadFileTool(cfg, nil, nil, true).Execute(context.Background(), fileReq("read_file", map[string]interface{}{"path": "a.txt"})); resp.IsError {
+		t.Errorf("read_file should work in safe mode: %s", resp.ForLLM)
+	}
+}
+
+func TestListDirTool(t *testing.T) {
+	cfg, ws := fileToolsConfig(t)
+	_ = os.MkdirAll(filepath.Join(ws, "src", "pkg"), 0755)
+	_ = os.MkdirAll(filepath.Join(ws, ".git"), 0755)
+	_ = os.WriteFile(filepath.Join(ws, ".git", "HEAD"), []byte("ref"), 0644)
+	_ = os.WriteFile(filepath.Join(ws, "src", "pkg", "x.go"), []byte("package pkg\n"), 0644)
+	tool := NewListDirTool(cfg, nil, nil, false)
+
+	resp := tool.Execute(context.Background(), fileReq("list_dir", map[string]interface{}{}))
+	if resp.IsError {
+		t.Fatal(resp.ForLLM)
+	}
+	if resp.ForLLM != ".git/\nsrc/" {
+		t.Errorf("top level: got %q", resp.ForLLM)
+	}
+
+	resp = tool.Execute(context.Background(), fileReq("list_dir", map[string]interface{}{"recursive": true}))
+	want := ".git/\nsrc/\nsrc/pkg/\nsrc/pkg/x.go  (12 bytes)"
+	if resp.ForLLM != want {
+		t.Errorf("recursive: got %q, want %q", resp.ForLLM, want)
+	}
+}
@@ -0,0 +1,458 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sypherexx/sypher-mini/pkg/audit"
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/executor"
+	"github.com/sypherexx/sypher-mini/pkg/policy"
+)
+
+const (
+	maxGitOutput   = 8000
+	defaultGitLogN = 20
+	maxGitLogN     = 100
+)
+
+// gitOps are the supported operations.
+var gitOps = map[string]bool{
+	"status": true, "diff": true, "log": true, "branch": true,
+	"commit": true, "stash": true, "checkout": true, "push": true,
+}
+
+// gitMutates reports whether op changes the repository. Listing branches and
+// stashes is read-only.
+func gitMutates(op string, args map[string]interface{}) bool {
+	switch op {
+	case "status", "diff", "log":
+		return false
+	case "branch":
+		name, _ := args["name"].(string)
+		return name != ""
+	case "stash":
+		action, _ := args["action"].(string)
+		return action != "list"
+	}
+	return true
+}
+
+// GitTool runs typed git operations in a repository inside the workspace.
+type GitTool struct {
+	fileAccess
+	env     *CommandEnv
+	timeout time.Duration
+}
+
+// NewGitTool creates a git tool.
+func NewGitTool(cfg *config.Config, policyEval *policy.Evaluator, auditLogger *audit.Logger, safeMode bool) *GitTool {
+	fa := newFileAccess(cfg, policyEval, auditLogger, safeMode)
+	timeout := 60 * time.Second
+	if fa.cfg.Tools.Exec.TimeoutSec > 0 {
+		timeout = time.Duration(fa.cfg.Tools.Exec.TimeoutSec) * time.Second
+	}
+	return &GitTool{fileAccess: fa, env: NewCommandEnv(fa.cfg, nil, nil), timeout: timeout}
+}
+
+// SetCommandEnv replaces the builder of git's environment.
+func (t *GitTool) SetCommandEnv(env *CommandEnv) { t.env = env }
+
+// Name returns the tool name.
+func (t *GitTool) Name() string { return "git" }
+
+// Description returns the tool description for the LLM.
+func (t *GitTool) Description() string {
+	return "Run a git operation in a repository in the workspace: status, diff (staged, ref, path), log (n, ref, path), " +
+		"branch (list, or create with name), commit (message, paths or all), stash (push, pop, list), checkout (ref, create), " +
+		"push (remote, branch; only if allowed for this agent, never forced)."
+}
+
+// Schema returns the JSON schema of the tool arguments.
+func (t *GitTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"operation": map[string]interface{}{"type": "string", "enum": []interface{}{"status", "diff", "log", "branch", "commit", "stash", "checkout", "push"}},
+			"repo":      map[string]interface{}{"type": "string", "description": "Repository directory (default: workspace)"},
+			"path":      map[string]interface{}{"type": "string", "description": "diff/log: limit to this path"},
+			"ref":       map[string]interface{}{"type": "string", "description": "diff: compare against ref; log: start ref; checkout: branch or commit"},
+			"staged":    map[string]interface{}{"type": "boolean", "description": "diff: show staged changes"},
+			"n":         map[string]interface{}{"type": "integer", "description": "log: number of commits (default 20, max 100)"},
+			"name":      map[string]interface{}{"type": "string", "description": "branch: name of the branch to create"},
+			"message":   map[string]interface{}{"type": "string", "description": "commit/stash: message"},
+			"paths":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "commit: paths to stage"},
+			"all":       map[string]interface{}{"type": "boolean", "description": "commit: stage all changes, including new files"},
+			"action":    map[string]interface{}{"type": "string", "enum": []interface{}{"push", "pop", "list"}, "description": "stash: action (default push)"},
+			"create":    map[string]interface{}{"type": "boolean", "description": "checkout: create ref as a new branch"},
+			"remote":    map[string]interface{}{"type": "string", "description": "push: remote (default origin)"},
+			"branch":    map[string]interface{}{"type": "string", "description": "push: branch (default current)"},
+		},
+		"required": []interface{}{"operation"},
+	}
+}
+
+// Capabilities returns the capabilities this tool provides.
+func (t *GitTool) Capabilities() []string {
+	return []string{"code_generation", "file_edit"}
+}
+
+// Execute runs the requested git operation.
+func (t *GitTool) Execute(ctx context.Context, req Request) Response {
+	op, _ := req.Args["operation"].(string)
+	if !gitOps[op] {
+		return ErrorResponse(req.ToolCallID,
+			fmt.Sprintf("Unknown git operation %q", op),
+			"Invalid git operation.",
+			CodePermissionDenied, false)
+	}
+	mutates := gitMutates(op, req.Args)
+	if mutates && t.safeMode {
+		return ErrorResponse(req.ToolCallID,
+			"git "+op+" disabled in safe mode",
+			"Repository changes are disabled in safe mode.",
+			CodePermissionDenied, false)
+	}
+
+	access := accessRead
+	if mutates {
+		access = accessWrite
+	}
+	repo, _ := req.Args["repo"].(string)
+	if repo == "" {
+		repo = "."
+	}
+	dir, denied := t.resolve(req, repo, access)
+	if denied != nil {
+		return *denied
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	// Operations act on the whole work tree, so its root must be accessible too
+	top, err := t.git(ctx, req, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return fileError(req, fmt.Errorf("%s is not a git repository: %v", repo, err), "Not a git repository.")
+	}
+	if _, denied := t.resolve(req, top, access); denied != nil {
+		return *denied
+	}
+
+	before := t.head(ctx, req, top)
+	out, err := t.run(ctx, req, top, op)
+	after := t.head(ctx, req, top)
+
+	summary := firstLine(strings.Split(out, "\n"))
+	if err != nil {
+		summary = "error: " + err.Error()
+	}
+	if t.auditLogger != nil {
+		_ = t.auditLogger.LogGitOp(req.TaskID, req.ToolCallID, op, top, before, after, summary)
+	}
+	if err != nil {
+		return fileError(req, fmt.Errorf("git %s: %w", op, err), "Git "+op+" failed.")
+	}
+	if len(out) > maxGitOutput {
+		out = out[:maxGitOutput] + "\n\n... (truncated)"
+	}
+	forUser := "git " + op
+	if before != after {
+		forUser += fmt.Sprintf(" (HEAD %s -> %s)", before, after)
+	}
+	return SuccessResponse(req.ToolCallID, out, forUser, fmt.Sprintf("audit/%s.log", req.TaskID))
+}
+
+// run executes op in the work tree top and returns the output for the LLM.
+func (t *GitTool) run(ctx context.Context, req Request, top, op string) (string, error) {
+	str := func(k string) string { s, _ := req.Args[k].(string); return s }
+	flag := func(k string) bool { b, _ := req.Args[k].(bool); return b }
+	for _, k := range []string{"ref", "name", "remote", "branch"} {
+		if err := checkGitArg(k, str(k)); err != nil {
+			return "", err
+		}
+	}
+	for _, k := range []string{"remote", "branch"} {
+		if err := checkRefName(k, str(k)); err != nil {
+			return "", err
+		}
+	}
+
+	switch op {
+	case "status":
+		out, err := t.git(ctx, req, top, "status", "--short", "--branch")
+		if err == nil && !strings.Contains(out, "\n") {
+			out += "\n(working tree clean)"
+		}
+		return out, err
+
+	case "diff":
+		args := []string{"diff", "--no-color", "--no-ext-diff"}
+		if flag("staged") {
+			args = append(args, "--cached")
+		}
+		if ref := str("ref"); ref != "" {
+			args = append(args, ref)
+		}
+		args = append(args, "--")
+		if p := str("path"); p != "" {
+			args = append(args, p)
+		}
+		stat, err := t.git(ctx, req, top, append([]string{args[0], "--stat"}, args[1:]...)...)
+		if err != nil {
+			return "", err
+		}
+		if stat == "" {
+			return "No changes", nil
+		}
+		patch, err := t.git(ctx, req, top, args...)
+		if err != nil {
+			return "", err
+		}
+		return formatDiff(stat, patch), nil
+
+	case "log":
+		n := defaultGitLogN
+		if v, ok := req.Args["n"].(float64); ok && v > 0 {
+			n = int(v)
+			if n > maxGitLogN {
+				n = maxGitLogN
+			}
+		}
+		args := []string{"log", "--no-color", "--date=short", "--format=%h %ad %an: %s", fmt.Sprintf("-n%d", n)}
+		if ref := str("ref"); ref != "" {
+			args = append(args, ref)
+		}
+		args = append(args, "--")
+		if p := str("path"); p != "" {
+			args = append(args, p)
+		}
+		out, err := t.git(ctx, req, top, args...)
+		if err == nil && out == "" {
+			out = "No commits"
+		}
+		return out, err
+
+	case "branch":
+		name := str("name")
+		if name == "" {
+			return t.git(ctx, req, top, "branch", "--no-color", "-vv")
+		}
+		args := []string{"branch", name}
+		if ref := str("ref"); ref != "" {
+			args = append(args, ref)
+		}
+		if _, err := t.git(ctx, req, top, args...); err != nil {
+			return "", err
+		}
+		return "Created branch " + name, nil
+
+	case "commit":
+		return t.commit(ctx, req, top, str("message"), flag("all"))
+
+	case "stash":
+		switch action := str("action"); action {
+		case "", "push":
+			args := []string{"stash", "push", "--include-untracked"}
+			if msg := str("message"); msg != "" {
+				args = append(args, "-m", msg)
+			}
+			return t.git(ctx, req, top, args...)
+		case "pop":
+			return t.git(ctx, req, top, "stash", "pop")
+		case "list":
+			out, err := t.git(ctx, req, top, "stash", "list")
+			if err == nil && out == "" {
+				out = "No stashes"
+			}
+			return out, err
+		default:
+			return "", fmt.Errorf("unknown stash action %q (use push, pop or list)", action)
+		}
+
+	case "checkout":
+		ref := str("ref")
+		if ref == "" {
+			return "", fmt.Errorf("checkout needs ref")
+		}
+		args := []string{"checkout", ref}
+		if flag("create") {
+			args = []string{"checkout", "-b", ref}
+		}
+		if _, err := t.git(ctx, req, top, args...); err != nil {
+			return "", err
+		}
+		return t.git(ctx, req, top, "status", "--short", "--branch")
+
+	case "push":
+		remote, branch := str("remote"), str("branch")
+		if remote == "" {
+			remote = "origin"
+		}
+		if !t.pushAllowed(req.AgentID, remote) {
+			return "", fmt.Errorf("push to %q is not allowed for agent %s (agents.list[].git_push)", remote, req.AgentID)
+		}
+		if branch == "" {
+			cur, err := t.git(ctx, req, top, "rev-parse", "--abbrev-ref", "HEAD")
+			if err != nil {
+				return "", err
+			}
+			if cur == "HEAD" {
+				return "", fmt.Errorf("HEAD is detached; pass the branch to push")
+			}
+			branch = cur
+		}
+		if _, err := t.git(ctx, req, top, "check-ref-format", "--branch", branch); err != nil {
+			return "", fmt.Errorf("invalid branch %q", branch)
+		}
+		// An explicit refspec: the same branch on both sides, never forced
+		ref := "refs/heads/" + branch
+		return t.git(ctx, req, top, "push", remote, ref+":"+ref)
+	}
+	return "", fmt.Errorf("unknown operation %q", op)
+}
+
+// commit stages the requested paths and commits with task and agent trailers.
+func (t *GitTool) commit(ctx context.Context, req Request, top, message string, all bool) (string, error) {
+	if strings.TrimSpace(message) == "" {
+		return "", fmt.Errorf("commit needs a message")
+	}
+	var paths []string
+	if raw, ok := req.Args["paths"].([]interface{}); ok {
+		for _, p := range raw {
+			if s, ok := p.(string); ok && s != "" {
+				paths = append(paths, s)
+			}
+		}
+	}
+	switch {
+	case all:
+		if _, err := t.git(ctx, req, top, "add", "-A"); err != nil {
+			return "", err
+		}
+	case len(paths) > 0:
+		if _, err := t.git(ctx, req, top, append([]string{"add", "--"}, paths...)...); err != nil {
+			return "", err
+		}
+	}
+	if _, err := t.git(ctx, req, top, "diff", "--cached", "--quiet"); err == nil {
+		return "", fmt.Errorf("nothing staged to commit (pass paths or all)")
+	}
+
+	msg := strings.TrimRight(message, "\n") + "\n\nSypher-Task: " + req.TaskID
+	if req.AgentID != "" {
+		msg += "\nSypher-Agent: " + req.AgentID
+	}
+	var args []string
+	// Fall back to a sypher identity when the repository has none configured
+	if email, _ := t.git(ctx, req, top, "config", "user.email"); email == "" {
+		args = append(args, "-c", "user.name=sypher", "-c", "user.email=sypher@localhost")
+	}
+	args = append(args, "commit", "-q", "--no-verify", "-m", msg)
+	if _, err := t.git(ctx, req, top, args...); err != nil {
+		return "", err
+	}
+	return t.git(ctx, req, top, "show", "--stat", "--no-color", "--format=%h %s", "HEAD")
+}
+
+// pushAllowed reports whether the agent may push to remote.
+func (t *GitTool) pushAllowed(agentID, remote string) bool {
+	a := t.cfg.AgentByID(agentID)
+	if a == nil {
+		return false
+	}
+	for _, r := range a.GitPush {
+		if r == "*" || r == remote {
+			return true
+		}
+	}
+	return false
+}
+
+// head returns the abbreviated HEAD SHA, or "none" before the first commit.
+func (t *GitTool) head(ctx context.Context, req Request, top string) string {
+	sha, err := t.git(ctx, req, top, "rev-parse", "--short", "HEAD")
+	if err != nil || sha == "" {
+		return "none"
+	}
+	return sha
+}
+
+// gitSafeConfig keeps a repository's own config from running code: hooks,
+// fsmonitor and a custom ssh command are overridden on every call.
+var gitSafeConfig = []string{"-c", "core.hooksPath=/dev/null", "-c", "core.fsmonitor=", "-c", "core.sshCommand=ssh"}
+
+// git runs git in dir through the agent's executor and command environment
+// (like exec) and returns its trimmed output. Prompts are disabled so a
+// missing credential fails instead of hanging.
+func (t *GitTool) git(ctx context.Context, req Request, dir string, args ...string) (string, error) {
+	argv := append(append([]string{"git"}, gitSafeConfig...), args...)
+	workspace := agentWorkspace(t.cfg, req.AgentID, t.workspace)
+	cmd, cleanup, errResp := executorCommand(ctx, t.cfg, t.env, req, executor.Spec{Args: argv, Dir: dir, Workspace: workspace})
+	if errResp != nil {
+		return "", fmt.Errorf("%s", errResp.ForLLM)
+	}
+	defer cleanup()
+	cmd.Env = append(cmd.Env, "GIT_TERMINAL_PROMPT=0", "GIT_PAGER=cat", "GIT_EDITOR=true")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("timed out")
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		if msg == "" {
+			return "", err
+		}
+		return "", fmt.Errorf("%s", msg)
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+// checkGitArg rejects values git would parse as options.
+func checkGitArg(name, v string) error {
+	if strings.HasPrefix(v, "-") || strings.ContainsAny(v, " \t\n\x00") {
+		return fmt.Errorf("invalid %s %q", name, v)
+	}
+	return nil
+}
+
+// checkRefName rejects refspec syntax in a remote or branch name: "+" would
+// force a push, ":" would name (or delete) another remote ref, and "^", "~",
+// ".." or "@{" select other commits.
+func checkRefName(name, v string) error {
+	if strings.ContainsAny(v, "+:^~?*[\\") || strings.Contains(v, "..") || strings.Contains(v, "@{") {
+		return fmt.Errorf("invalid %s %q", name, v)
+	}
+	return nil
+}
+
+// formatDiff puts the --stat summary first and keeps whole files of the patch
+// up to the output limit, naming the files that did not fit.
+func formatDiff(stat, patch string) string {
+	var b strings.Builder
+	b.WriteString("Summary:\n" + stat + "\n\n")
+	files := strings.Split(patch, "\ndiff --git ")
+	var skipped []string
+	for i, f := range files {
+		if i > 0 {
+			f = "diff --git " + f
+		}
+		if len(skipped) == 0 && (b.Len()+len(f) < maxGitOutput || i == 0 && b.Len() < maxGitOutput/2) {
+			b.WriteString(f + "\n")
+			continue
+		}
+		header := strings.SplitN(f, "\n", 2)[0]
+		skipped = append(skipped, strings.TrimPrefix(header, "diff --git "))
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintf(&b, "\n[%d file(s) not shown; diff them one at a time with path: %s]", len(skipped), strings.Join(skipped, ", "))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
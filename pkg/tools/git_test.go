@@ -0,0 +1,220 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/sypherexx/sypher-mini/pkg/audit"
+	"github.com/sypherexx/sypher-mini/pkg/config"
+)
+
+func gitRepo(t *testing.T) (*config.Config, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	cfg, ws := fileToolsConfig(t)
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	cfg.Tools.Exec.Env.Allow = []string{"GIT_CONFIG_*"}
+	if out, err := exec.Command("git", "-C", ws, "init", "-q", "-b", "main").CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+	return cfg, ws
+}
+
+func gitReq(args map[string]interface{}) Request {
+	return fileReq("git", args)
+}
+
+func TestGitTool_CommitAndDiff(t *testing.T) {
+	cfg, ws := gitRepo(t)
+	auditDir := t.TempDir()
+	tool := NewGitTool(cfg, nil, audit.New(auditDir), false)
+	ctx := context.Background()
+
+	writeTree(t, ws, map[string]string{"a.txt": "one\n"})
+	resp := tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "status"}))
+	if resp.IsError || !strings.Contains(resp.ForLLM, "?? a.txt") {
+		t.Fatalf("status: %+v", resp)
+	}
+
+	resp = tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "commit", "message": "Add a", "all": true}))
+	if resp.IsError {
+		t.Fatalf("commit: %s", resp.ForLLM)
+	}
+	body, _ := exec.Command("git", "-C", ws, "log", "-1", "--format=%B").Output()
+	if !strings.Contains(string(body), "Sypher-Task: t1") || !strings.Contains(string(body), "Sypher-Agent: main") {
+		t.Errorf("commit not attributed to task: %q", body)
+	}
+
+	resp = tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "commit", "message": "empty"}))
+	if !resp.IsError || !strings.Contains(resp.ForLLM, "nothing staged") {
+		t.Errorf("expected nothing-staged error, got %+v", resp)
+	}
+
+	writeTree(t, ws, map[string]string{"a.txt": "one\ntwo\n"})
+	resp = tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "diff"}))
+	if resp.IsError || !strings.HasPrefix(resp.ForLLM, "Summary:\n a.txt | 1 +") || !strings.Contains(resp.ForLLM, "+two") {
+		t.Errorf("diff: %q", resp.ForLLM)
+	}
+
+	resp = tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "log"}))
+	if resp.IsError || !strings.Contains(resp.ForLLM, "sypher: Add a") {
+		t.Errorf("log: %q", resp.ForLLM)
+	}
+
+	data, err := os.ReadFile(filepath.Join(auditDir, "t1.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "| git | op=commit") || !strings.Contains(string(data), "head=none..") {
+		t.Errorf("audit log missing commit with HEAD before/after: %s", data)
+	}
+}
+
+func TestGitTool_BranchCheckoutStash(t *testing.T) {
+	cfg, ws := gitRepo(t)
+	tool := NewGitTool(cfg, nil, nil, false)
+	ctx := context.Background()
+	writeTree(t, ws, map[string]string{"a.txt": "one\n"})
+	if resp := tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "commit", "message": "init", "paths": []interface{}{"a.txt"}})); resp.IsError {
+		t.Fatal(resp.ForLLM)
+	}
+
+	if resp := tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "checkout", "ref": "feature", "create": true})); resp.IsError {
+		t.Fatal(resp.ForLLM)
+	}
+	resp := tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "branch"}))
+	if !strings.Contains(resp.ForLLM, "* feature") {
+		t.Errorf("branch list: %q", resp.ForLLM)
+	}
+
+	writeTree(t, ws, map[string]string{"a.txt": "changed\n"})
+	if resp := tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "stash", "message": "wip"})); resp.IsError {
+		t.Fatal(resp.ForLLM)
+	}
+	if data, _ := os.ReadFile(filepath.Join(ws, "a.txt")); string(data) != "one\n" {
+		t.Errorf("stash should restore the work tree, got %q", data)
+	}
+	resp = tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "stash", "action": "list"}))
+	if !strings.Contains(resp.ForLLM, "wip") {
+		t.Errorf("stash list: %q", resp.ForLLM)
+	}
+
+	resp = tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "checkout", "ref": "--orphan"}))
+	if !resp.IsError {
+		t.Error("option-like ref must be rejected")
+	}
+}
+
+func TestGitTool_PushPolicy(t *testing.T) {
+	cfg, ws := gitRepo(t)
+	remote := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare: %v: %s", err, out)
+	}
+	if out, err := exec.Command("git", "-C", ws, "remote", "add", "origin", remote).CombinedOutput(); err != nil {
+		t.Fatalf("remote add: %v: %s", err, out)
+	}
+	ctx := context.Background()
+	writeTree(t, ws, map[string]string{"a.txt": "one\n"})
+	tool := NewGitTool(cfg, nil, nil, false)
+	if resp := tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "commit", "message": "init", "all": true})); resp.IsError {
+		t.Fatal(resp.ForLLM)
+	}
+
+	resp := tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "push"}))
+	if !resp.IsError || !strings.Contains(resp.ForLLM, "not allowed") {
+		t.Fatalf("push without git_push should be denied: %+v", resp)
+	}
+
+	cfg.Agents.List = []config.AgentConfig{{ID: "main", GitPush: []string{"origin"}}}
+	tool = NewGitTool(cfg, nil, nil, false)
+	if resp := tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "push"})); resp.IsError {
+		t.Fatalf("push to allowed remote: %s", resp.ForLLM)
+	}
+	if resp := tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "push", "remote": "upstream"})); !resp.IsError {
+		t.Error("push to a remote not in git_push should be denied")
+	}
+
+	// Rewrite the remote branch so only a forced push could replace it
+	if out, err := exec.Command("git", "-C", ws, "-c", "user.name=t", "-c", "user.email=t@example.com", "commit", "-q", "--amend", "-m", "rewritten").CombinedOutput(); err != nil {
+		t.Fatalf("amend: %v: %s", err, out)
+	}
+	for _, branch := range []string{"+main", ":main", "HEAD~1", "a..b", "main:other"} {
+		resp := tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "push", "branch": branch}))
+		if !resp.IsError || !strings.Contains(resp.ForLLM, "invalid branch") {
+			t.Errorf("branch %q should be rejected: %+v", branch, resp)
+		}
+	}
+	if resp := tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "push", "remote": "+origin"})); !resp.IsError {
+		t.Error("refspec syntax in remote should be rejected")
+	}
+	if resp := tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "push"})); !resp.IsError {
+		t.Error("a diverged branch must not be force-pushed")
+	}
+	remoteHead, err := exec.Command("git", "--git-dir", remote, "rev-parse", "main").Output()
+	if err != nil {
+		t.Fatalf("remote branch should still exist: %v", err)
+	}
+	localHead, _ := exec.Command("git", "-C", ws, "rev-parse", "HEAD").Output()
+	if string(remoteHead) == string(localHead) {
+		t.Error("remote branch was overwritten")
+	}
+}
+
+func TestGitTool_SafeMode(t *testing.T) {
+	cfg, _ := gitRepo(t)
+	tool := NewGitTool(cfg, nil, nil, true)
+	ctx := context.Background()
+	if resp := tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "status"})); resp.IsError {
+		t.Errorf("status should work in safe mode: %s", resp.ForLLM)
+	}
+	if resp := tool.Execute(ctx, gitReq(map[string]interface{}{"operation": "commit", "message": "x", "all": true})); !resp.IsError {
+		t.Error("commit should be disabled in safe mode")
+	}
+}
+
+func TestGitTool_RepoCannotRunCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell hook")
+	}
+	cfg, ws := gitRepo(t)
+	t.Setenv("OPENAI_API_KEY", "sk-git-leak")
+	marker := filepath.Join(t.TempDir(), "ran")
+	hook := "#!/bin/sh\nenv > " + marker + "\n"
+	for _, name := range []string{"pre-commit", "commit-msg", "post-commit"} {
+		if err := os.WriteFile(filepath.Join(ws, ".git", "hooks", name), []byte(hook), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if out, err := exec.Command("git", "-C", ws, "config", "core.fsmonitor", "env > "+marker).CombinedOutput(); err != nil {
+		t.Fatalf("git config: %v: %s", err, out)
+	}
+	tool := NewGitTool(cfg, nil, nil, false)
+
+	writeTree(t, ws, map[string]string{"a.txt": "one\n"})
+	for _, args := range []map[string]interface{}{
+		{"operation": "status"},
+		{"operation": "commit", "message": "Add a", "all": true},
+	} {
+		if resp := tool.Execute(context.Background(), gitReq(args)); resp.IsError {
+			t.Fatalf("%v: %s", args["operation"], resp.ForLLM)
+		}
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("repository hooks or fsmonitor ran")
+	}
+
+	fw := NewWriteFileTool(cfg, nil, nil, false)
+	resp := fw.Execute(context.Background(), fileReq("write_file", map[string]interface{}{"path": ".git/hooks/pre-push", "content": hook}))
+	if !resp.IsError {
+		t.Error("write_file must not write into .git")
+	}
+}
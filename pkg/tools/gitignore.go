@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreRule is one .gitignore pattern.
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// gitignoreSet holds the .gitignore rules of a tree, keyed by the
+// slash-separated directory (relative to the root) whose .gitignore defined them.
+type gitignoreSet struct {
+	root  string
+	rules map[string][]ignoreRule
+}
+
+func newGitignoreSet(root string) *gitignoreSet {
+	return &gitignoreSet{root: root, rules: make(map[string][]ignoreRule)}
+}
+
+// load reads the .gitignore of dir (relative, slash-separated; "" for the root).
+func (g *gitignoreSet) load(dir string) {
+	f, err := os.Open(filepath.Join(g.root, filepath.FromSlash(dir), ".gitignore"))
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	var rules []ignoreRule
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		if r, ok := parseIgnoreRule(sc.Text()); ok {
+			rules = append(rules, r)
+		}
+	}
+	if len(rules) > 0 {
+		g.rules[dir] = rules
+	}
+}
+
+// ignored reports whether rel (slash-separated, relative to the root) is
+// excluded. Rules of deeper directories and later lines take precedence.
+func (g *gitignoreSet) ignored(rel string, isDir bool) bool {
+	dirs := []string{""}
+	for i := 0; i < len(rel); i++ {
+		if rel[i] == '/' {
+			dirs = append(dirs, rel[:i])
+		}
+	}
+	ignored := false
+	for _, dir := range dirs {
+		sub := rel
+		if dir != "" {
+			sub = rel[len(dir)+1:]
+		}
+		for _, r := range g.rules[dir] {
+			if r.dirOnly && !isDir {
+				continue
+			}
+			if r.re.MatchString(sub) {
+				ignored = !r.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// parseIgnoreRule converts a .gitignore line into a rule.
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+	var r ignoreRule
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	}
+	line = strings.TrimPrefix(line, `\`)
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case strings.HasPrefix(line[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(line[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			if end := strings.IndexByte(line[i:], ']'); end > 0 {
+				class := line[i+1 : i+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				b.WriteString("[" + class + "]")
+				i += end
+			} else {
+				b.WriteString(`\[`)
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	// A pattern naming a directory also covers everything below it
+	b.WriteString("(?:/.*)?$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	r.re = re
+	return r, true
+}
+
+// relSlash returns p relative to root with forward slashes ("" for root itself).
+func relSlash(root, p string) string {
+	rel, err := filepath.Rel(root, p)
+	if err != nil || rel == "." {
+		return ""
+	}
+	return path.Clean(filepath.ToSlash(rel))
+}
@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTPTool forwards tool calls to an external endpoint. The endpoint receives
+// the Request as JSON and must reply with a Response as JSON. Extensions use it
+// to add tools without Go code.
+type HTTPTool struct {
+	name         string
+	description  string
+	schema       map[string]interface{}
+	capabilities []string
+	endpoint     string
+	client       *http.Client
+	safeMode     bool
+}
+
+// NewHTTPTool creates a tool that POSTs calls to endpoint.
+func NewHTTPTool(name, description string, schema map[string]interface{}, capabilities []string, endpoint string, safeMode bool) *HTTPTool {
+	if schema == nil {
+		schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+	return &HTTPTool{
+		name:         name,
+		description:  description,
+		schema:       schema,
+		capabilities: capabilities,
+		endpoint:     endpoint,
+		client:       &http.Client{Timeout: 60 * time.Second},
+		safeMode:     safeMode,
+	}
+}
+
+// Name returns the tool name.
+func (t *HTTPTool) Name() string { return t.name }
+
+// Description returns the tool description for the LLM.
+func (t *HTTPTool) Description() string { return t.description }
+
+// Schema returns the JSON schema of the tool arguments.
+func (t *HTTPTool) Schema() map[string]interface{} { return t.schema }
+
+// Capabilities returns the capabilities this tool provides.
+func (t *HTTPTool) Capabilities() []string { return t.capabilities }
+
+// Execute posts the request to the endpoint and decodes its response.
+func (t *HTTPTool) Execute(ctx context.Context, req Request) Response {
+	if t.safeMode {
+		return ErrorResponse(req.ToolCallID,
+			"Extension tools are disabled in safe mode",
+			"Extension tools are disabled in safe mode.",
+			CodeSafetyBlocked, false)
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return ErrorResponse(req.ToolCallID, fmt.Sprintf("Encode request: %v", err), "", "", false)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return ErrorResponse(req.ToolCallID, fmt.Sprintf("Invalid endpoint: %v", err), "", "", false)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(httpReq)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return ErrorResponse(req.ToolCallID, "Tool call timed out", "Tool call timed out.", CodeTimeout, true)
+		}
+		return ErrorResponse(req.ToolCallID, fmt.Sprintf("Tool endpoint error: %v", err), "", "", true)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return ErrorResponse(req.ToolCallID, fmt.Sprintf("Read response: %v", err), "", "", true)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ErrorResponse(req.ToolCallID, fmt.Sprintf("Tool endpoint returned %d: %s", resp.StatusCode, string(data)), "", "", resp.StatusCode >= 500)
+	}
+	var out Response
+	if err := json.Unmarshal(data, &out); err != nil {
+		return ErrorResponse(req.ToolCallID, fmt.Sprintf("Invalid tool response: %v", err), "", "", false)
+	}
+	out.ToolCallID = req.ToolCallID
+	return out
+}
@@ -0,0 +1,194 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultJobOutputLines = 50
+	maxJobOutputLines     = 1000
+)
+
+// JobStatusTool reports the state of the task's background jobs.
+type JobStatusTool struct {
+	jobs *JobManager
+}
+
+// NewJobStatusTool creates a job_status tool.
+func NewJobStatusTool(jobs *JobManager) *JobStatusTool {
+	return &JobStatusTool{jobs: jobs}
+}
+
+// Name returns the tool name.
+func (t *JobStatusTool) Name() string { return "job_status" }
+
+// Description returns the tool description for the LLM.
+func (t *JobStatusTool) Description() string {
+	return "Show whether a background job started by exec is still running, or list all jobs of this task when job_id is omitted."
+}
+
+// Schema returns the JSON schema of the tool arguments.
+func (t *JobStatusTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"job_id": map[string]interface{}{"type": "string", "description": "Job ID returned by exec (optional)"},
+		},
+	}
+}
+
+// Capabilities returns the capabilities this tool provides.
+func (t *JobStatusTool) Capabilities() []string {
+	return []string{"code_generation", "log_analysis", "deploy_service"}
+}
+
+// ParallelSafe reports that status checks may run concurrently.
+func (t *JobStatusTool) ParallelSafe() bool { return true }
+
+// Execute reports job status.
+func (t *JobStatusTool) Execute(ctx context.Context, req Request) Response {
+	if id, _ := req.Args["job_id"].(string); id != "" {
+		j, resp := lookupJob(t.jobs, req)
+		if j == nil {
+			return resp
+		}
+		s := j.Status()
+		return SuccessResponse(req.ToolCallID, s, s, "")
+	}
+	var lines []string
+	for _, j := range t.jobs.List(req.TaskID) {
+		lines = append(lines, j.Status())
+	}
+	if len(lines) == 0 {
+		return SuccessResponse(req.ToolCallID, "No background jobs", "No background jobs.", "")
+	}
+	return SuccessResponse(req.ToolCallID, strings.Join(lines, "\n"), fmt.Sprintf("%d background job(s)", len(lines)), "")
+}
+
+// JobOutputTool returns the captured output of a background job.
+type JobOutputTool struct {
+	jobs *JobManager
+}
+
+// NewJobOutputTool creates a job_output tool.
+func NewJobOutputTool(jobs *JobManager) *JobOutputTool {
+	return &JobOutputTool{jobs: jobs}
+}
+
+// Name returns the tool name.
+func (t *JobOutputTool) Name() string { return "job_output" }
+
+// Description returns the tool description for the LLM.
+func (t *JobOutputTool) Description() string {
+	return "Read the last N lines of a background job's output (stdout and stderr, last 64 KB kept)."
+}
+
+// Schema returns the JSON schema of the tool arguments.
+func (t *JobOutputTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"job_id": map[string]interface{}{"type": "string", "description": "Job ID returned by exec"},
+			"lines":  map[string]interface{}{"type": "integer", "description": "Number of lines (default 50, max 1000)"},
+		},
+		"required": []interface{}{"job_id"},
+	}
+}
+
+// Capabilities returns the capabilities this tool provides.
+func (t *JobOutputTool) Capabilities() []string {
+	return []string{"code_generation", "log_analysis", "deploy_service"}
+}
+
+// ParallelSafe reports that output reads may run concurrently.
+func (t *JobOutputTool) ParallelSafe() bool { return true }
+
+// Execute returns the job's output tail, preceded by its status.
+func (t *JobOutputTool) Execute(ctx context.Context, req Request) Response {
+	j, resp := lookupJob(t.jobs, req)
+	if j == nil {
+		return resp
+	}
+	n := defaultJobOutputLines
+	if v, ok := req.Args["lines"].(float64); ok && v > 0 && v <= maxJobOutputLines {
+		n = int(v)
+	}
+	out := j.Output(n)
+	if out == "" {
+		out = "(no output yet)"
+	}
+	return SuccessResponse(req.ToolCallID, j.Status()+"\n\n"+out, j.Status(), "")
+}
+
+// JobStopTool stops a background job.
+type JobStopTool struct {
+	jobs     *JobManager
+	safeMode bool
+}
+
+// NewJobStopTool creates a job_stop tool.
+func NewJobStopTool(jobs *JobManager, safeMode bool) *JobStopTool {
+	return &JobStopTool{jobs: jobs, safeMode: safeMode}
+}
+
+// Name returns the tool name.
+func (t *JobStopTool) Name() string { return "job_stop" }
+
+// Description returns the tool description for the LLM.
+func (t *JobStopTool) Description() string {
+	return "Stop a background job started by exec (SIGTERM, then SIGKILL after a grace period)."
+}
+
+// Schema returns the JSON schema of the tool arguments.
+func (t *JobStopTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"job_id": map[string]interface{}{"type": "string", "description": "Job ID returned by exec"},
+		},
+		"required": []interface{}{"job_id"},
+	}
+}
+
+// Capabilities returns the capabilities this tool provides.
+func (t *JobStopTool) Capabilities() []string {
+	return []string{"code_generation", "deploy_service"}
+}
+
+// Execute stops the job.
+func (t *JobStopTool) Execute(ctx context.Context, req Request) Response {
+	if t.safeMode {
+		return ErrorResponse(req.ToolCallID,
+			"job_stop disabled in safe mode",
+			"Job control is disabled in safe mode.",
+			CodePermissionDenied, false)
+	}
+	j, resp := lookupJob(t.jobs, req)
+	if j == nil {
+		return resp
+	}
+	t.jobs.Stop(j)
+	s := j.Status()
+	return SuccessResponse(req.ToolCallID, s+"\n\n"+j.Output(20), s, fmt.Sprintf("audit/%s.log", req.TaskID))
+}
+
+// lookupJob finds the job named by the job_id argument among the task's jobs.
+func lookupJob(jobs *JobManager, req Request) (*Job, Response) {
+	id, _ := req.Args["job_id"].(string)
+	if id == "" {
+		return nil, ErrorResponse(req.ToolCallID,
+			"Missing 'job_id' argument",
+			"Job ID is required.",
+			CodePermissionDenied, false)
+	}
+	j, ok := jobs.Get(req.TaskID, id)
+	if !ok {
+		return nil, ErrorResponse(req.ToolCallID,
+			fmt.Sprintf("No job %s in this task", id),
+			"Job not found.",
+			CodePermissionDenied, false)
+	}
+	return j, Response{}
+}
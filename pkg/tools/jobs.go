@@ -0,0 +1,249 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sypherexx/sypher-mini/pkg/audit"
+	"github.com/sypherexx/sypher-mini/pkg/process"
+)
+
+const (
+	jobBufferBytes = 64 << 10
+	maxJobsPerTask = 8
+	jobStopGrace   = 3 * time.Second
+)
+
+// Job is a command started by exec with background: true.
+type Job struct {
+	ID         string
+	TaskID     string
+	ToolCallID string
+	Command    string
+	Dir        string
+	PID        int
+	StartedAt  time.Time
+
+	cmd      *exec.Cmd
+	cleanup  func()
+	output   *ringBuffer
+	done     chan struct{}
+	mu       sync.Mutex
+	exitCode int
+	endedAt  time.Time
+	stopped  bool
+}
+
+// Running reports whether the job's process has not exited yet.
+func (j *Job) Running() bool {
+	select {
+	case <-j.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Status describes the job in one line.
+func (j *Job) Status() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.Running() {
+		return fmt.Sprintf("%s pid=%d running for %s: %s", j.ID, j.PID, time.Since(j.StartedAt).Round(time.Second), j.Command)
+	}
+	state := fmt.Sprintf("exited (code %d)", j.exitCode)
+	if j.stopped {
+		state = "stopped"
+	}
+	return fmt.Sprintf("%s pid=%d %s after %s: %s", j.ID, j.PID, state, j.endedAt.Sub(j.StartedAt).Round(time.Second), j.Command)
+}
+
+// Output returns the last n lines of the job's captured output.
+func (j *Job) Output(n int) string {
+	lines := strings.Split(strings.TrimRight(j.output.String(), "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// JobManager owns the background jobs of all tasks.
+type JobManager struct {
+	procTracker *process.Tracker
+	auditLogger *audit.Logger
+	mu          sync.Mutex
+	jobs        map[string]map[string]*Job // task ID -> job ID -> job
+	nextID      int
+}
+
+// NewJobManager creates a job manager. PIDs are recorded in procTracker so the
+// kill tool accepts them too.
+func NewJobManager(procTracker *process.Tracker, auditLogger *audit.Logger) *JobManager {
+	return &JobManager{
+		procTracker: procTracker,
+		auditLogger: auditLogger,
+		jobs:        make(map[string]map[string]*Job),
+	}
+}
+
+// Start starts cmd as a background job of the task, capturing stdout and
+// stderr in a ring buffer. The exit is written to the audit log and cleanup
+// (from the executor that built cmd) runs once the job has exited.
+func (m *JobManager) Start(taskID, toolCallID, command string, cmd *exec.Cmd, cleanup func()) (*Job, error) {
+	m.mu.Lock()
+	if len(m.jobs[taskID]) >= maxJobsPerTask {
+		m.mu.Unlock()
+		return nil, fmt.Errorf("task already has %d background jobs; stop one first", maxJobsPerTask)
+	}
+	m.nextID++
+	id := fmt.Sprintf("job-%d", m.nextID)
+	m.mu.Unlock()
+
+	process.SetGroup(cmd)
+	buf := newRingBuffer(jobBufferBytes)
+	cmd.Stdout = buf
+	cmd.Stderr = buf
+	// Children that inherit the output pipe must not keep Wait blocked after the job exits
+	cmd.WaitDelay = time.Second
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	j := &Job{
+		ID:         id,
+		TaskID:     taskID,
+		ToolCallID: toolCallID,
+		Command:    command,
+		Dir:        cmd.Dir,
+		PID:        cmd.Process.Pid,
+		StartedAt:  time.Now(),
+		cmd:        cmd,
+		cleanup:    cleanup,
+		output:     buf,
+		done:       make(chan struct{}),
+	}
+	if m.procTracker != nil {
+		m.procTracker.RecordGroup(taskID, j.PID)
+	}
+	m.mu.Lock()
+	if m.jobs[taskID] == nil {
+		m.jobs[taskID] = make(map[string]*Job)
+	}
+	m.jobs[taskID][id] = j
+	m.mu.Unlock()
+
+	go m.wait(j)
+	return j, nil
+}
+
+func (m *JobManager) wait(j *Job) {
+	err := j.cmd.Wait()
+	if j.cleanup != nil {
+		j.cleanup()
+	}
+	code := 0
+	if err != nil {
+		code = -1
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			code = exitErr.ExitCode()
+		}
+	}
+	j.mu.Lock()
+	j.exitCode = code
+	j.endedAt = time.Now()
+	j.mu.Unlock()
+	close(j.done)
+	if m.procTracker != nil && !process.Alive(j.PID) {
+		m.procTracker.Forget(j.TaskID, j.PID)
+	}
+	if m.auditLogger != nil {
+		_ = m.auditLogger.LogCommand(j.TaskID, j.ToolCallID, j.Command, j.Dir, code, "[background "+j.ID+"] "+j.Output(20))
+	}
+}
+
+// Get returns a job of the task. Jobs of other tasks are not visible.
+func (m *JobManager) Get(taskID, jobID string) (*Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	j, ok := m.jobs[taskID][jobID]
+	return j, ok
+}
+
+// List returns the task's jobs ordered by start time.
+func (m *JobManager) List(taskID string) []*Job {
+	m.mu.Lock()
+	var out []*Job
+	for _, j := range m.jobs[taskID] {
+		out = append(out, j)
+	}
+	m.mu.Unlock()
+	sort.Slice(out, func(a, b int) bool { return out[a].StartedAt.Before(out[b].StartedAt) })
+	return out
+}
+
+// Stop terminates a job's process group: SIGTERM first, SIGKILL for whatever
+// is still running after a grace period. Jobs that have exited are left alone;
+// their PID may already belong to another process.
+func (m *JobManager) Stop(j *Job) {
+	if !j.Running() {
+		return
+	}
+	j.mu.Lock()
+	j.stopped = true
+	j.mu.Unlock()
+	_ = process.Terminate(j.PID, jobStopGrace)
+	<-j.done
+}
+
+// RemoveTask stops and forgets all jobs of a task (e.g. on completion).
+func (m *JobManager) RemoveTask(taskID string) {
+	m.mu.Lock()
+	jobs := m.jobs[taskID]
+	delete(m.jobs, taskID)
+	m.mu.Unlock()
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		wg.Add(1)
+		go func(j *Job) {
+			defer wg.Done()
+			m.Stop(j)
+		}(j)
+	}
+	wg.Wait()
+}
+
+// ringBuffer keeps the last size bytes written to it.
+type ringBuffer struct {
+	mu   sync.Mutex
+	buf  []byte
+	size int
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{size: size}
+}
+
+func (r *ringBuffer) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buf = append(r.buf, p...)
+	if over := len(r.buf) - r.size; over > 0 {
+		// Drop whole lines where possible so output starts cleanly
+		cut := over
+		if i := bytes.IndexByte(r.buf[over:], '\n'); i >= 0 && i < 1024 {
+			cut = over + i + 1
+		}
+		r.buf = append(r.buf[:0:0], r.buf[cut:]...)
+	}
+	return len(p), nil
+}
+
+func (r *ringBuffer) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return string(r.buf)
+}
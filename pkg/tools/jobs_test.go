@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sypherexx/sypher-mini/pkg/audit"
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/process"
+)
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 5s")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestExecTool_Background(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	pt := process.New()
+	execTool := NewExecTool(cfg, audit.New(t.TempDir()), pt, false)
+	jobs := execTool.Jobs()
+	ctx := context.Background()
+
+	start := time.Now()
+	resp := execTool.Execute(ctx, Request{
+		ToolCallID: "tc1", TaskID: "t1", AgentID: "main",
+		Args: map[string]interface{}{"command": "echo ready; sleep 30", "background": true},
+	})
+	if resp.IsError {
+		t.Fatal(resp.ForLLM)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Fatal("background exec should return immediately")
+	}
+	list := jobs.List("t1")
+	if len(list) != 1 {
+		t.Fatalf("expected 1 job, got %d", len(list))
+	}
+	job := list[0]
+	if !strings.Contains(resp.ForLLM, job.ID) || !pt.CanKill("t1", job.PID) {
+		t.Fatalf("job ID and PID should be returned and tracked: %s", resp.ForLLM)
+	}
+
+	output := NewJobOutputTool(jobs)
+	waitFor(t, func() bool {
+		r := output.Execute(ctx, Request{TaskID: "t1", Args: map[string]interface{}{"job_id": job.ID}})
+		return strings.Contains(r.ForLLM, "ready")
+	})
+
+	status := NewJobStatusTool(jobs).Execute(ctx, Request{TaskID: "t1", Args: map[string]interface{}{}})
+	if !strings.Contains(status.ForLLM, "running") {
+		t.Errorf("status: %q", status.ForLLM)
+	}
+
+	// Jobs of other tasks are invisible
+	if r := output.Execute(ctx, Request{TaskID: "t2", Args: map[string]interface{}{"job_id": job.ID}}); !r.IsError {
+		t.Error("job should not be visible to another task")
+	}
+
+	stop := NewJobStopTool(jobs, false).Execute(ctx, Request{TaskID: "t1", Args: map[string]interface{}{"job_id": job.ID}})
+	if stop.IsError || !strings.Contains(stop.ForLLM, "stopped") {
+		t.Errorf("stop: %+v", stop)
+	}
+	if job.Running() {
+		t.Error("job should have exited")
+	}
+}
+
+func TestJobManager_RemoveTaskStopsJobs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	execTool := NewExecTool(cfg, nil, process.New(), false)
+	resp := execTool.Execute(context.Background(), Request{
+		TaskID: "t1", Args: map[string]interface{}{"command": "sleep 30", "background": true},
+	})
+	if resp.IsError {
+		t.Fatal(resp.ForLLM)
+	}
+	job := execTool.Jobs().List("t1")[0]
+
+	execTool.Jobs().RemoveTask("t1")
+	if job.Running() {
+		t.Error("RemoveTask should stop running jobs")
+	}
+	if len(execTool.Jobs().List("t1")) != 0 {
+		t.Error("RemoveTask should forget the task's jobs")
+	}
+}
+
+func TestJobManager_StopExitedJob(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = t.TempDir()
+	execTool := NewExecTool(cfg, nil, process.New(), false)
+	resp := execTool.Execute(context.Background(), Request{
+		TaskID: "t1", Args: map[string]interface{}{"command": "true", "background": true},
+	})
+	if resp.IsError {
+		t.Fatal(resp.ForLLM)
+	}
+	job := execTool.Jobs().List("t1")[0]
+	waitFor(t, func() bool { return !job.Running() })
+
+	start := time.Now()
+	execTool.Jobs().Stop(job)
+	if time.Since(start) > time.Second {
+		t.Error("stopping an exited job should return at once")
+	}
+	if status := job.Status(); !strings.Contains(status, "exited (code 0)") {
+		t.Errorf("exited job should not be marked stopped: %s", status)
+	}
+}
+
+func TestRingBuffer_KeepsTail(t *testing.T) {
+	r := newRingBuffer(16)
+	_, _ = r.Write([]byte("line1\nline2\nline3\n"))
+	_, _ = r.Write([]byte("line4\n"))
+	if got := r.String(); got != "line3\nline4\n" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -3,7 +3,6 @@ package tools
 import (
 	"context"
 	"fmt"
-	"os"
 	"strconv"
 
 	"github.com/sypherexx/sypher-mini/pkg/process"
@@ -23,6 +22,30 @@ func NewKillTool(procTracker *process.Tracker, safeMode bool) *KillTool {
 	}
 }
 
+// Name returns the tool name.
+func (t *KillTool) Name() string { return "kill" }
+
+// Description returns the tool description for the LLM.
+func (t *KillTool) Description() string {
+	return "Kill a process started by Sypher-mini for this task, with everything it spawned (SIGTERM, then SIGKILL). Only PIDs from exec tool can be killed."
+}
+
+// Schema returns the JSON schema of the tool arguments.
+func (t *KillTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"pid": map[string]interface{}{"type": "integer", "description": "Process ID to kill"},
+		},
+		"required": []interface{}{"pid"},
+	}
+}
+
+// Capabilities returns the capabilities this tool provides.
+func (t *KillTool) Capabilities() []string {
+	return []string{"code_generation", "deploy_service"}
+}
+
 // Execute kills a process if it belongs to the task.
 func (t *KillTool) Execute(ctx context.Context, req Request) Response {
 	if t.safeMode {
@@ -76,15 +99,8 @@ func (t *KillTool) Execute(ctx context.Context, req Request) Response {
 			CodePermissionDenied, false)
 	}
 
-	proc, err := os.FindProcess(pid)
-	if err != nil {
-		return ErrorResponse(req.ToolCallID,
-			fmt.Sprintf("FindProcess: %v", err),
-			"Could not find process.",
-			CodePermissionDenied, false)
-	}
-
-	if err := proc.Kill(); err != nil {
+	// Signal the whole process group so children of "sh -c" go too
+	if err := process.Terminate(pid, process.DefaultGrace); err != nil {
 		return ErrorResponse(req.ToolCallID,
 			fmt.Sprintf("Kill failed: %v", err),
 			"Kill failed.",
@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sypherexx/sypher-mini/pkg/audit"
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/policy"
+)
+
+const maxListEntries = 500
+
+// ListDirTool lists the entries of a directory.
+type ListDirTool struct {
+	fileAccess
+}
+
+// NewListDirTool creates a list_dir tool.
+func NewListDirTool(cfg *config.Config, policyEval *policy.Evaluator, auditLogger *audit.Logger, safeMode bool) *ListDirTool {
+	return &ListDirTool{fileAccess: newFileAccess(cfg, policyEval, auditLogger, safeMode)}
+}
+
+// Name returns the tool name.
+func (t *ListDirTool) Name() string { return "list_dir" }
+
+// Description returns the tool description for the LLM.
+func (t *ListDirTool) Description() string {
+	return "List a directory (default: the workspace). Directories end with '/', files show their size. Set recursive to walk subdirectories (.git is skipped)."
+}
+
+// Schema returns the JSON schema of the tool arguments.
+func (t *ListDirTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path":      map[string]interface{}{"type": "string", "description": "Directory path (default: workspace)"},
+			"recursive": map[string]interface{}{"type": "boolean", "description": "Include subdirectories (default false)"},
+		},
+	}
+}
+
+// Capabilities returns the capabilities this tool provides.
+func (t *ListDirTool) Capabilities() []string {
+	return []string{"log_analysis", "file_edit"}
+}
+
+// ParallelSafe reports that listings may run concurrently.
+func (t *ListDirTool) ParallelSafe() bool { return true }
+
+// Execute lists the directory.
+func (t *ListDirTool) Execute(ctx context.Context, req Request) Response {
+	p, _ := req.Args["path"].(string)
+	if p == "" {
+		p = "."
+	}
+	recursive, _ := req.Args["recursive"].(bool)
+	dir, denied := t.resolve(req, p, accessRead)
+	if denied != nil {
+		return *denied
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fileError(req, fmt.Errorf("list %s: %w", p, err), "Could not list directory.")
+	}
+	if !info.IsDir() {
+		return fileError(req, fmt.Errorf("%s is not a directory", p), "Could not list directory.")
+	}
+
+	var entries []string
+	truncated := false
+	walkErr := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		if path == dir {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if len(entries) >= maxListEntries {
+			truncated = true
+			return filepath.SkipAll
+		}
+		rel, _ := filepath.Rel(dir, path)
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			entries = append(entries, rel+"/")
+			if !recursive || d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		size := ""
+		if fi, err := d.Info(); err == nil && fi.Mode().IsRegular() {
+			size = fmt.Sprintf("  (%d bytes)", fi.Size())
+		} else if d.Type()&fs.ModeSymlink != 0 {
+			size = "  (symlink)"
+		}
+		entries = append(entries, rel+size)
+		return nil
+	})
+	if walkErr != nil {
+		return fileError(req, fmt.Errorf("list %s: %w", p, walkErr), "Could not list directory.")
+	}
+
+	out := strings.Join(entries, "\n")
+	if len(entries) == 0 {
+		out = "(empty directory)"
+	}
+	if truncated {
+		out += fmt.Sprintf("\n[truncated at %d entries]", maxListEntries)
+	}
+	auditRef := t.audit(req, "list_dir", dir, fmt.Sprintf("entries=%d recursive=%t", len(entries), recursive))
+	return SuccessResponse(req.ToolCallID, out, fmt.Sprintf("Listed %s (%d entries)", p, len(entries)), auditRef)
+}
@@ -30,6 +30,30 @@ func NewMessageTool(msgBus *bus.MessageBus, safeMode bool) *MessageTool {
 	}
 }
 
+// Name returns the tool name.
+func (t *MessageTool) Name() string { return "message" }
+
+// Description returns the tool description for the LLM.
+func (t *MessageTool) Description() string {
+	return "Send a message to the user in the current conversation."
+}
+
+// Schema returns the JSON schema of the tool arguments.
+func (t *MessageTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"content": map[string]interface{}{"type": "string", "description": "Message content to send"},
+		},
+		"required": []interface{}{"content"},
+	}
+}
+
+// Capabilities returns the capabilities this tool provides.
+func (t *MessageTool) Capabilities() []string {
+	return []string{"notify_user"}
+}
+
 // SetReplyTarget sets the reply target for a task (called by agent loop).
 func (t *MessageTool) SetReplyTarget(taskID string, channel, chatID string) {
 	t.targetsMu.Lock()
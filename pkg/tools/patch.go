@@ -0,0 +1,162 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hunk is one "@@" section of a unified diff.
+type hunk struct {
+	oldStart int      // 1-based line in the original file (0 for an empty file)
+	oldLines []string // context and removed lines
+	newLines []string // context and added lines
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+// parseHunks parses the hunks of a single-file unified diff. File headers
+// (---/+++, diff --git, index) are skipped and hunk line counts are not
+// trusted: a hunk ends at the next header. Empty lines count as empty context.
+func parseHunks(diff string) ([]hunk, error) {
+	lines := strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n")
+	var hunks []hunk
+	var cur *hunk
+	for i, line := range lines {
+		if m := hunkHeader.FindStringSubmatch(line); m != nil {
+			start, _ := strconv.Atoi(m[1])
+			hunks = append(hunks, hunk{oldStart: start})
+			cur = &hunks[len(hunks)-1]
+			continue
+		}
+		if cur == nil || strings.HasPrefix(line, "diff ") ||
+			(strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")) {
+			if len(hunks) > 0 && strings.HasPrefix(line, "--- ") {
+				return nil, fmt.Errorf("diff touches more than one file; send one diff per file")
+			}
+			cur = nil
+			continue
+		}
+		switch {
+		case line == "" && i == len(lines)-1:
+			// trailing newline of the diff itself
+		case line == "":
+			cur.oldLines = append(cur.oldLines, "")
+			cur.newLines = append(cur.newLines, "")
+		case line[0] == ' ':
+			cur.oldLines = append(cur.oldLines, line[1:])
+			cur.newLines = append(cur.newLines, line[1:])
+		case line[0] == '-':
+			cur.oldLines = append(cur.oldLines, line[1:])
+		case line[0] == '+':
+			cur.newLines = append(cur.newLines, line[1:])
+		case line[0] == '\\':
+			// "\ No newline at end of file": the file keeps its trailing newline state
+		default:
+			return nil, fmt.Errorf("invalid diff line %q (expected ' ', '-' or '+' prefix)", line)
+		}
+	}
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("no hunks found (expected @@ -start,count +start,count @@ headers)")
+	}
+	return hunks, nil
+}
+
+// applyHunks applies hunks to content in order. Each hunk's context and
+// removed lines must match the file; the match closest to the hunk's stated
+// position wins, so stale line numbers are tolerated. Trailing whitespace is
+// ignored only when no exact match exists.
+func applyHunks(content string, hunks []hunk) (string, error) {
+	trailingNewline := strings.HasSuffix(content, "\n")
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	from, delta := 0, 0
+	for n, h := range hunks {
+		want := h.oldStart - 1 + delta
+		if h.oldStart == 0 {
+			want = 0
+		}
+		var pos int
+		if len(h.oldLines) == 0 {
+			pos = clamp(want, from, len(lines))
+		} else {
+			pos = findBlock(lines, h.oldLines, from, want, false)
+			if pos < 0 {
+				pos = findBlock(lines, h.oldLines, from, want, true)
+			}
+		}
+		if pos < 0 {
+			return "", fmt.Errorf("hunk %d (@@ -%d) does not match the file: %q not found", n+1, h.oldStart, firstLine(h.oldLines))
+		}
+		out := make([]string, 0, len(lines)-len(h.oldLines)+len(h.newLines))
+		out = append(out, lines[:pos]...)
+		out = append(out, h.newLines...)
+		out = append(out, lines[pos+len(h.oldLines):]...)
+		lines = out
+		from = pos + len(h.newLines)
+		delta += len(h.newLines) - len(h.oldLines)
+	}
+
+	result := strings.Join(lines, "\n")
+	if trailingNewline || (content == "" && len(lines) > 0) {
+		result += "\n"
+	}
+	return result, nil
+}
+
+// findBlock returns the start of block in lines at or after from, nearest to want.
+func findBlock(lines, block []string, from, want int, loose bool) int {
+	best := -1
+	for i := from; i+len(block) <= len(lines); i++ {
+		if !blockAt(lines, block, i, loose) {
+			continue
+		}
+		if best < 0 || abs(i-want) < abs(best-want) {
+			best = i
+		}
+	}
+	return best
+}
+
+func blockAt(lines, block []string, at int, loose bool) bool {
+	for j, b := range block {
+		l := lines[at+j]
+		if loose {
+			l, b = strings.TrimRight(l, " \t"), strings.TrimRight(b, " \t")
+		}
+		if l != b {
+			return false
+		}
+	}
+	return true
+}
+
+func firstLine(lines []string) string {
+	for _, l := range lines {
+		if strings.TrimSpace(l) != "" {
+			return l
+		}
+	}
+	return ""
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/sypherexx/sypher-mini/pkg/audit"
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/policy"
+)
+
+const (
+	defaultReadLines = 2000
+	maxReadChars     = 32000
+)
+
+// ReadFileTool reads a text file, optionally a line range, with line numbers.
+type ReadFileTool struct {
+	fileAccess
+}
+
+// NewReadFileTool creates a read_file tool.
+func NewReadFileTool(cfg *config.Config, policyEval *policy.Evaluator, auditLogger *audit.Logger, safeMode bool) *ReadFileTool {
+	return &ReadFileTool{fileAccess: newFileAccess(cfg, policyEval, auditLogger, safeMode)}
+}
+
+// Name returns the tool name.
+func (t *ReadFileTool) Name() string { return "read_file" }
+
+// Description returns the tool description for the LLM.
+func (t *ReadFileTool) Description() string {
+	return "Read a text file. Lines are prefixed with their line number and a tab. Use start_line/end_line to read part of a large file."
+}
+
+// Schema returns the JSON schema of the tool arguments.
+func (t *ReadFileTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path":       map[string]interface{}{"type": "string", "description": "File path (relative to the workspace or absolute)"},
+			"start_line": map[string]interface{}{"type": "integer", "description": "First line to read, 1-based (default 1)"},
+			"end_line":   map[string]interface{}{"type": "integer", "description": "Last line to read, inclusive (default start_line+1999)"},
+		},
+		"required": []interface{}{"path"},
+	}
+}
+
+// Capabilities returns the capabilities this tool provides.
+func (t *ReadFileTool) Capabilities() []string {
+	return []string{"log_analysis", "file_edit"}
+}
+
+// ParallelSafe reports that reads may run concurrently.
+func (t *ReadFileTool) ParallelSafe() bool { return true }
+
+// Execute reads the requested lines of a file.
+func (t *ReadFileTool) Execute(ctx context.Context, req Request) Response {
+	p, _ := req.Args["path"].(string)
+	if p == "" {
+		return ErrorResponse(req.ToolCallID,
+			"Missing 'path' argument",
+			"Path is required.",
+			CodePermissionDenied, false)
+	}
+	path, denied := t.resolve(req, p, accessRead)
+	if denied != nil {
+		return *denied
+	}
+
+	data, err := readTextFile(path)
+	if err != nil {
+		return fileError(req, fmt.Errorf("read %s: %w", p, err), "Could not read file.")
+	}
+
+	lines := strings.Split(string(data), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	total := len(lines)
+
+	start := 1
+	if v, ok := req.Args["start_line"].(float64); ok && v >= 1 {
+		start = int(v)
+	}
+	end := start + defaultReadLines - 1
+	if v, ok := req.Args["end_line"].(float64); ok && v >= 1 {
+		end = int(v)
+	}
+	if end > total {
+		end = total
+	}
+	if start > total && total > 0 {
+		return ErrorResponse(req.ToolCallID,
+			fmt.Sprintf("start_line %d is past the end of %s (%d lines)", start, p, total),
+			"Line range out of bounds.",
+			CodePermissionDenied, false)
+	}
+	if end < start {
+		end = start - 1
+	}
+
+	var b strings.Builder
+	truncated := false
+	last := start - 1
+	for i := start; i <= end; i++ {
+		line := fmt.Sprintf("%6d\t%s\n", i, lines[i-1])
+		if b.Len()+len(line) > maxReadChars {
+			truncated = true
+			break
+		}
+		b.WriteString(line)
+		last = i
+	}
+	if truncated || start > 1 || last < total {
+		fmt.Fprintf(&b, "[lines %d-%d of %d", start, last, total)
+		if last < total {
+			fmt.Fprintf(&b, "; continue with start_line=%d", last+1)
+		}
+		b.WriteString("]")
+	}
+
+	auditRef := t.audit(req, "read_file", path, fmt.Sprintf("lines=%d-%d of %d", start, last, total))
+	forUser := fmt.Sprintf("Read %s (lines %d-%d of %d)", p, start, last, total)
+	return SuccessResponse(req.ToolCallID, strings.TrimSuffix(b.String(), "\n"), forUser, auditRef)
+}
@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/sypherexx/sypher-mini/pkg/audit"
+	"github.com/sypherexx/sypher-mini/pkg/bus"
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/policy"
+	"github.com/sypherexx/sypher-mini/pkg/process"
+)
+
+// Tool is a callable tool exposed to the LLM.
+type Tool interface {
+	// Name is the unique tool name the LLM calls.
+	Name() string
+	// Description tells the LLM what the tool does.
+	Description() string
+	// Schema is the JSON schema of the tool arguments.
+	Schema() map[string]interface{}
+	// Capabilities lists the capabilities the tool provides (see pkg/capabilities).
+	// An agent only sees a tool when it holds one of these; none = every agent.
+	Capabilities() []string
+	// Execute runs the tool.
+	Execute(ctx context.Context, req Request) Response
+}
+
+// Registry holds the tools available to the agent loop.
+type Registry struct {
+	tools map[string]Tool
+	order []string
+	mu    sync.RWMutex
+}
+
+// NewRegistry creates an empty tool registry.
+func NewRegistry() *Registry {
+	return &Registry{tools: make(map[string]Tool)}
+}
+
+// Register adds a tool. It fails if a tool with the same name is registered.
+func (r *Registry) Register(t Tool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := t.Name()
+	if name == "" {
+		return fmt.Errorf("tool has empty name")
+	}
+	if _, ok := r.tools[name]; ok {
+		return fmt.Errorf("tool already registered: %s", name)
+	}
+	r.tools[name] = t
+	r.order = append(r.order, name)
+	return nil
+}
+
+// Get returns the tool by name.
+func (r *Registry) Get(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tools[name]
+	return t, ok
+}
+
+// List returns all tools in registration order.
+func (r *Registry) List() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Tool, 0, len(r.order))
+	for _, name := range r.order {
+		out = append(out, r.tools[name])
+	}
+	return out
+}
+
+// Env holds the shared dependencies passed to tool factories.
+type Env struct {
+	Config      *config.Config
+	MsgBus      *bus.MessageBus
+	AuditLogger *audit.Logger
+	ProcTracker *process.Tracker
+	PolicyEval  *policy.Evaluator
+	SafeMode    bool
+}
+
+// Factory builds a tool from the shared environment.
+type Factory func(env Env) Tool
+
+var (
+	factories   = make(map[string]Factory)
+	factoriesMu sync.RWMutex
+)
+
+// RegisterFactory registers a tool factory so every agent loop created afterwards
+// includes the tool. Third-party packages typically call it from init().
+func RegisterFactory(name string, f Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[name] = f
+}
+
+// Factories returns registered factories sorted by name.
+func Factories() []Factory {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]Factory, 0, len(names))
+	for _, name := range names {
+		out = append(out, factories[name])
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"context"
+	"testing"
+)
+
+type stubTool struct{ name string }
+
+func (s stubTool) Name() string                   { return s.name }
+func (s stubTool) Description() string            { return "stub" }
+func (s stubTool) Schema() map[string]interface{} { return map[string]interface{}{"type": "object"} }
+func (s stubTool) Capabilities() []string         { return nil }
+func (s stubTool) Execute(ctx context.Context, req Request) Response {
+	return SuccessResponse(req.ToolCallID, s.name, "", "")
+}
+
+func TestRegistry_RegisterGetList(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Register(stubTool{"b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(stubTool{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(stubTool{"a"}); err == nil {
+		t.Error("duplicate registration should fail")
+	}
+	if err := r.Register(stubTool{""}); err == nil {
+		t.Error("empty name should fail")
+	}
+	if _, ok := r.Get("a"); !ok {
+		t.Error("expected tool a")
+	}
+	list := r.List()
+	if len(list) != 2 || list[0].Name() != "b" || list[1].Name() != "a" {
+		t.Errorf("expected registration order [b a], got %v", list)
+	}
+}
@@ -0,0 +1,353 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/sypherexx/sypher-mini/pkg/audit"
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/policy"
+)
+
+// Search modes.
+const (
+	searchRegex   = "regex"
+	searchLiteral = "literal"
+	searchSymbol  = "symbol"
+)
+
+const (
+	defaultSearchResults = 100
+	maxSearchResults     = 500
+	maxSearchFileBytes   = 1 << 20
+	maxMatchLineChars    = 200
+)
+
+// SearchCodeTool searches the workspace for text or Go declarations.
+type SearchCodeTool struct {
+	fileAccess
+}
+
+// NewSearchCodeTool creates a search_code tool.
+func NewSearchCodeTool(cfg *config.Config, policyEval *policy.Evaluator, auditLogger *audit.Logger, safeMode bool) *SearchCodeTool {
+	return &SearchCodeTool{fileAccess: newFileAccess(cfg, policyEval, auditLogger, safeMode)}
+}
+
+// Name returns the tool name.
+func (t *SearchCodeTool) Name() string { return "search_code" }
+
+// Description returns the tool description for the LLM.
+func (t *SearchCodeTool) Description() string {
+	return "Search files in the workspace and return file:line matches. Modes: literal (default), regex (Go RE2 syntax), " +
+		"and symbol (Go declarations whose name contains query; empty query lists all). .gitignore'd files are skipped."
+}
+
+// Schema returns the JSON schema of the tool arguments.
+func (t *SearchCodeTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query":            map[string]interface{}{"type": "string", "description": "Text, regex or symbol name to search for"},
+			"mode":             map[string]interface{}{"type": "string", "enum": []interface{}{searchLiteral, searchRegex, searchSymbol}, "description": "Search mode (default literal)"},
+			"path":             map[string]interface{}{"type": "string", "description": "File or directory to search (default: workspace)"},
+			"glob":             map[string]interface{}{"type": "string", "description": "Only search files whose name matches, e.g. *.go"},
+			"case_insensitive": map[string]interface{}{"type": "boolean", "description": "Ignore case (default false)"},
+			"max_results":      map[string]interface{}{"type": "integer", "description": "Maximum matches (default 100, max 500)"},
+		},
+		"required": []interface{}{"query"},
+	}
+}
+
+// Capabilities returns the capabilities this tool provides.
+func (t *SearchCodeTool) Capabilities() []string {
+	return []string{"code_generation", "log_analysis", "file_edit"}
+}
+
+// ParallelSafe reports that searches may run concurrently.
+func (t *SearchCodeTool) ParallelSafe() bool { return true }
+
+// Execute runs the search.
+func (t *SearchCodeTool) Execute(ctx context.Context, req Request) Response {
+	query, _ := req.Args["query"].(string)
+	mode, _ := req.Args["mode"].(string)
+	if mode == "" {
+		mode = searchLiteral
+	}
+	glob, _ := req.Args["glob"].(string)
+	caseInsensitive, _ := req.Args["case_insensitive"].(bool)
+	limit := defaultSearchResults
+	if v, ok := req.Args["max_results"].(float64); ok && v > 0 {
+		limit = int(v)
+		if limit > maxSearchResults {
+			limit = maxSearchResults
+		}
+	}
+	if glob != "" {
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return ErrorResponse(req.ToolCallID, "Invalid glob: "+err.Error(), "Invalid search arguments.", CodePermissionDenied, false)
+		}
+	}
+
+	var match func(file string, emit func(line int, text string) bool) error
+	switch mode {
+	case searchLiteral, searchRegex:
+		if query == "" {
+			return ErrorResponse(req.ToolCallID, "Missing 'query' argument", "Query is required.", CodePermissionDenied, false)
+		}
+		expr := query
+		if mode == searchLiteral {
+			expr = regexp.QuoteMeta(query)
+		}
+		if caseInsensitive {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return ErrorResponse(req.ToolCallID, "Invalid regex: "+err.Error(), "Invalid search arguments.", CodePermissionDenied, false)
+		}
+		match = func(file string, emit func(int, string) bool) error { return grepFile(file, re, emit) }
+	case searchSymbol:
+		if glob == "" {
+			glob = "*.go"
+		}
+		match = func(file string, emit func(int, string) bool) error {
+			if !strings.HasSuffix(file, ".go") {
+				return nil
+			}
+			return goSymbols(file, query, emit)
+		}
+	default:
+		return ErrorResponse(req.ToolCallID,
+			fmt.Sprintf("Unknown mode %q (use %s, %s or %s)", mode, searchLiteral, searchRegex, searchSymbol),
+			"Invalid search arguments.", CodePermissionDenied, false)
+	}
+
+	p, _ := req.Args["path"].(string)
+	if p == "" {
+		p = "."
+	}
+	root, denied := t.resolve(req, p, accessRead)
+	if denied != nil {
+		return *denied
+	}
+	if _, err := os.Stat(root); err != nil {
+		return fileError(req, fmt.Errorf("search %s: %w", p, err), "Could not search path.")
+	}
+
+	// .gitignore files are read from the workspace down, so rules above the
+	// searched directory still apply.
+	workspace := realPath(agentWorkspace(t.cfg, req.AgentID, t.workspace))
+	ignoreRoot := root
+	if withinDir(root, workspace) {
+		ignoreRoot = workspace
+	}
+	ignores := newGitignoreSet(ignoreRoot)
+	if root != ignoreRoot {
+		rel := relSlash(ignoreRoot, root)
+		ignores.load("")
+		for i := 0; i < len(rel); i++ {
+			if rel[i] == '/' {
+				ignores.load(rel[:i])
+			}
+		}
+	}
+
+	var results []string
+	files := 0
+	truncated := false
+	walkErr := filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if file == root {
+				return err
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		rel := relSlash(ignoreRoot, file)
+		if d.IsDir() {
+			if file != root && (d.Name() == ".git" || ignores.ignored(rel, true)) {
+				return filepath.SkipDir
+			}
+			ignores.load(rel)
+			return nil
+		}
+		// Symlinks are not followed: they could lead outside the allowed tree
+		if !d.Type().IsRegular() || (file != root && ignores.ignored(rel, false)) {
+			return nil
+		}
+		if glob != "" {
+			if ok, _ := filepath.Match(glob, d.Name()); !ok {
+				return nil
+			}
+		}
+		if !t.policyEval.CanAccessFile(req.AgentID, file, accessRead) {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxSearchFileBytes {
+			return nil
+		}
+		files++
+		display := t.displayPath(workspace, file)
+		_ = match(file, func(line int, text string) bool {
+			if len(results) >= limit {
+				truncated = true
+				return false
+			}
+			results = append(results, fmt.Sprintf("%s:%d: %s", display, line, text))
+			return true
+		})
+		if truncated {
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if walkErr != nil {
+		return fileError(req, fmt.Errorf("search %s: %w", p, walkErr), "Search failed.")
+	}
+
+	out := strings.Join(results, "\n")
+	if len(results) == 0 {
+		out = fmt.Sprintf("No matches (%d files searched)", files)
+	}
+	if truncated {
+		out += fmt.Sprintf("\n[results truncated at %d; narrow the query, path or glob]", limit)
+	}
+	auditRef := t.audit(req, "search_code", root, fmt.Sprintf("mode=%s query=%q matches=%d files=%d", mode, query, len(results), files))
+	return SuccessResponse(req.ToolCallID, out, fmt.Sprintf("Found %d match(es) in %d files", len(results), files), auditRef)
+}
+
+// displayPath shows workspace files relative to the workspace.
+func (t *SearchCodeTool) displayPath(workspace, file string) string {
+	if withinDir(file, workspace) && file != workspace {
+		return relSlash(workspace, file)
+	}
+	return file
+}
+
+// grepFile emits the lines of a text file matching re. Binary files are skipped.
+func grepFile(file string, re *regexp.Regexp, emit func(int, string) bool) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), maxSearchFileBytes)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		if strings.IndexByte(line, 0) >= 0 {
+			return nil
+		}
+		if !re.MatchString(line) {
+			continue
+		}
+		line = strings.TrimSpace(line)
+		if len(line) > maxMatchLineChars {
+			line = line[:maxMatchLineChars] + "..."
+		}
+		if !emit(n, line) {
+			return nil
+		}
+	}
+	return sc.Err()
+}
+
+// goSymbols emits the top-level declarations of a Go file whose name contains
+// query (case-insensitive), as "kind signature".
+func goSymbols(file, query string, emit func(int, string) bool) error {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, nil, parser.SkipObjectResolution)
+	if f == nil {
+		return err
+	}
+	query = strings.ToLower(query)
+	want := func(name string) bool { return strings.Contains(strings.ToLower(name), query) }
+	line := func(pos token.Pos) int { return fset.Position(pos).Line }
+
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !want(d.Name.Name) {
+				continue
+			}
+			sig := "func " + d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				sig = fmt.Sprintf("method (%s) %s", types.ExprString(d.Recv.List[0].Type), d.Name.Name)
+			}
+			if !emit(line(d.Pos()), sig+funcSignature(d.Type)) {
+				return nil
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if want(s.Name.Name) && !emit(line(s.Pos()), "type "+s.Name.Name+" "+typeKind(s.Type)) {
+						return nil
+					}
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						if n.Name != "_" && want(n.Name) && !emit(line(n.Pos()), d.Tok.String()+" "+n.Name) {
+							return nil
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// funcSignature renders the parameter and result lists of a function type.
+func funcSignature(ft *ast.FuncType) string {
+	fields := func(fl *ast.FieldList) string {
+		if fl == nil {
+			return ""
+		}
+		var parts []string
+		for _, f := range fl.List {
+			typ := types.ExprString(f.Type)
+			if len(f.Names) == 0 {
+				parts = append(parts, typ)
+				continue
+			}
+			for _, n := range f.Names {
+				parts = append(parts, n.Name+" "+typ)
+			}
+		}
+		return strings.Join(parts, ", ")
+	}
+	sig := "(" + fields(ft.Params) + ")"
+	if res := fields(ft.Results); res != "" {
+		if len(ft.Results.List) == 1 && len(ft.Results.List[0].Names) == 0 {
+			sig += " " + res
+		} else {
+			sig += " (" + res + ")"
+		}
+	}
+	return sig
+}
+
+// typeKind names the kind of a type declaration.
+func typeKind(expr ast.Expr) string {
+	switch expr.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	case *ast.FuncType:
+		return "func"
+	}
+	return types.ExprString(expr)
+}
@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSearchCodeTool_TextModes(t *testing.T) {
+	cfg, ws := fileToolsConfig(t)
+	writeTree(t, ws, map[string]string{
+		".gitignore":       "build/\n*.log\n!keep.log\n",
+		"main.go":          "package main\n\n// TODO: fix a.b\nfunc main() {}\n",
+		"pkg/util.go":      "package pkg\n\nvar x = \"todo: later\"\n",
+		"pkg/.gitignore":   "generated.go\n",
+		"pkg/generated.go": "package pkg // TODO generated\n",
+		"build/out.go":     "// TODO in build output\n",
+		"debug.log":        "TODO in log\n",
+		"keep.log":         "TODO kept\n",
+		".git/COMMIT_MSG":  "TODO in git dir\n",
+	})
+	tool := NewSearchCodeTool(cfg, nil, nil, false)
+
+	resp := tool.Execute(context.Background(), fileReq("search_code", map[string]interface{}{"query": "TODO"}))
+	if resp.IsError {
+		t.Fatal(resp.ForLLM)
+	}
+	want := "keep.log:1: TODO kept\nmain.go:3: // TODO: fix a.b"
+	if resp.ForLLM != want {
+		t.Errorf("literal: got %q, want %q", resp.ForLLM, want)
+	}
+
+	// Literal mode does not interpret regex syntax
+	resp = tool.Execute(context.Background(), fileReq("search_code", map[string]interface{}{"query": "a.b", "mode": "literal"}))
+	if !strings.HasPrefix(resp.ForLLM, "main.go:3:") {
+		t.Errorf("literal a.b: got %q", resp.ForLLM)
+	}
+
+	resp = tool.Execute(context.Background(), fileReq("search_code", map[string]interface{}{
+		"query": `^var \w+ = "todo`, "mode": "regex", "path": "pkg", "glob": "*.go",
+	}))
+	if resp.ForLLM != `pkg/util.go:3: var x = "todo: later"` {
+		t.Errorf("regex: got %q", resp.ForLLM)
+	}
+
+	resp = tool.Execute(context.Background(), fileReq("search_code", map[string]interface{}{
+		"query": "todo", "case_insensitive": true, "max_results": float64(1),
+	}))
+	if !strings.Contains(resp.ForLLM, "[results truncated at 1") {
+		t.Errorf("expected truncation note, got %q", resp.ForLLM)
+	}
+}
+
+func TestSearchCodeTool_Symbols(t *testing.T) {
+	cfg, ws := fileToolsConfig(t)
+	writeTree(t, ws, map[string]string{
+		"server.go": `package srv
+
+type Server struct{}
+
+type Handler interface{}
+
+const DefaultPort = 8080
+
+func NewServer(addr string, opts ...Option) (*Server, error) { return nil, nil }
+
+func (s *Server) Serve() error { return nil }
+`,
+	})
+	tool := NewSearchCodeTool(cfg, nil, nil, false)
+
+	resp := tool.Execute(context.Background(), fileReq("search_code", map[string]interface{}{"query": "serve", "mode": "symbol"}))
+	if resp.IsError {
+		t.Fatal(resp.ForLLM)
+	}
+	want := "server.go:3: type Server struct\n" +
+		"server.go:9: func NewServer(addr string, opts ...Option) (*Server, error)\n" +
+		"server.go:11: method (*Server) Serve() error"
+	if resp.ForLLM != want {
+		t.Errorf("got %q, want %q", resp.ForLLM, want)
+	}
+
+	resp = tool.Execute(context.Background(), fileReq("search_code", map[string]interface{}{"query": "", "mode": "symbol"}))
+	if !strings.Contains(resp.ForLLM, "const DefaultPort") || !strings.Contains(resp.ForLLM, "type Handler interface") {
+		t.Errorf("empty query should list all declarations: %q", resp.ForLLM)
+	}
+}
+
+func TestSearchCodeTool_OutsideWorkspace(t *testing.T) {
+	cfg, _ := fileToolsConfig(t)
+	tool := NewSearchCodeTool(cfg, nil, nil, false)
+	resp := tool.Execute(context.Background(), fileReq("search_code", map[string]interface{}{"query": "root", "path": "/etc"}))
+	if !resp.IsError || resp.Code != CodePermissionDenied {
+		t.Errorf("expected permission denied, got %+v", resp)
+	}
+}
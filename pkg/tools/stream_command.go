@@ -7,23 +7,26 @@ import (
 	"fmt"
 	"os/exec"
 	"path/filepath"
-	"regexp"
-	"runtime"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/sypherexx/sypher-mini/pkg/bus"
 	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/executor"
+	"github.com/sypherexx/sypher-mini/pkg/process"
 )
 
 // StreamCommandTool runs a command and streams output to the user via the message bus.
 type StreamCommandTool struct {
+	cfg                *config.Config
 	msgBus             *bus.MessageBus
 	messageTool        *MessageTool
 	workspace          string
 	restrictToWorkspace bool
 	allowedCommands    []string // allowlist; empty = none allowed
+	guard              *commandGuard
+	env                *CommandEnv
 	safeMode           bool
 }
 
@@ -41,15 +44,46 @@ func NewStreamCommandTool(cfg *config.Config, msgBus *bus.MessageBus, messageToo
 		allowed = cfg.Tools.LiveMonitoring.AllowedCommands
 	}
 	return &StreamCommandTool{
+		cfg:                cfg,
 		msgBus:             msgBus,
 		messageTool:       messageTool,
 		workspace:         workspace,
 		restrictToWorkspace: cfg.Agents.Defaults.RestrictToWorkspace,
 		allowedCommands:   allowed,
+		guard:             newCommandGuard(cfg),
+		env:               NewCommandEnv(cfg, nil, nil),
 		safeMode:          safeMode,
 	}
 }
 
+// SetCommandEnv replaces the builder of the command's environment.
+func (t *StreamCommandTool) SetCommandEnv(env *CommandEnv) { t.env = env }
+
+// Name returns the tool name.
+func (t *StreamCommandTool) Name() string { return "stream_command" }
+
+// Description returns the tool description for the LLM.
+func (t *StreamCommandTool) Description() string {
+	return "Run a command and stream output to the user. Only commands in live_monitoring.allowed_commands are permitted."
+}
+
+// Schema returns the JSON schema of the tool arguments.
+func (t *StreamCommandTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"command":     map[string]interface{}{"type": "string", "description": "Command to run"},
+			"working_dir": map[string]interface{}{"type": "string", "description": "Working directory (optional)"},
+		},
+		"required": []interface{}{"command"},
+	}
+}
+
+// Capabilities returns the capabilities this tool provides.
+func (t *StreamCommandTool) Capabilities() []string {
+	return []string{"log_analysis", "deploy_service"}
+}
+
 // Execute runs the command and streams output to the user.
 func (t *StreamCommandTool) Execute(ctx context.Context, req Request) Response {
 	if t.safeMode {
@@ -75,32 +109,23 @@ func (t *StreamCommandTool) Execute(ctx context.Context, req Request) Response {
 			CodePermissionDenied, false)
 	}
 
-	// Basic deny patterns (subset of exec)
-	deny := []*regexp.Regexp{
-		regexp.MustCompile(`\brm\s+-[rf]{1,2}\b`),
-		regexp.MustCompile(`\bsudo\b`),
-		regexp.MustCompile(`\|\s*sh\b`),
-		regexp.MustCompile(`\|\s*bash\b`),
-	}
-	for _, re := range deny {
-		if re.MatchString(cmdStr) {
-			return ErrorResponse(req.ToolCallID,
-				"Command blocked by safety guard",
-				"Command was blocked for safety.",
-				CodeSafetyBlocked, false)
-		}
+	// Same command rules as exec
+	if reason := t.guard.check(cmdStr); reason != "" {
+		return ErrorResponse(req.ToolCallID,
+			"Command blocked by safety guard: "+reason,
+			"Command was blocked for safety.",
+			CodeSafetyBlocked, false)
 	}
 
+	workspace := agentWorkspace(t.cfg, req.AgentID, t.workspace)
 	workingDir, _ := req.Args["working_dir"].(string)
 	if workingDir == "" {
-		workingDir = t.workspace
+		workingDir = workspace
 	}
 	workingDir = config.ExpandPath(workingDir)
 
 	if t.restrictToWorkspace {
-		abs, _ := filepath.Abs(workingDir)
-		wsAbs, _ := filepath.Abs(t.workspace)
-		if !strings.HasPrefix(abs, wsAbs) {
+		if !inWorkspace(workingDir, workspace) {
 			return ErrorResponse(req.ToolCallID,
 				"Working directory outside workspace",
 				"Command blocked: working directory outside allowed workspace.",
@@ -116,13 +141,14 @@ func (t *StreamCommandTool) Execute(ctx context.Context, req Request) Response {
 		chatID = "default"
 	}
 
-	var cmd *exec.Cmd
-	if runtime.GOOS == "windows" {
-		cmd = exec.CommandContext(ctx, "cmd", "/c", cmdStr)
-	} else {
-		cmd = exec.CommandContext(ctx, "sh", "-c", cmdStr)
+	cmd, cleanup, errResp := executorCommand(ctx, t.cfg, t.env, req, executor.Spec{Command: cmdStr, Dir: workingDir, Workspace: workspace})
+	if errResp != nil {
+		return *errResp
 	}
-	cmd.Dir = workingDir
+	defer cleanup()
+	process.SetGroup(cmd)
+	cmd.Cancel = func() error { return process.KillGroup(cmd.Process.Pid) }
+	cmd.WaitDelay = time.Second
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -159,28 +185,36 @@ func (t *StreamCommandTool) Execute(ctx context.Context, req Request) Response {
 		})
 	}
 
+	// Live chunks skip the loop's redaction of the tool result, so each
+	// stream redacts its own, holding back a possible partial secret
+	redactor := t.env.Redactor()
+
 	// Stream stdout
 	go func() {
+		live := redactor.Stream()
 		sc := bufio.NewScanner(stdout)
 		for sc.Scan() {
 			line := sc.Text() + "\n"
 			mu.Lock()
 			buf.WriteString(line)
 			mu.Unlock()
-			sendChunk(line)
+			sendChunk(live.Write(line))
 		}
+		sendChunk(live.Flush())
 	}()
 
 	// Stream stderr
 	go func() {
+		live := redactor.Stream()
 		sc := bufio.NewScanner(stderr)
 		for sc.Scan() {
 			line := "[stderr] " + sc.Text() + "\n"
 			mu.Lock()
 			buf.WriteString(line)
 			mu.Unlock()
-			sendChunk(line)
+			sendChunk(live.Write(line))
 		}
+		sendChunk(live.Flush())
 	}()
 
 	err = cmd.Wait()
@@ -13,6 +13,7 @@ import (
 
 // TailOutputTool reads the last N lines from a file.
 type TailOutputTool struct {
+	cfg                *config.Config
 	workspace          string
 	restrictToWorkspace bool
 	safeMode           bool
@@ -28,12 +29,38 @@ func NewTailOutputTool(cfg *config.Config, safeMode bool) *TailOutputTool {
 		workspace, _ = os.Getwd()
 	}
 	return &TailOutputTool{
+		cfg:                 cfg,
 		workspace:           workspace,
 		restrictToWorkspace: cfg.Agents.Defaults.RestrictToWorkspace,
 		safeMode:            safeMode,
 	}
 }
 
+// Name returns the tool name.
+func (t *TailOutputTool) Name() string { return "tail_output" }
+
+// Description returns the tool description for the LLM.
+func (t *TailOutputTool) Description() string {
+	return "Read the last N lines from a file. Use for live log monitoring."
+}
+
+// Schema returns the JSON schema of the tool arguments.
+func (t *TailOutputTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path":  map[string]interface{}{"type": "string", "description": "File path to read"},
+			"lines": map[string]interface{}{"type": "integer", "description": "Number of lines (default 50, max 1000)"},
+		},
+		"required": []interface{}{"path"},
+	}
+}
+
+// Capabilities returns the capabilities this tool provides.
+func (t *TailOutputTool) Capabilities() []string {
+	return []string{"log_analysis"}
+}
+
 // Execute reads the last N lines from the given file path.
 func (t *TailOutputTool) Execute(ctx context.Context, req Request) Response {
 	if t.safeMode {
@@ -56,15 +83,18 @@ func (t *TailOutputTool) Execute(ctx context.Context, req Request) Response {
 		n = int(v)
 	}
 
+	workspace := agentWorkspace(t.cfg, req.AgentID, t.workspace)
 	path = config.ExpandPath(path)
+	if !filepath.IsAbs(path) && workspace != "" {
+		path = filepath.Join(workspace, path)
+	}
 	abs, err := filepath.Abs(path)
 	if err != nil {
 		abs = path
 	}
 
 	if t.restrictToWorkspace {
-		wsAbs, _ := filepath.Abs(t.workspace)
-		if !strings.HasPrefix(abs, wsAbs) {
+		if !inWorkspace(abs, workspace) {
 			return ErrorResponse(req.ToolCallID,
 				"Path outside workspace",
 				"File path is outside the allowed workspace.",
@@ -108,3 +138,8 @@ func (t *TailOutputTool) Execute(ctx context.Context, req Request) Response {
 		fmt.Sprintf("Last %d lines from %s", len(lines[start:]), path),
 		"")
 }
+
+// ParallelSafe reports that tail_output is read-only.
+func (t *TailOutputTool) ParallelSafe() bool {
+	return true
+}
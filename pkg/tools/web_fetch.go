@@ -30,6 +30,30 @@ func NewWebFetchTool(cfg *config.Config, policyEval *policy.Evaluator, safeMode
 	}
 }
 
+// Name returns the tool name.
+func (t *WebFetchTool) Name() string { return "web_fetch" }
+
+// Description returns the tool description for the LLM.
+func (t *WebFetchTool) Description() string {
+	return "Fetch content from a URL. Use for web search or reading web pages."
+}
+
+// Schema returns the JSON schema of the tool arguments.
+func (t *WebFetchTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"url": map[string]interface{}{"type": "string", "description": "URL to fetch"},
+		},
+		"required": []interface{}{"url"},
+	}
+}
+
+// Capabilities returns the capabilities this tool provides.
+func (t *WebFetchTool) Capabilities() []string {
+	return []string{"web_search"}
+}
+
 // Execute fetches a URL and returns content.
 func (t *WebFetchTool) Execute(ctx context.Context, req Request) Response {
 	if t.safeMode {
@@ -100,6 +124,11 @@ func (t *WebFetchTool) Execute(ctx context.Context, req Request) Response {
 	return SuccessResponse(req.ToolCallID, content, fmt.Sprintf("Fetched %d bytes", len(body)), "")
 }
 
+// ParallelSafe reports that web_fetch is read-only.
+func (t *WebFetchTool) ParallelSafe() bool {
+	return true
+}
+
 func extractHost(urlStr string) string {
 	urlStr = strings.TrimSpace(urlStr)
 	if idx := strings.Index(urlStr, "://"); idx >= 0 {
@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/sypherexx/sypher-mini/pkg/audit"
+	"github.com/sypherexx/sypher-mini/pkg/config"
+	"github.com/sypherexx/sypher-mini/pkg/policy"
+)
+
+// WriteFileTool creates or overwrites a file.
+type WriteFileTool struct {
+	fileAccess
+}
+
+// NewWriteFileTool creates a write_file tool.
+func NewWriteFileTool(cfg *config.Config, policyEval *policy.Evaluator, auditLogger *audit.Logger, safeMode bool) *WriteFileTool {
+	return &WriteFileTool{fileAccess: newFileAccess(cfg, policyEval, auditLogger, safeMode)}
+}
+
+// Name returns the tool name.
+func (t *WriteFileTool) Name() string { return "write_file" }
+
+// Description returns the tool description for the LLM.
+func (t *WriteFileTool) Description() string {
+	return "Create or overwrite a file with the given content. Parent directories are created. Prefer edit_file for changes to existing files."
+}
+
+// Schema returns the JSON schema of the tool arguments.
+func (t *WriteFileTool) Schema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path":    map[string]interface{}{"type": "string", "description": "File path (relative to the workspace or absolute)"},
+			"content": map[string]interface{}{"type": "string", "description": "Full file content"},
+		},
+		"required": []interface{}{"path", "content"},
+	}
+}
+
+// Capabilities returns the capabilities this tool provides.
+func (t *WriteFileTool) Capabilities() []string {
+	return []string{"code_generation", "file_edit"}
+}
+
+// Execute writes the file.
+func (t *WriteFileTool) Execute(ctx context.Context, req Request) Response {
+	if t.safeMode {
+		return safeModeError(req, "write_file")
+	}
+	p, _ := req.Args["path"].(string)
+	if p == "" {
+		return ErrorResponse(req.ToolCallID,
+			"Missing 'path' argument",
+			"Path is required.",
+			CodePermissionDenied, false)
+	}
+	content, ok := req.Args["content"].(string)
+	if !ok {
+		return ErrorResponse(req.ToolCallID,
+			"Missing 'content' argument",
+			"Content is required.",
+			CodePermissionDenied, false)
+	}
+	if len(content) > maxFileBytes {
+		return ErrorResponse(req.ToolCallID,
+			fmt.Sprintf("Content too large (%d bytes, max %d)", len(content), maxFileBytes),
+			"File content is too large.",
+			CodePermissionDenied, false)
+	}
+	path, denied := t.resolve(req, p, accessWrite)
+	if denied != nil {
+		return *denied
+	}
+
+	_, statErr := os.Stat(path)
+	created := os.IsNotExist(statErr)
+	if err := writeFileAtomic(path, []byte(content)); err != nil {
+		return fileError(req, fmt.Errorf("write %s: %w", p, err), "Could not write file.")
+	}
+
+	auditRef := t.audit(req, "write_file", path, fmt.Sprintf("bytes=%d created=%t", len(content), created))
+	verb := "Updated"
+	if created {
+		verb = "Created"
+	}
+	msg := fmt.Sprintf("%s %s (%d bytes)", verb, p, len(content))
+	return SuccessResponse(req.ToolCallID, msg, msg, auditRef)
+}
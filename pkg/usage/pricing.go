@@ -0,0 +1,97 @@
+package usage
+
+import (
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+)
+
+// Price is a model price in USD per million tokens.
+type Price struct {
+	InputPerMTok  float64
+	OutputPerMTok float64
+}
+
+// DefaultPricing holds built-in list prices keyed by "provider/model".
+// Local providers are free. A provider's "*" entry prices its unlisted
+// models at its most expensive listed model, so budgets still trigger.
+var DefaultPricing = map[string]Price{
+	"cerebras/llama-3.1-70b":               {InputPerMTok: 0.60, OutputPerMTok: 0.60},
+	"cerebras/llama-3.1-8b":                {InputPerMTok: 0.10, OutputPerMTok: 0.10},
+	"openai/gpt-4o-mini":                   {InputPerMTok: 0.15, OutputPerMTok: 0.60},
+	"openai/gpt-4o":                        {InputPerMTok: 2.50, OutputPerMTok: 10.00},
+	"anthropic/claude-3-5-sonnet-20241022": {InputPerMTok: 3.00, OutputPerMTok: 15.00},
+	"anthropic/claude-3-5-haiku-20241022":  {InputPerMTok: 0.80, OutputPerMTok: 4.00},
+	"gemini/gemini-1.5-flash":              {InputPerMTok: 0.075, OutputPerMTok: 0.30},
+	"gemini/gemini-1.5-pro":                {InputPerMTok: 1.25, OutputPerMTok: 5.00},
+	"cerebras/*":                           {InputPerMTok: 0.60, OutputPerMTok: 0.60},
+	"openai/*":                             {InputPerMTok: 2.50, OutputPerMTok: 10.00},
+	"anthropic/*":                          {InputPerMTok: 3.00, OutputPerMTok: 15.00},
+	"gemini/*":                             {InputPerMTok: 1.25, OutputPerMTok: 5.00},
+	"ollama/*":                             {},
+	"llamacpp/*":                           {},
+}
+
+// FallbackPrice prices models of remote providers without any entry.
+var FallbackPrice = Price{InputPerMTok: 3.00, OutputPerMTok: 15.00}
+
+// Pricing turns token counts into estimated cost.
+type Pricing struct {
+	prices     map[string]Price
+	configured map[string]bool // keys set by usage.pricing
+
+	mu     sync.Mutex
+	warned map[string]bool
+}
+
+// NewPricing creates a pricing table from DefaultPricing plus usage.pricing overrides.
+func NewPricing(cfg *config.Config) *Pricing {
+	prices := make(map[string]Price, len(DefaultPricing))
+	for k, v := range DefaultPricing {
+		prices[k] = v
+	}
+	configured := make(map[string]bool)
+	if cfg != nil {
+		for _, p := range cfg.Usage.Pricing {
+			key := priceKey(p.Provider, p.Model)
+			prices[key] = Price{InputPerMTok: p.InputPerMTok, OutputPerMTok: p.OutputPerMTok}
+			configured[key] = true
+		}
+	}
+	return &Pricing{prices: prices, configured: configured, warned: make(map[string]bool)}
+}
+
+// Cost returns the estimated USD cost of a request. The provider's "*" entry
+// is used for models without their own price, and FallbackPrice for
+// providers without one. Estimated prices are logged once per model.
+func (p *Pricing) Cost(provider, model string, promptTokens, completionTokens int) float64 {
+	price, ok := p.prices[priceKey(provider, model)]
+	if !ok {
+		wildcard := priceKey(provider, "*")
+		price, ok = p.prices[wildcard]
+		if !ok {
+			price = FallbackPrice
+		}
+		if !p.configured[wildcard] && price != (Price{}) {
+			p.warnOnce(provider, model, price)
+		}
+	}
+	return (float64(promptTokens)*price.InputPerMTok + float64(completionTokens)*price.OutputPerMTok) / 1e6
+}
+
+func priceKey(provider, model string) string {
+	return strings.ToLower(provider) + "/" + model
+}
+
+func (p *Pricing) warnOnce(provider, model string, price Price) {
+	key := priceKey(provider, model)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.warned[key] {
+		return
+	}
+	p.warned[key] = true
+	log.Printf("usage: no price for %s; estimating $%.2f/$%.2f per million input/output tokens (set usage.pricing)", key, price.InputPerMTok, price.OutputPerMTok)
+}
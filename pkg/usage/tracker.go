@@ -0,0 +1,285 @@
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+)
+
+// Record is the token usage of one LLM response.
+type Record struct {
+	Time             time.Time `json:"time"`
+	TaskID           string    `json:"task_id"`
+	AgentID          string    `json:"agent_id"`
+	SessionKey       string    `json:"session_key"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+}
+
+// Tracker records usage to monthly JSONL files ({dir}/usage-YYYY-MM.jsonl)
+// and keeps the current day's and month's spend per agent for budget checks.
+type Tracker struct {
+	dir     string
+	pricing *Pricing
+	budgets []config.Budget
+	now     func() time.Time
+
+	mu      sync.Mutex
+	day     string
+	month   string
+	daily   map[string]float64 // agentID -> USD today
+	monthly map[string]float64 // agentID -> USD this month
+}
+
+// NewTracker creates a tracker and loads the current month's spend.
+func NewTracker(cfg *config.Config) *Tracker {
+	t := &Tracker{
+		dir:     Dir(cfg),
+		pricing: NewPricing(cfg),
+		budgets: cfg.Usage.Budgets,
+		now:     time.Now,
+	}
+	t.reset(t.now())
+	records, err := Load(t.dir, monthStart(t.now()))
+	if err == nil {
+		for _, r := range records {
+			t.accumulate(r)
+		}
+	}
+	return t
+}
+
+// Dir returns the usage directory: usage.dir, or ~/.sypher-mini/usage next
+// to the audit logs, outside any agent's workspace.
+func Dir(cfg *config.Config) string {
+	if cfg.Usage.Dir != "" {
+		return config.ExpandPath(cfg.Usage.Dir)
+	}
+	return config.ExpandPath("~/.sypher-mini/usage")
+}
+
+// Add prices r, persists it and returns it with Time and CostUSD set.
+func (t *Tracker) Add(r Record) (Record, error) {
+	if r.Time.IsZero() {
+		r.Time = t.now()
+	}
+	r.CostUSD = t.pricing.Cost(r.Provider, r.Model, r.PromptTokens, r.CompletionTokens)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.accumulate(r)
+	if err := os.MkdirAll(t.dir, 0755); err != nil {
+		return r, err
+	}
+	f, err := os.OpenFile(filepath.Join(t.dir, fileName(r.Time)), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return r, err
+	}
+	defer f.Close()
+	data, err := json.Marshal(r)
+	if err != nil {
+		return r, err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return r, err
+}
+
+// Exceeded describes a budget that has been used up.
+type Exceeded struct {
+	Budget config.Budget
+	Period string // "daily" or "monthly"
+	Spent  float64
+	Limit  float64
+}
+
+func (e *Exceeded) Error() string {
+	who := "agent " + e.Budget.AgentID
+	if e.Budget.AgentID == "*" {
+		who = "global"
+	}
+	return fmt.Sprintf("%s %s budget exceeded ($%.2f of $%.2f)", who, e.Period, e.Spent, e.Limit)
+}
+
+// Downgrade reports whether the budget downgrades to a cheaper model instead of stopping.
+func (e *Exceeded) Downgrade() bool {
+	return e.Budget.Action == "downgrade" && e.Budget.DowngradeModel != ""
+}
+
+// Check returns the first exceeded budget that applies to agentID, or nil.
+// A "stop" budget is reported before a "downgrade" one.
+func (t *Tracker) Check(agentID string) *Exceeded {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover(t.now())
+	var downgrade *Exceeded
+	for _, b := range t.budgets {
+		if b.AgentID != "*" && b.AgentID != agentID {
+			continue
+		}
+		key := b.AgentID
+		if key != "*" {
+			key = agentID
+		}
+		var ex *Exceeded
+		if b.DailyUSD > 0 && t.daily[key] >= b.DailyUSD {
+			ex = &Exceeded{Budget: b, Period: "daily", Spent: t.daily[key], Limit: b.DailyUSD}
+		} else if b.MonthlyUSD > 0 && t.monthly[key] >= b.MonthlyUSD {
+			ex = &Exceeded{Budget: b, Period: "monthly", Spent: t.monthly[key], Limit: b.MonthlyUSD}
+		}
+		if ex == nil {
+			continue
+		}
+		if !ex.Downgrade() {
+			return ex
+		}
+		if downgrade == nil {
+			downgrade = ex
+		}
+	}
+	return downgrade
+}
+
+// Spent returns today's and this month's spend for agentID ("*" = all agents).
+func (t *Tracker) Spent(agentID string) (daily, monthly float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rollover(t.now())
+	return t.daily[agentID], t.monthly[agentID]
+}
+
+// accumulate adds r to the running totals if it falls in the current period.
+// Callers hold mu (or own t exclusively).
+func (t *Tracker) accumulate(r Record) {
+	t.rollover(t.now())
+	if r.Time.Format("2006-01") != t.month {
+		return
+	}
+	t.monthly[r.AgentID] += r.CostUSD
+	t.monthly["*"] += r.CostUSD
+	if r.Time.Format("2006-01-02") == t.day {
+		t.daily[r.AgentID] += r.CostUSD
+		t.daily["*"] += r.CostUSD
+	}
+}
+
+func (t *Tracker) rollover(now time.Time) {
+	if now.Format("2006-01-02") == t.day {
+		return
+	}
+	if now.Format("2006-01") != t.month {
+		t.reset(now)
+		return
+	}
+	t.day = now.Format("2006-01-02")
+	t.daily = make(map[string]float64)
+}
+
+func (t *Tracker) reset(now time.Time) {
+	t.day = now.Format("2006-01-02")
+	t.month = now.Format("2006-01")
+	t.daily = make(map[string]float64)
+	t.monthly = make(map[string]float64)
+}
+
+func fileName(t time.Time) string {
+	return "usage-" + t.Format("2006-01") + ".jsonl"
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// Load reads all records at or after since from dir.
+func Load(dir string, since time.Time) ([]Record, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "usage-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	first := fileName(since)
+	var out []Record
+	for _, path := range matches {
+		if !since.IsZero() && filepath.Base(path) < first {
+			continue
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			var r Record
+			if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+				continue
+			}
+			if !r.Time.Before(since) {
+				out = append(out, r)
+			}
+		}
+		f.Close()
+	}
+	return out, nil
+}
+
+// Summary aggregates records that share a key.
+type Summary struct {
+	Key              string
+	Requests         int
+	PromptTokens     int
+	CompletionTokens int
+	CostUSD          float64
+}
+
+// Summarize groups records by "agent", "session", "task", "provider" or "model",
+// most expensive first.
+func Summarize(records []Record, by string) ([]Summary, error) {
+	key := func(r Record) string { return r.AgentID }
+	switch strings.ToLower(by) {
+	case "", "agent":
+	case "session":
+		key = func(r Record) string { return r.SessionKey }
+	case "task":
+		key = func(r Record) string { return r.TaskID }
+	case "provider":
+		key = func(r Record) string { return r.Provider }
+	case "model":
+		key = func(r Record) string { return r.Provider + "/" + r.Model }
+	default:
+		return nil, fmt.Errorf("unknown grouping %q (agent, session, task, provider, model)", by)
+	}
+	byKey := make(map[string]*Summary)
+	for _, r := range records {
+		k := key(r)
+		s, ok := byKey[k]
+		if !ok {
+			s = &Summary{Key: k}
+			byKey[k] = s
+		}
+		s.Requests++
+		s.PromptTokens += r.PromptTokens
+		s.CompletionTokens += r.CompletionTokens
+		s.CostUSD += r.CostUSD
+	}
+	out := make([]Summary, 0, len(byKey))
+	for _, s := range byKey {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CostUSD != out[j].CostUSD {
+			return out[i].CostUSD > out[j].CostUSD
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out, nil
+}
@@ -0,0 +1,71 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sypherexx/sypher-mini/pkg/config"
+)
+
+func TestPricing_Cost(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Usage.Pricing = []config.ModelPrice{{Provider: "openai", Model: "*", InputPerMTok: 1, OutputPerMTok: 2}}
+	p := NewPricing(cfg)
+	if got := p.Cost("openai", "gpt-4o", 1000000, 100000); got != 3.5 {
+		t.Errorf("gpt-4o cost = %v, want 3.5", got)
+	}
+	if got := p.Cost("openai", "o1-preview", 1000000, 1000000); got != 3 {
+		t.Errorf("wildcard cost = %v, want 3", got)
+	}
+	if got := p.Cost("ollama", "qwen2.5", 1000000, 1000000); got != 0 {
+		t.Errorf("local cost = %v, want 0", got)
+	}
+	if got := p.Cost("anthropic", "claude-new", 1000000, 0); got != 3 {
+		t.Errorf("unlisted anthropic model cost = %v, want provider default 3", got)
+	}
+	if got := p.Cost("openrouter", "some/model", 1000000, 1000000); got != FallbackPrice.InputPerMTok+FallbackPrice.OutputPerMTok {
+		t.Errorf("unpriced remote model cost = %v, want fallback", got)
+	}
+}
+
+func TestTracker_PersistAndSummarize(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Usage.Dir = t.TempDir()
+	cfg.Usage.Budgets = []config.Budget{{AgentID: "main", MonthlyUSD: 0.5}}
+	tr := NewTracker(cfg)
+	for _, r := range []Record{
+		{TaskID: "t1", AgentID: "main", SessionKey: "s1", Provider: "openai", Model: "gpt-4o-mini", PromptTokens: 1000000},
+		{TaskID: "t1", AgentID: "main", SessionKey: "s1", Provider: "openai", Model: "gpt-4o-mini", CompletionTokens: 1000000},
+		{TaskID: "t2", AgentID: "coding", SessionKey: "s2", Provider: "cerebras", Model: "llama-3.1-8b", PromptTokens: 500000},
+	} {
+		if _, err := tr.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ex := tr.Check("main"); ex == nil || ex.Period != "monthly" {
+		t.Errorf("expected main monthly budget exceeded, got %v", ex)
+	}
+	if ex := tr.Check("coding"); ex != nil {
+		t.Errorf("coding has no budget, got %v", ex)
+	}
+
+	// A new tracker picks up this month's spend from disk
+	if _, monthly := NewTracker(cfg).Spent("*"); monthly < 0.79 || monthly > 0.81 {
+		t.Errorf("reloaded monthly spend = %v, want 0.80", monthly)
+	}
+
+	records, err := Load(cfg.Usage.Dir, time.Time{})
+	if err != nil || len(records) != 3 {
+		t.Fatalf("Load = %d records, %v", len(records), err)
+	}
+	sums, err := Summarize(records, "task")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sums) != 2 || sums[0].Key != "t1" || sums[0].Requests != 2 || sums[0].CompletionTokens != 1000000 {
+		t.Errorf("unexpected summary: %+v", sums)
+	}
+	if _, err := Summarize(records, "color"); err == nil {
+		t.Error("expected error for unknown grouping")
+	}
+}
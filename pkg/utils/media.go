@@ -44,16 +44,22 @@ func SanitizeFilename(filename string) string {
 type DownloadOptions struct {
 	Timeout      time.Duration
 	ExtraHeaders map[string]string
+	Dir          string       // target directory; defaults to a temp directory
+	MaxBytes     int64        // reject larger files; 0 means no limit
+	Client       *http.Client // used instead of a default client with Timeout
 }
 
-// DownloadFile downloads a file from URL to a local temp directory.
-// Returns the local file path or empty string on error.
+// DownloadFile downloads a file from URL to a local directory (opts.Dir, or
+// a temp directory). Returns the local file path or empty string on error.
 func DownloadFile(url, filename string, opts DownloadOptions) string {
 	if opts.Timeout == 0 {
 		opts.Timeout = 60 * time.Second
 	}
 
-	mediaDir := filepath.Join(os.TempDir(), "sypher-mini_media")
+	mediaDir := opts.Dir
+	if mediaDir == "" {
+		mediaDir = filepath.Join(os.TempDir(), "sypher-mini_media")
+	}
 	if err := os.MkdirAll(mediaDir, 0700); err != nil {
 		return ""
 	}
@@ -70,7 +76,10 @@ func DownloadFile(url, filename string, opts DownloadOptions) string {
 		req.Header.Set(key, value)
 	}
 
-	client := &http.Client{Timeout: opts.Timeout}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: opts.Timeout}
+	}
 	resp, err := client.Do(req)
 	if err != nil {
 		return ""
@@ -87,7 +96,12 @@ func DownloadFile(url, filename string, opts DownloadOptions) string {
 	}
 	defer out.Close()
 
-	if _, err := io.Copy(out, resp.Body); err != nil {
+	var body io.Reader = resp.Body
+	if opts.MaxBytes > 0 {
+		body = io.LimitReader(resp.Body, opts.MaxBytes+1)
+	}
+	n, err := io.Copy(out, body)
+	if err != nil || (opts.MaxBytes > 0 && n > opts.MaxBytes) {
 		out.Close()
 		os.Remove(localPath)
 		return ""